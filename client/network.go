@@ -31,3 +31,15 @@ func (r *NetworkRequester) Status() (*types.ApiResponse[swapi.NetworkStatusData]
 	args := map[string]string{}
 	return client.SendGetRequest[swapi.NetworkStatusData](r, "status", "Status", args)
 }
+
+// Get the StakeWise Keeper's oracle config as of the last block the daemon scanned, along with the changes it has seen
+func (r *NetworkRequester) OracleConfig() (*types.ApiResponse[swapi.NetworkOracleConfigData], error) {
+	args := map[string]string{}
+	return client.SendGetRequest[swapi.NetworkOracleConfigData](r, "oracle-config", "OracleConfig", args)
+}
+
+// Get the on-chain health of each vault in this deployment, along with an APR estimate
+func (r *NetworkRequester) VaultReport() (*types.ApiResponse[swapi.NetworkVaultReportData], error) {
+	args := map[string]string{}
+	return client.SendGetRequest[swapi.NetworkVaultReportData](r, "vault-report", "VaultReport", args)
+}
@@ -1,6 +1,10 @@
 package swclient
 
 import (
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
 	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
 	"github.com/rocket-pool/node-manager-core/api/client"
 	"github.com/rocket-pool/node-manager-core/api/types"
@@ -36,6 +40,27 @@ func (r *ServiceRequester) GetNetworkSettings() (*types.ApiResponse[swapi.Servic
 	return client.SendGetRequest[swapi.ServiceGetNetworkSettingsData](r, "get-network-settings", "GetNetworkSettings", nil)
 }
 
+// Gets the audit records of the validators requests the relay has handled, oldest first.
+// If start or end are provided, only requests received at or after start and before end will be returned.
+// If vault is provided, only requests for that vault will be returned.
+// If limit is provided, only the latest limit requests will be returned; otherwise the daemon's default limit is used.
+func (r *ServiceRequester) RelayHistory(start *time.Time, end *time.Time, vault *common.Address, limit *uint64) (*types.ApiResponse[swapi.ServiceRelayHistoryData], error) {
+	args := map[string]string{}
+	if start != nil {
+		args["start"] = start.Format(time.RFC3339)
+	}
+	if end != nil {
+		args["end"] = end.Format(time.RFC3339)
+	}
+	if vault != nil {
+		args["vault"] = vault.Hex()
+	}
+	if limit != nil {
+		args["limit"] = strconv.FormatUint(*limit, 10)
+	}
+	return client.SendGetRequest[swapi.ServiceRelayHistoryData](r, "relay-history", "RelayHistory", args)
+}
+
 // Gets the version of the daemon
 func (r *ServiceRequester) Version() (*types.ApiResponse[swapi.ServiceVersionData], error) {
 	return client.SendGetRequest[swapi.ServiceVersionData](r, "version", "Version", nil)
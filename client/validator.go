@@ -30,12 +30,26 @@ func (r *ValidatorRequester) GetContext() client.IRequesterContext {
 	return r.context
 }
 
+// Submit presigned exits from an encrypted exit archive to the Beacon Chain.
+// If pubkeys are provided, only the exits for those validators will be broadcast; otherwise every exit in the archive will be.
+func (r *ValidatorRequester) BroadcastExits(archive swapi.EncryptedExitArchive, password string, pubkeys []beacon.ValidatorPubkey) (*types.ApiResponse[swapi.ValidatorBroadcastExitsData], error) {
+	body := swapi.ValidatorBroadcastExitsBody{
+		Password: password,
+		Archive:  archive,
+		Pubkeys:  pubkeys,
+	}
+	return client.SendPostRequest[swapi.ValidatorBroadcastExitsData](r, "broadcast-exits", "BroadcastExits", body)
+}
+
 // Exit the provided validators from the Beacon Chain (or simply return their signed exit messages for later use without broadcasting),
 // with an optional epoch parameter. If not specified, the epoch from the current chain head will be used.
-func (r *ValidatorRequester) Exit(pubkeys []beacon.ValidatorPubkey, epoch *uint64, noBroadcastBool bool) (*types.ApiResponse[swapi.ValidatorExitData], error) {
+// Each validator is checked first to make sure it can exit; ones that fail the checks won't be broadcast.
+// If dryRun is set, only the checks are run and nothing is signed or broadcast.
+func (r *ValidatorRequester) Exit(pubkeys []beacon.ValidatorPubkey, epoch *uint64, noBroadcastBool bool, dryRun bool) (*types.ApiResponse[swapi.ValidatorExitData], error) {
 	args := map[string]string{
 		"pubkeys":      client.MakeBatchArg(pubkeys),
 		"no-broadcast": strconv.FormatBool(noBroadcastBool),
+		"dry-run":      strconv.FormatBool(dryRun),
 	}
 	if epoch != nil {
 		args["epoch"] = strconv.FormatUint(*epoch, 10)
@@ -43,6 +57,28 @@ func (r *ValidatorRequester) Exit(pubkeys []beacon.ValidatorPubkey, epoch *uint6
 	return client.SendGetRequest[swapi.ValidatorExitData](r, "exit", "Exit", args)
 }
 
+// Presign voluntary exits for all of the node's active validators and return them in an archive encrypted with the provided password.
+// The exits are signed with the Capella domain so they remain valid after future forks.
+func (r *ValidatorRequester) ExportExits(password string) (*types.ApiResponse[swapi.ValidatorExportExitsData], error) {
+	body := swapi.ValidatorExportExitsBody{
+		Password: password,
+	}
+	return client.SendPostRequest[swapi.ValidatorExportExitsData](r, "export-exits", "ExportExits", body)
+}
+
+// Get the performance of the node's NodeSet-registered validators over the last number of days, estimated from the daemon's balance snapshots.
+// Rewards are grouped by the provided period. If pubkeys are provided, only those validators will be returned; otherwise every validator with snapshots will be.
+func (r *ValidatorRequester) Performance(pubkeys []beacon.ValidatorPubkey, period swapi.PerformancePeriod, days uint64) (*types.ApiResponse[swapi.ValidatorPerformanceData], error) {
+	args := map[string]string{
+		"period": string(period),
+		"days":   strconv.FormatUint(days, 10),
+	}
+	if len(pubkeys) > 0 {
+		args["pubkeys"] = client.MakeBatchArg(pubkeys)
+	}
+	return client.SendGetRequest[swapi.ValidatorPerformanceData](r, "performance", "Performance", args)
+}
+
 // Get the status on Beacon for all of the validator keys that have been registered with StakeWise.
 // If vault is provided, only the keys in that vault will be returned.
 // Otherwise the keys for all vaults will be returned.
@@ -53,3 +89,13 @@ func (r *ValidatorRequester) Status(vault *common.Address) (*types.ApiResponse[s
 	}
 	return client.SendGetRequest[swapi.ValidatorStatusData](r, "status", "Status", args)
 }
+
+// Generate deposit data that adds the provided amount, in gwei, to each of the provided compounding (0x02) validators.
+// The deposit data is only generated, not submitted to the Beacon deposit contract.
+func (r *ValidatorRequester) TopUp(pubkeys []beacon.ValidatorPubkey, amount uint64) (*types.ApiResponse[swapi.ValidatorTopUpData], error) {
+	args := map[string]string{
+		"pubkeys": client.MakeBatchArg(pubkeys),
+		"amount":  strconv.FormatUint(amount, 10),
+	}
+	return client.SendGetRequest[swapi.ValidatorTopUpData](r, "top-up", "TopUp", args)
+}
@@ -3,6 +3,7 @@ package swclient
 import (
 	"strconv"
 
+	"github.com/ethereum/go-ethereum/common"
 	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
 	"github.com/rocket-pool/node-manager-core/api/client"
 	"github.com/rocket-pool/node-manager-core/api/types"
@@ -29,12 +30,22 @@ func (r *WalletRequester) GetContext() client.IRequesterContext {
 	return r.context
 }
 
-// Generate and save new validator keys
-func (r *WalletRequester) GenerateKeys(count uint64, restartVc bool) (*types.ApiResponse[swapi.WalletGenerateKeysData], error) {
+// Get the node's claimable vault token and ETH rewards from the SplitWarehouse, along with the TX info for withdrawing them.
+// The returned transaction can be submitted with Hyperdrive's transaction routes.
+func (r *WalletRequester) ClaimRewards() (*types.ApiResponse[swapi.WalletClaimRewardsData], error) {
+	return client.SendGetRequest[swapi.WalletClaimRewardsData](r, "claim-rewards", "ClaimRewards", nil)
+}
+
+// Generate and save new validator keys.
+// If vault is provided, the keys will be reserved for that vault; otherwise they can be used for any vault.
+func (r *WalletRequester) GenerateKeys(count uint64, restartVc bool, vault *common.Address) (*types.ApiResponse[swapi.WalletGenerateKeysData], error) {
 	args := map[string]string{
 		"count":      strconv.FormatUint(count, 10),
 		"restart-vc": strconv.FormatBool(restartVc),
 	}
+	if vault != nil {
+		args["vault"] = vault.Hex()
+	}
 	return client.SendGetRequest[swapi.WalletGenerateKeysData](r, "generate-keys", "GenerateKeys", args)
 }
 
@@ -43,22 +54,107 @@ func (r *WalletRequester) Initialize() (*types.ApiResponse[swapi.WalletInitializ
 	return client.SendGetRequest[swapi.WalletInitializeData](r, "initialize", "Initialize", nil)
 }
 
-// Get the keys that are available for new deposits
-func (r *WalletRequester) GetAvailableKeys(lookback bool) (*types.ApiResponse[swapi.WalletGetAvailableKeysData], error) {
+// Get the keys that are available for new deposits.
+// If vault is provided, only keys reserved for that vault or not reserved for any vault will be available.
+func (r *WalletRequester) GetAvailableKeys(lookback bool, vault *common.Address) (*types.ApiResponse[swapi.WalletGetAvailableKeysData], error) {
 	args := map[string]string{
 		"lookback": strconv.FormatBool(lookback),
 	}
+	if vault != nil {
+		args["vault"] = vault.Hex()
+	}
 	return client.SendGetRequest[swapi.WalletGetAvailableKeysData](r, "get-available-keys", "GetAvailableKeys", args)
 }
 
-// Attempt to regenerate the private BLS keys for the given pubkeys using the provided search parameters
-func (r *WalletRequester) RecoverKeys(pubkeys []beacon.ValidatorPubkey, startIndex uint64, count uint64, searchLimit uint64, restartVc bool) (*types.ApiResponse[swapi.WalletRecoverKeysData], error) {
+// Get the deposit data for the available keys that can be used with the provided vault, along with its validators root.
+// If vault is nil, the vault in the module's network settings is used. If no pubkeys are provided, every key that can be used with the vault is included.
+func (r *WalletRequester) DepositData(vault *common.Address, pubkeys []beacon.ValidatorPubkey) (*types.ApiResponse[swapi.WalletDepositDataData], error) {
+	args := map[string]string{}
+	if vault != nil {
+		args["vault"] = vault.Hex()
+	}
+	if len(pubkeys) > 0 {
+		args["pubkeys"] = client.MakeBatchArg(pubkeys)
+	}
+	return client.SendGetRequest[swapi.WalletDepositDataData](r, "deposit-data", "DepositData", args)
+}
+
+// Get the lifecycle history of the provided keys.
+// If no pubkeys are provided, the lifecycle of every known key is returned.
+func (r *WalletRequester) KeyLifecycle(pubkeys []beacon.ValidatorPubkey) (*types.ApiResponse[swapi.WalletKeyLifecycleData], error) {
+	args := map[string]string{}
+	if len(pubkeys) > 0 {
+		args["pubkeys"] = client.MakeBatchArg(pubkeys)
+	}
+	return client.SendGetRequest[swapi.WalletKeyLifecycleData](r, "key-lifecycle", "KeyLifecycle", args)
+}
+
+// Move the provided keys into the pool for the given vault.
+// If vault is nil, the keys will no longer be reserved for any vault.
+func (r *WalletRequester) MoveKeys(pubkeys []beacon.ValidatorPubkey, vault *common.Address) (*types.ApiResponse[swapi.WalletMoveKeysData], error) {
+	args := map[string]string{
+		"pubkeys": client.MakeBatchArg(pubkeys),
+	}
+	if vault != nil {
+		args["vault"] = vault.Hex()
+	}
+	return client.SendGetRequest[swapi.WalletMoveKeysData](r, "move-keys", "MoveKeys", args)
+}
+
+// Start regenerating the private BLS keys for the given pubkeys in the background using the provided search parameters.
+// Use RecoverKeysStatus to follow its progress.
+func (r *WalletRequester) RecoverKeys(pubkeys []beacon.ValidatorPubkey, startIndex uint64, count uint64, searchLimit uint64, concurrency uint64, restartVc bool) (*types.ApiResponse[swapi.WalletRecoverKeysData], error) {
 	body := swapi.WalletRecoverKeysBody{
 		Pubkeys:     pubkeys,
 		StartIndex:  startIndex,
 		Count:       count,
 		SearchLimit: searchLimit,
+		Concurrency: concurrency,
 		RestartVc:   restartVc,
 	}
 	return client.SendPostRequest[swapi.WalletRecoverKeysData](r, "recover-keys", "RecoverKeys", body)
 }
+
+// Start regenerating the private BLS keys for every validator NodeSet has registered for this node that doesn't have a local keystore.
+// The search runs in the background until every key is found or gapLimit indices in a row don't match one; use RecoverKeysStatus to follow its progress.
+func (r *WalletRequester) RecoverAll(startIndex uint64, gapLimit uint64, searchLimit uint64, concurrency uint64, restartVc bool) (*types.ApiResponse[swapi.WalletRecoverAllData], error) {
+	body := swapi.WalletRecoverAllBody{
+		StartIndex:  startIndex,
+		GapLimit:    gapLimit,
+		SearchLimit: searchLimit,
+		Concurrency: concurrency,
+		RestartVc:   restartVc,
+	}
+	return client.SendPostRequest[swapi.WalletRecoverAllData](r, "recover-all", "RecoverAll", body)
+}
+
+// Resume a key recovery that was interrupted or failed from its last checkpoint
+func (r *WalletRequester) ResumeRecoverKeys() (*types.ApiResponse[swapi.WalletRecoverKeysData], error) {
+	body := swapi.WalletRecoverKeysBody{
+		Resume: true,
+	}
+	return client.SendPostRequest[swapi.WalletRecoverKeysData](r, "recover-keys", "RecoverKeys", body)
+}
+
+// Get the progress of the current or last key recovery
+func (r *WalletRequester) RecoverKeysStatus() (*types.ApiResponse[swapi.WalletRecoverKeysStatusData], error) {
+	return client.SendGetRequest[swapi.WalletRecoverKeysStatusData](r, "recover-keys-status", "RecoverKeysStatus", nil)
+}
+
+// Re-encrypt the validator keystores with a new random password, optionally including the VC keystores
+func (r *WalletRequester) RotateKeystorePassword(rotateVc bool, restartVc bool) (*types.ApiResponse[swapi.WalletRotateKeystorePasswordData], error) {
+	body := swapi.WalletRotateKeystorePasswordBody{
+		RotateVc:  rotateVc,
+		RestartVc: restartVc,
+	}
+	return client.SendPostRequest[swapi.WalletRotateKeystorePasswordData](r, "rotate-keystore-password", "RotateKeystorePassword", body)
+}
+
+// Check every validator key's keystores for problems, optionally re-deriving the keys that have broken or missing keystores
+func (r *WalletRequester) Audit(repair bool, restartVc bool) (*types.ApiResponse[swapi.WalletAuditData], error) {
+	body := swapi.WalletAuditBody{
+		Repair:    repair,
+		RestartVc: restartVc,
+	}
+	return client.SendPostRequest[swapi.WalletAuditData](r, "audit", "Audit", body)
+}
@@ -0,0 +1,160 @@
+package swcommon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// How long to wait before sending the same alert again if the problem hasn't been resolved
+	alertRepeatInterval time.Duration = 6 * time.Hour
+
+	// How long each notifier gets to deliver an alert
+	notifierTimeout time.Duration = 15 * time.Second
+)
+
+// The kind of problem an alert is for
+type AlertType string
+
+const (
+	AlertType_RelayFailure     AlertType = "relay_failure"
+	AlertType_KeyPoolExhausted AlertType = "key_pool_exhausted"
+	AlertType_LowBalance       AlertType = "low_balance"
+	AlertType_ValidatorOffline AlertType = "validator_offline"
+	AlertType_ValidatorSlashed AlertType = "validator_slashed"
+	AlertType_ClientOutOfSync  AlertType = "client_out_of_sync"
+	AlertType_OracleConfig     AlertType = "oracle_config"
+)
+
+// How urgent an alert is
+type AlertSeverity string
+
+const (
+	AlertSeverity_Warning  AlertSeverity = "warning"
+	AlertSeverity_Critical AlertSeverity = "critical"
+)
+
+// A notification about a problem with the node
+type Alert struct {
+	// The kind of problem
+	Type AlertType `json:"type"`
+
+	// How urgent the problem is
+	Severity AlertSeverity `json:"severity"`
+
+	// What the problem is about, such as a validator pubkey or client name, so separate instances of the same type can be tracked separately
+	Subject string `json:"subject"`
+
+	// A short summary of the problem
+	Title string `json:"title"`
+
+	// The details of the problem
+	Message string `json:"message"`
+
+	// When the alert was raised; set automatically when it's sent
+	Time time.Time `json:"time"`
+}
+
+// A backend that can deliver alerts
+type INotifier interface {
+	// The name of the backend, for logging
+	GetName() string
+
+	// Deliver an alert
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// AlertManager delivers alerts to every configured notifier.
+// Repeats of an unresolved alert are suppressed until the repeat interval has passed.
+type AlertManager struct {
+	ctx       context.Context
+	notifiers []INotifier
+	lock      *sync.Mutex
+	lastSent  map[string]time.Time
+}
+
+// Creates a new alert manager with the notifiers enabled in the config
+func NewAlertManager(sp IStakeWiseServiceProvider) *AlertManager {
+	cfg := sp.GetConfig()
+	notifiers := []INotifier{}
+	if cfg.AlertWebhookUrl.Value != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(cfg.AlertWebhookUrl.Value))
+	}
+	if cfg.AlertSmtpServer.Value != "" {
+		recipients := []string{}
+		for _, recipient := range strings.Split(cfg.AlertSmtpTo.Value, ",") {
+			recipient = strings.TrimSpace(recipient)
+			if recipient != "" {
+				recipients = append(recipients, recipient)
+			}
+		}
+		notifiers = append(notifiers, NewSmtpNotifier(cfg.AlertSmtpServer.Value, cfg.AlertSmtpUsername.Value, cfg.AlertSmtpPassword.Value, cfg.AlertSmtpFrom.Value, recipients))
+	}
+	if cfg.EnableAlertFile.Value {
+		notifiers = append(notifiers, NewFileNotifier(filepath.Join(sp.GetModuleDir(), swconfig.AlertsFile)))
+	}
+	return &AlertManager{
+		ctx:       sp.GetBaseContext(),
+		notifiers: notifiers,
+		lock:      &sync.Mutex{},
+		lastSent:  map[string]time.Time{},
+	}
+}
+
+// Check if any notifiers are configured
+func (m *AlertManager) IsEnabled() bool {
+	return len(m.notifiers) > 0
+}
+
+// Send an alert to all of the notifiers in the background.
+// If the same alert was sent recently and hasn't been resolved since, it's skipped.
+func (m *AlertManager) Send(logger *slog.Logger, alert Alert) {
+	if len(m.notifiers) == 0 {
+		return
+	}
+
+	// Check if the alert was already sent
+	alert.Time = time.Now().UTC()
+	key := getAlertKey(alert.Type, alert.Subject)
+	m.lock.Lock()
+	lastSent, exists := m.lastSent[key]
+	if exists && alert.Time.Sub(lastSent) < alertRepeatInterval {
+		m.lock.Unlock()
+		return
+	}
+	m.lastSent[key] = alert.Time
+	m.lock.Unlock()
+
+	// Deliver it
+	logger.Warn("Sending alert", "type", alert.Type, "subject", alert.Subject, "title", alert.Title)
+	for _, notifier := range m.notifiers {
+		go func(notifier INotifier) {
+			ctx, cancel := context.WithTimeout(m.ctx, notifierTimeout)
+			defer cancel()
+			err := notifier.Notify(ctx, alert)
+			if err != nil {
+				logger.Error("Error sending alert", "notifier", notifier.GetName(), "type", alert.Type, log.Err(err))
+			}
+		}(notifier)
+	}
+}
+
+// Mark an alert as resolved, so it will be sent right away if the problem comes back
+func (m *AlertManager) Resolve(alertType AlertType, subject string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.lastSent, getAlertKey(alertType, subject))
+}
+
+// Get the key used to track when an alert was last sent
+func getAlertKey(alertType AlertType, subject string) string {
+	return fmt.Sprintf("%s/%s", alertType, subject)
+}
@@ -13,11 +13,13 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/goccy/go-json"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
 	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
 	"github.com/rocket-pool/node-manager-core/beacon"
 	bclient "github.com/rocket-pool/node-manager-core/beacon/client"
-	eth2types "github.com/wealdtech/go-eth2-types/v2"
 )
 
 const (
@@ -40,8 +42,8 @@ type AvailableKey struct {
 	// The pubkey
 	PublicKey beacon.ValidatorPubkey `json:"pubkey"`
 
-	// The private key - not serialized for obvious reasons
-	PrivateKey *eth2types.BLSPrivateKey `json:"-"`
+	// True if the validator signer has the key loaded and can sign with it - not serialized since it's checked on each load
+	HasSigningKey bool `json:"-"`
 
 	// Flag indicating whether or not a deposit event scan (starting with the lookback limit) has been performed for this key
 	HasLookbackScanned bool `json:"hasLookbackScanned"`
@@ -49,6 +51,9 @@ type AvailableKey struct {
 	// If this pubkey was used already in a previous deposit attempt, this is the Beacon deposit contract's deposit root during that attempt.
 	// It's used to compare against the current deposit root to determine if the deposit was unsuccessful and the key can be reused.
 	LastDepositRoot common.Hash `json:"lastDepositRoot"`
+
+	// The vault this key is reserved for. If this is the zero address, the key isn't reserved and can be used for any vault.
+	Vault common.Address `json:"vault"`
 }
 
 // A reason why a key is ineligible for use in a deposit
@@ -69,8 +74,31 @@ const (
 
 	// The key is ineligible because it has already been used in a deposit contract event with the same deposit root
 	IneligibleReason_AlreadyUsedDepositRoot
+
+	// The key is ineligible because it has been reserved for a different vault
+	IneligibleReason_ReservedForOtherVault
 )
 
+// Get a short name for the reason, suitable for logging and metrics labels
+func (r IneligibleReason) String() string {
+	switch r {
+	case IneligibleReason_NoPrivateKey:
+		return "no_private_key"
+	case IneligibleReason_LookbackScanRequired:
+		return "lookback_scan_required"
+	case IneligibleReason_OnBeacon:
+		return "on_beacon"
+	case IneligibleReason_HasDepositEvent:
+		return "has_deposit_event"
+	case IneligibleReason_AlreadyUsedDepositRoot:
+		return "already_used_deposit_root"
+	case IneligibleReason_ReservedForOtherVault:
+		return "reserved_for_other_vault"
+	default:
+		return "unknown"
+	}
+}
+
 // AvailableKeyManager manages the keys that have been generated but not yet used for deposits
 type AvailableKeyManager struct {
 	dataPath      string
@@ -78,13 +106,20 @@ type AvailableKeyManager struct {
 	lock          *sync.Mutex
 	hasLoadedKeys bool
 
+	// Incremented whenever a key is added, moved, offered, or reloaded, so callers can tell if results they've cached are out of date
+	generation uint64
+
 	data *availableKeyManagerData
 }
 
 type availableKeyManagerData struct {
-	// The next block to scan for deposit events, assuming no new keys have been added
+	// The next block to scan for deposit events, assuming no new keys have been added.
+	// Everything before this block has been scanned and is considered settled.
 	NextBlockToScan uint64 `json:"nextBlockToScan"`
 
+	// The hash of the block before NextBlockToScan, used to detect reorgs that reach past the settled blocks
+	CheckpointHash common.Hash `json:"checkpointHash"`
+
 	// The list of available keys
 	Keys []*AvailableKey `json:"keys"`
 }
@@ -129,9 +164,18 @@ func (m *AvailableKeyManager) Reload() error {
 	}
 	m.data = data
 	m.hasLoadedKeys = false
+	m.generation++
 	return nil
 }
 
+// Get the current generation of the key list.
+// If it's changed since eligible keys were last retrieved, those keys may no longer be eligible.
+func (m *AvailableKeyManager) GetGeneration() uint64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.generation
+}
+
 // Check if the private keys have been loaded yet
 func (m *AvailableKeyManager) HasLoadedKeys() bool {
 	m.lock.Lock()
@@ -149,37 +193,46 @@ func (m *AvailableKeyManager) LoadPrivateKeys(logger *slog.Logger) {
 // Implementation of the LoadPrivateKeys function, used when the lock is already held
 func (m *AvailableKeyManager) loadPrivateKeysImpl(logger *slog.Logger) {
 	start := time.Now()
-	wallet := m.sp.GetWallet()
+	pubkeys := make([]beacon.ValidatorPubkey, len(m.data.Keys))
+	for i, key := range m.data.Keys {
+		pubkeys[i] = key.PublicKey
+	}
+	signer := m.sp.GetValidatorSigner()
+	loaded, err := signer.LoadKeys(m.sp.GetBaseContext(), pubkeys)
+	if err != nil {
+		logger.Warn(
+			"Couldn't load some private keys, they will not be eligible for deposits until this is resolved",
+			"signer", signer.GetName(),
+			"error", err,
+		)
+	}
+
 	count := 0
 	for _, key := range m.data.Keys {
-		var err error
-		key.PrivateKey, err = wallet.GetPrivateKeyForPubkey(key.PublicKey) // Set to nil on error
-		if err != nil {
-			logger.Warn(
-				"Couldn't get private key for pubkey, it will not be eligible for deposits until this is resolved",
-				"pubkey", key.PublicKey.Hex(),
-				"error", err,
-			)
-		} else {
+		key.HasSigningKey = loaded[key.PublicKey]
+		if key.HasSigningKey {
 			count++
 		}
 	}
 	logger.Debug(
 		"Loaded private keys",
+		"signer", signer.GetName(),
 		"count", count,
 		"total", len(m.data.Keys),
 		"elapsed", time.Since(start),
 	)
 	m.hasLoadedKeys = true
+	m.generation++
 }
 
-// Add a new key to the list of available keys
-func (m *AvailableKeyManager) AddNewKey(key *eth2types.BLSPrivateKey) error {
+// Add a new key to the list of available keys, reserving it for the provided vault.
+// If the vault is the zero address, the key won't be reserved and can be used for any vault.
+// The key must already be imported into the validator signer.
+func (m *AvailableKeyManager) AddNewKey(pubkey beacon.ValidatorPubkey, vault common.Address) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
 	// Check if the key is already in the list
-	pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
 	for _, k := range m.data.Keys {
 		if k.PublicKey == pubkey {
 			return nil
@@ -189,9 +242,11 @@ func (m *AvailableKeyManager) AddNewKey(key *eth2types.BLSPrivateKey) error {
 	// Add the new key
 	m.data.Keys = append(m.data.Keys, &AvailableKey{
 		PublicKey:          pubkey,
-		PrivateKey:         key,
+		HasSigningKey:      true,
 		HasLookbackScanned: false,
+		Vault:              vault,
 	})
+	m.generation++
 
 	// Save the new list
 	err := m.updateData()
@@ -199,9 +254,98 @@ func (m *AvailableKeyManager) AddNewKey(key *eth2types.BLSPrivateKey) error {
 		return fmt.Errorf("error updating available keys: %w", err)
 	}
 
+	// Start the key's lifecycle
+	err = m.sp.GetKeyLifecycleManager().RecordTransitions([]KeyTransition{
+		{
+			Pubkey: pubkey,
+			Event: swapi.KeyLifecycleEvent{
+				State: swapi.KeyLifecycleState_Generated,
+				Vault: vault,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error recording key lifecycle: %w", err)
+	}
+
+	return nil
+}
+
+// Move the keys with the provided pubkeys into the pool for the provided vault.
+// If the vault is the zero address, the keys will be moved into the unreserved pool and can be used for any vault.
+func (m *AvailableKeyManager) MoveKeys(pubkeys []beacon.ValidatorPubkey, vault common.Address) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Find the keys first so nothing is changed if one is missing
+	keyMap := make(map[beacon.ValidatorPubkey]*AvailableKey, len(m.data.Keys))
+	for _, key := range m.data.Keys {
+		keyMap[key.PublicKey] = key
+	}
+	keys := make([]*AvailableKey, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		key, exists := keyMap[pubkey]
+		if !exists {
+			return fmt.Errorf("key [%s] is not in the list of available keys", pubkey.HexWithPrefix())
+		}
+		keys[i] = key
+	}
+
+	// Update and save
+	for _, key := range keys {
+		key.Vault = vault
+	}
+	m.generation++
+	err := m.updateData()
+	if err != nil {
+		return fmt.Errorf("error updating available keys: %w", err)
+	}
 	return nil
 }
 
+// Get the pubkeys of all of the available keys
+func (m *AvailableKeyManager) GetPubkeys() []beacon.ValidatorPubkey {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	pubkeys := make([]beacon.ValidatorPubkey, len(m.data.Keys))
+	for i, key := range m.data.Keys {
+		pubkeys[i] = key.PublicKey
+	}
+	return pubkeys
+}
+
+// Get the keys that can be used for deposits into the provided vault, with the keys reserved for it before the unreserved keys,
+// along with the keys that are reserved for other vaults. The private keys are loaded first if they haven't been yet.
+func (m *AvailableKeyManager) GetKeysForVault(logger *slog.Logger, vault common.Address) (vaultKeys []*AvailableKey, otherVaultKeys []*AvailableKey) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.hasLoadedKeys {
+		m.loadPrivateKeysImpl(logger)
+	}
+	return m.filterKeysOnVault(m.data.Keys, vault)
+}
+
+// Get the number of available keys in each vault's pool. Keys that aren't reserved for a vault are counted under the zero address.
+func (m *AvailableKeyManager) GetPoolCounts() map[common.Address]int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	counts := map[common.Address]int{}
+	for _, key := range m.data.Keys {
+		counts[key.Vault]++
+	}
+	return counts
+}
+
+// Get the next block that will be scanned for deposit events. Every block before it has been scanned and is considered settled.
+func (m *AvailableKeyManager) GetNextBlockToScan() uint64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.data.NextBlockToScan
+}
+
 // Check if there are any candidate keys ready for validation and potential usage
 func (m *AvailableKeyManager) HasKeyCandidates() bool {
 	m.lock.Lock()
@@ -237,9 +381,14 @@ type GetAvailableKeyOptions struct {
 
 	// If false, this starts the deposit event scan from the last block that was scanned.
 	// This is the default behavior, and much faster than scanning the entire history - useful if you've already scanned the logs before.
-	// If true, this will start the scan at DepositEventLookbackLimit blocks instead.
+	// If true, this will check the entire history in the deposit event index instead, along with the Beacon chain.
+	// If the index hasn't finished building yet, the scan starts at DepositEventLookbackLimit blocks instead.
 	// Set this if you have a new key that hasn't had its history checked yet.
 	DoLookbackScan bool
+
+	// If set, only keys reserved for this vault and keys that aren't reserved for any vault will be eligible.
+	// Keys reserved for this vault will come before unreserved keys in the list of eligible keys.
+	Vault *common.Address
 }
 
 // Get the keys that can be used for new deposits from the list of available keys.
@@ -259,12 +408,30 @@ func (m *AvailableKeyManager) GetAvailableKeys(
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	logger.Debug("Got key lock", "elapsed", time.Since(start))
+	scanStart := time.Now()
 
 	// Load the keys from disk if they haven't been loaded yet
 	if !m.hasLoadedKeys {
 		m.loadPrivateKeysImpl(logger)
 	}
 
+	// Rescan if the chain has reorged past the checkpoint
+	if !options.DoLookbackScan {
+		canonical, err := m.isCheckpointCanonical(ctx, currentBlock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking scan checkpoint: %w", err)
+		}
+		if !canonical {
+			options.DoLookbackScan = true
+			logger.Warn(
+				"Scan checkpoint is no longer on the canonical chain, forcing lookback scan",
+				"checkpointBlock", m.data.NextBlockToScan-1,
+				"checkpointHash", m.data.CheckpointHash.Hex(),
+				"currentBlock", currentBlock,
+			)
+		}
+	}
+
 	// Check if a lookback scan is needed
 	startBlock := m.data.NextBlockToScan
 	if options.DoLookbackScan {
@@ -315,9 +482,10 @@ func (m *AvailableKeyManager) GetAvailableKeys(
 	}
 
 	// Remove keys that haven't been lookback scanned yet if we aren't doing one
+	var pendingKeys []*AvailableKey
 	if !options.DoLookbackScan {
-		goodKeys, badKeys = m.filterKeysOnLookbackScanned(goodKeys)
-		for _, key := range badKeys {
+		goodKeys, pendingKeys = m.filterKeysOnLookbackScanned(goodKeys)
+		for _, key := range pendingKeys {
 			ineligibleKeys[key] = IneligibleReason_LookbackScanRequired
 		}
 	}
@@ -353,11 +521,22 @@ func (m *AvailableKeyManager) GetAvailableKeys(
 	}
 
 	// Remove keys that have already been used in a deposit contract event
+	var unsettledKeys []*AvailableKey
 	if startBlock > currentBlock {
 		logger.Debug("Already scanned current block, skipping deposit event filter")
 	} else {
+		// Get the last block that can't be reorged out anymore
+		settledHeader, err := getSettledHeader(ctx, m.sp, currentBlock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting settled block: %w", err)
+		}
+		settledBlock := int64(-1)
+		if settledHeader != nil {
+			settledBlock = settledHeader.Number.Int64()
+		}
+
 		start = time.Now()
-		goodKeys, badKeys, err = m.filterKeysOnDepositEvents(ctx, logger, goodKeys, startBlock, currentBlock)
+		goodKeys, badKeys, unsettledKeys, err = m.filterKeysOnDepositEvents(ctx, logger, goodKeys, startBlock, currentBlock, settledBlock, options.DoLookbackScan)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error filtering keys via deposit contract events: %w", err)
 		}
@@ -365,19 +544,47 @@ func (m *AvailableKeyManager) GetAvailableKeys(
 		for _, key := range badKeys {
 			ineligibleKeys[key] = IneligibleReason_HasDepositEvent
 		}
-		m.data.NextBlockToScan = currentBlock + 1
+		for _, key := range unsettledKeys {
+			ineligibleKeys[key] = IneligibleReason_HasDepositEvent
+		}
+
+		// Only move the checkpoint up to the settled block so the blocks after it get scanned again
+		if settledHeader != nil {
+			m.data.NextBlockToScan = uint64(settledBlock) + 1
+			m.data.CheckpointHash = settledHeader.Hash()
+		}
 
 		// Set the lookback flag for all keys
 		if options.DoLookbackScan {
+			transitions := make([]KeyTransition, 0, len(goodKeys))
 			for _, key := range goodKeys {
 				key.HasLookbackScanned = true
+				transitions = append(transitions, KeyTransition{
+					Pubkey: key.PublicKey,
+					Event: swapi.KeyLifecycleEvent{
+						State: swapi.KeyLifecycleState_Scanned,
+					},
+				})
+			}
+			for _, key := range unsettledKeys {
+				key.HasLookbackScanned = true
+			}
+			err = m.sp.GetKeyLifecycleManager().RecordTransitions(transitions)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error recording key lifecycles: %w", err)
 			}
 		}
 	}
 
-	m.data.Keys = goodKeys // Save all of the keys before filtering by deposit root
+	// Save all of the keys before filtering by deposit root
 	// because ones with this deposit root are in the mempool and may get reverted.
 	// If that happens then they can be reused later.
+	// Keys still waiting on a lookback scan are kept too so the background scanner can get to them,
+	// as are keys with deposit events in unsettled blocks since those events could still get reorged out.
+	m.data.Keys = make([]*AvailableKey, 0, len(goodKeys)+len(pendingKeys)+len(unsettledKeys))
+	m.data.Keys = append(m.data.Keys, goodKeys...)
+	m.data.Keys = append(m.data.Keys, pendingKeys...)
+	m.data.Keys = append(m.data.Keys, unsettledKeys...)
 	start = time.Now()
 	goodKeys, badKeys = m.filterKeysOnDepositRoot(goodKeys, beaconDepositRoot)
 	logger.Debug("Filtered keys on deposit root", "available", len(goodKeys), "elapsed", time.Since(start))
@@ -385,6 +592,14 @@ func (m *AvailableKeyManager) GetAvailableKeys(
 		ineligibleKeys[key] = IneligibleReason_AlreadyUsedDepositRoot
 	}
 
+	// Remove keys that are reserved for other vaults
+	if options.Vault != nil {
+		goodKeys, badKeys = m.filterKeysOnVault(goodKeys, *options.Vault)
+		for _, key := range badKeys {
+			ineligibleKeys[key] = IneligibleReason_ReservedForOtherVault
+		}
+	}
+
 	// Save the new list
 	err = m.updateData()
 	if err != nil {
@@ -396,27 +611,60 @@ func (m *AvailableKeyManager) GetAvailableKeys(
 		"elapsed", time.Since(start),
 	)
 
+	// Update the metrics
+	metrics := m.sp.GetMetricsManager()
+	var lastScannedBlock uint64
+	if m.data.NextBlockToScan > 0 {
+		lastScannedBlock = m.data.NextBlockToScan - 1
+	}
+	metrics.RecordKeyScan(len(goodKeys), ineligibleKeys, lastScannedBlock)
+	if options.DoLookbackScan {
+		metrics.RecordLookbackScan(time.Since(scanStart))
+	}
+
 	// Return the eligible ones
 	return goodKeys, ineligibleKeys, nil
 }
 
-// Set the last deposit root for a list of keys, indicating they will be used in a new deposit
-func (m *AvailableKeyManager) SetLastDepositRoot(keys []*AvailableKey, lastDepositRoot common.Hash) error {
+// Set the last deposit root for a list of keys, indicating they will be offered to the provided vault for a new deposit
+func (m *AvailableKeyManager) SetLastDepositRoot(keys []*AvailableKey, lastDepositRoot common.Hash, vault common.Address) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
 	// Update and save
-	for _, key := range keys {
+	transitions := make([]KeyTransition, len(keys))
+	for i, key := range keys {
 		key.LastDepositRoot = lastDepositRoot
+		transitions[i] = KeyTransition{
+			Pubkey: key.PublicKey,
+			Event: swapi.KeyLifecycleEvent{
+				State:       swapi.KeyLifecycleState_Offered,
+				Vault:       vault,
+				DepositRoot: lastDepositRoot,
+			},
+		}
 	}
+	m.generation++
 	err := m.updateData()
 	if err != nil {
 		return fmt.Errorf("error updating available keys: %w", err)
 	}
 
+	// Record the offer in each key's lifecycle
+	err = m.sp.GetKeyLifecycleManager().RecordTransitions(transitions)
+	if err != nil {
+		return fmt.Errorf("error recording key lifecycles: %w", err)
+	}
+
 	return nil
 }
 
+// Tell the validator signer the key won't be used for deposits anymore
+func (m *AvailableKeyManager) unloadKey(key *AvailableKey) {
+	key.HasSigningKey = false
+	m.sp.GetValidatorSigner().UnloadKeys([]beacon.ValidatorPubkey{key.PublicKey})
+}
+
 // Filter the list of available keys to remove any that don't have a private key
 func (m *AvailableKeyManager) filterKeysOnPrivateKey(
 	keys []*AvailableKey,
@@ -427,7 +675,7 @@ func (m *AvailableKeyManager) filterKeysOnPrivateKey(
 	eligibleKeys = []*AvailableKey{}
 	ineligibleKeys = []*AvailableKey{}
 	for _, key := range keys {
-		if key.PrivateKey == nil {
+		if !key.HasSigningKey {
 			ineligibleKeys = append(ineligibleKeys, key)
 		} else {
 			eligibleKeys = append(eligibleKeys, key)
@@ -478,11 +726,19 @@ func (m *AvailableKeyManager) filterKeysOnBeacon(
 	// Ignore keys that are already active on Beacon
 	eligibleKeys = []*AvailableKey{}
 	ineligibleKeys = []*AvailableKey{}
+	transitions := []KeyTransition{}
 	for _, key := range keys {
 		status, exists := statuses[key.PublicKey]
 		if exists {
-			key.PrivateKey = nil // Empty out the private key so it's not resident in memory
+			m.unloadKey(key) // Release the private key so it's not resident in memory
 			ineligibleKeys = append(ineligibleKeys, key)
+			transitions = append(transitions, KeyTransition{
+				Pubkey: key.PublicKey,
+				Event: swapi.KeyLifecycleEvent{
+					State:          GetKeyLifecycleStateForStatus(status.Status),
+					ValidatorIndex: status.Index,
+				},
+			})
 			logger.Info(
 				"Key was found on Beacon",
 				"pubkey", key.PublicKey.Hex(),
@@ -492,6 +748,10 @@ func (m *AvailableKeyManager) filterKeysOnBeacon(
 			eligibleKeys = append(eligibleKeys, key)
 		}
 	}
+	err = m.sp.GetKeyLifecycleManager().RecordTransitions(transitions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error recording key lifecycles: %w", err)
+	}
 	logger.Info(
 		"Removed ineligible keys from Beacon scan",
 		"removed", len(ineligibleKeys),
@@ -532,6 +792,7 @@ func (m *AvailableKeyManager) filterKeysOnPendingDeposits(
 		keyMap[key.PublicKey] = key
 	}
 	removedKeys := map[beacon.ValidatorPubkey]struct{}{}
+	transitions := []KeyTransition{}
 	for _, deposit := range pendingDeposits {
 		key, exists := keyMap[deposit.Pubkey]
 		if exists {
@@ -543,11 +804,21 @@ func (m *AvailableKeyManager) filterKeysOnPendingDeposits(
 			_, removed := removedKeys[deposit.Pubkey]
 			if !removed {
 				removedKeys[deposit.Pubkey] = struct{}{}
-				key.PrivateKey = nil // Empty out the private key so it's not resident in memory
+				m.unloadKey(key) // Release the private key so it's not resident in memory
 				ineligibleKeys = append(ineligibleKeys, key)
+				transitions = append(transitions, KeyTransition{
+					Pubkey: key.PublicKey,
+					Event: swapi.KeyLifecycleEvent{
+						State: swapi.KeyLifecycleState_Pending,
+					},
+				})
 			}
 		}
 	}
+	err = m.sp.GetKeyLifecycleManager().RecordTransitions(transitions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error recording key lifecycles: %w", err)
+	}
 
 	// Remove the ineligible keys from the list
 	for _, key := range keys {
@@ -564,60 +835,140 @@ func (m *AvailableKeyManager) filterKeysOnPendingDeposits(
 	return eligibleKeys, ineligibleKeys, nil
 }
 
-// Filter the list of available keys to remove any that have deposit events in the deposit contract logs
+// Filter the list of available keys to remove any that have deposit events in the deposit contract logs.
+// This uses the deposit event index once it has been built; if fullHistory is set, the index is checked all the way back to the deposit contract's deployment, which reads every record in it since the index is only sorted by block.
+// While the index is still being built for the first time, this falls back to scanning the logs between the start and current blocks directly.
+// Keys whose first deposit event is after the settled block are returned separately, since a reorg could still remove the event.
 func (m *AvailableKeyManager) filterKeysOnDepositEvents(
 	ctx context.Context,
 	logger *slog.Logger,
 	keys []*AvailableKey,
 	startBlock uint64,
 	currentBlock uint64,
+	settledBlock int64,
+	fullHistory bool,
 ) (
 	eligibleKeys []*AvailableKey,
 	ineligibleKeys []*AvailableKey,
+	unsettledKeys []*AvailableKey,
 	err error,
 ) {
-	// Get the deposit events
-	logger.Debug(
-		"Getting deposit events",
-		"start", startBlock,
-		"end", currentBlock,
-	)
 	pubkeys := make([]beacon.ValidatorPubkey, len(keys))
 	for i, data := range keys {
 		pubkeys[i] = data.PublicKey
 	}
-	depositContract := m.sp.GetBeaconDepositContract()
-	depositEvents, err := depositContract.DepositEventsForPubkeys(
-		pubkeys,
-		new(big.Int).SetUint64(startBlock),
-		new(big.Int).SetUint64(currentBlock),
-		intervalSizeBig,
-	)
-	if err != nil {
-		return nil, nil, fmt.Errorf("error getting deposit events: %w", err)
+
+	// Get the first deposit for each key that has one
+	type firstDeposit struct {
+		txHash common.Hash
+		block  uint64
+	}
+	deposits := map[beacon.ValidatorPubkey]firstDeposit{}
+	index := m.sp.GetDepositEventIndex()
+	indexNextBlock := index.GetNextBlock()
+	if indexNextBlock+IntervalSize > currentBlock {
+		// Bring the index up to date and check it
+		err = index.Sync(ctx, logger, currentBlock)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error syncing deposit event index: %w", err)
+		}
+		if fullHistory {
+			startBlock = 0
+		}
+		logger.Debug(
+			"Getting deposit events from index",
+			"start", startBlock,
+			"end", currentBlock,
+		)
+		depositEvents, err := index.GetDepositEvents(pubkeys, startBlock, currentBlock)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error getting deposit events from index: %w", err)
+		}
+		for pubkey, events := range depositEvents {
+			deposits[pubkey] = firstDeposit{txHash: events[0].TxHash, block: events[0].BlockNumber}
+		}
+	} else {
+		// The index isn't ready yet, so scan the logs directly
+		logger.Debug(
+			"Deposit event index is still being built, getting deposit events from logs",
+			"indexBlock", indexNextBlock,
+			"start", startBlock,
+			"end", currentBlock,
+		)
+		depositContract := m.sp.GetBeaconDepositContract()
+		depositEvents, err := depositContract.DepositEventsForPubkeys(
+			pubkeys,
+			new(big.Int).SetUint64(startBlock),
+			new(big.Int).SetUint64(currentBlock),
+			intervalSizeBig,
+		)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error getting deposit events: %w", err)
+		}
+		for pubkey, events := range depositEvents {
+			deposits[pubkey] = firstDeposit{txHash: events[0].TxHash, block: events[0].BlockNumber}
+		}
 	}
 
 	// Ignore keys that are already in the deposit logs
+	transitions := []KeyTransition{}
 	for _, key := range keys {
-		event, exists := depositEvents[key.PublicKey]
-		if exists {
+		deposit, exists := deposits[key.PublicKey]
+		if !exists {
+			eligibleKeys = append(eligibleKeys, key)
+			continue
+		}
+		transitions = append(transitions, KeyTransition{
+			Pubkey: key.PublicKey,
+			Event: swapi.KeyLifecycleEvent{
+				State:       swapi.KeyLifecycleState_Deposited,
+				TxHash:      deposit.txHash,
+				BlockNumber: deposit.block,
+			},
+		})
+		if int64(deposit.block) > settledBlock {
 			logger.Info(
-				"Key was found in a deposit event",
+				"Key was found in a deposit event that isn't settled yet",
 				"pubkey", key.PublicKey.Hex(),
-				"tx", event[0].TxHash.Hex(),
+				"tx", deposit.txHash.Hex(),
+				"block", deposit.block,
 			)
-			key.PrivateKey = nil // Empty out the private key so it's not resident in memory
-			ineligibleKeys = append(ineligibleKeys, key)
-		} else {
-			eligibleKeys = append(eligibleKeys, key)
+			unsettledKeys = append(unsettledKeys, key)
+			continue
 		}
+		logger.Info(
+			"Key was found in a deposit event",
+			"pubkey", key.PublicKey.Hex(),
+			"tx", deposit.txHash.Hex(),
+		)
+		m.unloadKey(key) // Release the private key so it's not resident in memory
+		ineligibleKeys = append(ineligibleKeys, key)
+	}
+	lifecycleMgr := m.sp.GetKeyLifecycleManager()
+	err = lifecycleMgr.RecordTransitions(transitions)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error recording key lifecycles: %w", err)
+	}
+
+	// Keys that were deposited in unsettled blocks but don't have deposit events anymore were reorged out
+	eligiblePubkeys := make([]beacon.ValidatorPubkey, len(eligibleKeys))
+	for i, key := range eligibleKeys {
+		eligiblePubkeys[i] = key.PublicKey
+	}
+	rewound, err := lifecycleMgr.RewindDeposits(eligiblePubkeys, m.data.NextBlockToScan)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error rewinding key lifecycles: %w", err)
+	}
+	for _, pubkey := range rewound {
+		logger.Warn("Key's deposit event was removed by a chain reorg, key is available again", "pubkey", pubkey.Hex())
 	}
 	logger.Info(
 		"Removed ineligible keys from deposit events",
 		"removed", len(ineligibleKeys),
+		"unsettled", len(unsettledKeys),
 		"available", len(eligibleKeys),
 	)
-	return eligibleKeys, ineligibleKeys, nil
+	return eligibleKeys, ineligibleKeys, unsettledKeys, nil
 }
 
 // Filter the list of available keys to remove any that were already provided for the given deposit root
@@ -640,6 +991,85 @@ func (m *AvailableKeyManager) filterKeysOnDepositRoot(
 	return eligibleKeys, ineligibleKeys
 }
 
+// Filter the list of available keys to remove any that are reserved for a vault other than the provided one.
+// Keys reserved for the provided vault are moved ahead of unreserved keys.
+func (m *AvailableKeyManager) filterKeysOnVault(
+	keys []*AvailableKey,
+	vault common.Address,
+) (
+	eligibleKeys []*AvailableKey,
+	ineligibleKeys []*AvailableKey,
+) {
+	reservedKeys := []*AvailableKey{}
+	unreservedKeys := []*AvailableKey{}
+	ineligibleKeys = []*AvailableKey{}
+	for _, key := range keys {
+		switch key.Vault {
+		case vault:
+			reservedKeys = append(reservedKeys, key)
+		case common.Address{}:
+			unreservedKeys = append(unreservedKeys, key)
+		default:
+			ineligibleKeys = append(ineligibleKeys, key)
+		}
+	}
+	eligibleKeys = append(reservedKeys, unreservedKeys...)
+	return eligibleKeys, ineligibleKeys
+}
+
+// Check if the block at the scan checkpoint is still on the canonical chain
+func (m *AvailableKeyManager) isCheckpointCanonical(ctx context.Context, currentBlock uint64) (bool, error) {
+	// Nothing to check if there isn't a checkpoint yet
+	if m.data.NextBlockToScan == 0 || m.data.CheckpointHash == (common.Hash{}) {
+		return true, nil
+	}
+
+	checkpointBlock := m.data.NextBlockToScan - 1
+	if checkpointBlock > currentBlock {
+		return false, nil
+	}
+	header, err := m.sp.GetEthClient().HeaderByNumber(ctx, new(big.Int).SetUint64(checkpointBlock))
+	if err != nil {
+		return false, fmt.Errorf("error getting header for block %d: %w", checkpointBlock, err)
+	}
+	return header.Hash() == m.data.CheckpointHash, nil
+}
+
+// Get the header of the newest block that's deep enough in the chain to be considered settled.
+// Returns nil if the chain is too short for any blocks to be settled yet.
+func getSettledHeader(ctx context.Context, sp IStakeWiseServiceProvider, currentBlock uint64) (*types.Header, error) {
+	cfg := sp.GetConfig()
+	ec := sp.GetEthClient()
+
+	// Use the finalized block if requested
+	if cfg.ScanUseFinalizedBlock.Value {
+		header, err := ec.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+		if err != nil {
+			return nil, fmt.Errorf("error getting finalized block header: %w", err)
+		}
+		if header.Number.Uint64() > currentBlock {
+			// Don't go past the block being scanned
+			header, err = ec.HeaderByNumber(ctx, new(big.Int).SetUint64(currentBlock))
+			if err != nil {
+				return nil, fmt.Errorf("error getting header for block %d: %w", currentBlock, err)
+			}
+		}
+		return header, nil
+	}
+
+	// Otherwise use the confirmation depth
+	depth := cfg.ScanConfirmationDepth.Value
+	if depth > currentBlock {
+		return nil, nil
+	}
+	settledBlock := currentBlock - depth
+	header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(settledBlock))
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %d: %w", settledBlock, err)
+	}
+	return header, nil
+}
+
 // Update the contents of the available keys file
 func (m *AvailableKeyManager) updateData() error {
 	// Serialize the key list
@@ -63,3 +63,38 @@ func NewIEthVault(address common.Address, ec eth.IExecutionClient, txMgr *eth.Tr
 func (c *IEthVault) WithdrawableAssets(mc *batch.MultiCaller, out **big.Int) {
 	eth.AddCallToMulticaller(mc, c.contract, out, "withdrawableAssets")
 }
+
+// Total assets managed by the Vault, including staked assets
+func (c *IEthVault) TotalAssets(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "totalAssets")
+}
+
+// Total shares minted by the Vault
+func (c *IEthVault) TotalShares(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "totalShares")
+}
+
+// The amount of assets the provided number of shares is worth
+func (c *IEthVault) ConvertToAssets(mc *batch.MultiCaller, out **big.Int, shares *big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "convertToAssets", shares)
+}
+
+// Shares that are queued to exit the Vault
+func (c *IEthVault) QueuedShares(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "queuedShares")
+}
+
+// Assets that are in the process of exiting the Vault
+func (c *IEthVault) TotalExitingAssets(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "totalExitingAssets")
+}
+
+// The max amount of assets the Vault can accept
+func (c *IEthVault) Capacity(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "capacity")
+}
+
+// The fee the Vault charges on rewards, in basis points
+func (c *IEthVault) FeePercent(mc *batch.MultiCaller, out *uint16) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "feePercent")
+}
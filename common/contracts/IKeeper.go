@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	batch "github.com/rocket-pool/batch-query"
 	"github.com/rocket-pool/node-manager-core/eth"
 )
 
@@ -56,12 +57,46 @@ func NewIKeeper(address common.Address, ec eth.IExecutionClient, txMgr *eth.Tran
 	}, nil
 }
 
+// =============
+// === Calls ===
+// =============
+
+// Get the number of oracles registered with the keeper
+func (c *IKeeper) TotalOracles(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "totalOracles")
+}
+
+// Get the number of oracle signatures required to update rewards
+func (c *IKeeper) RewardsMinOracles(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "rewardsMinOracles")
+}
+
+// Get the number of oracle signatures required to approve validators and exit signatures
+func (c *IKeeper) ValidatorsMinOracles(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "validatorsMinOracles")
+}
+
 // ==============
 // === Events ===
 // ==============
 
 type ConfigUpdatedEvent struct {
-	ConfigIPFSHash string `abi:"configIpfsHash" json:"configIpfsHash"`
+	ConfigIPFSHash string      `abi:"configIpfsHash" json:"configIpfsHash"`
+	BlockNumber    uint64      `json:"blockNumber"`
+	TxHash         common.Hash `json:"txHash"`
+}
+
+// An oracle being added to or removed from the keeper
+type OracleUpdatedEvent struct {
+	Oracle      common.Address `json:"oracle"`
+	Added       bool           `json:"added"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxHash      common.Hash    `json:"txHash"`
+}
+
+// oracleEventImpl represents an OracleAdded or OracleRemoved event raised by the keeper
+type oracleEventImpl struct {
+	Oracle common.Address `abi:"oracle"`
 }
 
 // Get the ConfigUpdated events for the provided block range
@@ -83,7 +118,47 @@ func (c *IKeeper) ConfigUpdated(startBlock *big.Int, endBlock *big.Int, interval
 		if err != nil {
 			return nil, err
 		}
+		event.BlockNumber = log.BlockNumber
+		event.TxHash = log.TxHash
 		events = append(events, event)
 	}
 	return events, nil
 }
+
+// Get the OracleAdded and OracleRemoved events for the provided block range, in the order they happened
+func (c *IKeeper) OracleUpdated(startBlock *big.Int, endBlock *big.Int, intervalSize *big.Int) ([]OracleUpdatedEvent, error) {
+	// Get the logs
+	addedEvent := c.contract.ABI.Events["OracleAdded"]
+	removedEvent := c.contract.ABI.Events["OracleRemoved"]
+	addressFilter := []common.Address{c.contract.Address}
+	topicFilter := [][]common.Hash{{addedEvent.ID, removedEvent.ID}}
+	logs, err := eth.GetLogs(c.ec, addressFilter, topicFilter, intervalSize, startBlock, endBlock, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process each event
+	events := make([]OracleUpdatedEvent, 0, len(logs))
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		added := log.Topics[0] == addedEvent.ID
+		eventName := removedEvent.Name
+		if added {
+			eventName = addedEvent.Name
+		}
+		var event oracleEventImpl
+		err = c.contract.ContractImpl.UnpackLog(&event, eventName, log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, OracleUpdatedEvent{
+			Oracle:      event.Oracle,
+			Added:       added,
+			BlockNumber: log.BlockNumber,
+			TxHash:      log.TxHash,
+		})
+	}
+	return events, nil
+}
@@ -2,6 +2,7 @@ package swcontracts
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
 	"sync"
 
@@ -63,6 +64,11 @@ func (c *StakewiseVault) GetValidatorsRoot(mc *batch.MultiCaller, out *common.Ha
 	eth.AddCallToMulticaller(mc, c.contract, out, "validatorsRoot")
 }
 
+// Get the index of the next validator the vault will register from its validators root
+func (c *StakewiseVault) GetValidatorIndex(mc *batch.MultiCaller, out **big.Int) {
+	eth.AddCallToMulticaller(mc, c.contract, out, "validatorIndex")
+}
+
 // ====================
 // === Transactions ===
 // ====================
@@ -0,0 +1,267 @@
+package swcommon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// The vault and key a cached deposit data entry was made for
+type depositDataCacheKey struct {
+	vault  common.Address
+	pubkey beacon.ValidatorPubkey
+}
+
+// DepositDataCache keeps the signed deposit data for each available key and vault on disk, so it only has to be generated once.
+// Entries are regenerated if the network's genesis fork version or the vault's withdrawal credentials or deposit amount no longer match them.
+type DepositDataCache struct {
+	dataPath string
+	sp       IStakeWiseServiceProvider
+	lock     *sync.Mutex
+
+	data      *depositDataCacheData
+	entryMap  map[depositDataCacheKey]*depositDataCacheEntry
+	exportMap map[depositDataExportKey]*depositDataExport
+}
+
+type depositDataCacheData struct {
+	// The cached deposit data
+	Entries []*depositDataCacheEntry `json:"entries"`
+
+	// The deposit data lists that have been exported for vaults
+	Exports []*depositDataExport `json:"exports"`
+}
+
+type depositDataCacheEntry struct {
+	// The vault the deposit data is for
+	Vault common.Address `json:"vault"`
+
+	// The signed deposit data
+	DepositData beacon.ExtendedDepositData `json:"depositData"`
+}
+
+// The vault and validators root an exported deposit data list was made for
+type depositDataExportKey struct {
+	vault common.Address
+	root  common.Hash
+}
+
+// A deposit data list exactly as it was exported for a vault.
+// The vault indexes its validators root by each key's position in the list, so it has to be kept as it was even after the available keys change.
+type depositDataExport struct {
+	// The vault the deposit data was exported for
+	Vault common.Address `json:"vault"`
+
+	// The validators root of the deposit data list
+	ValidatorsRoot common.Hash `json:"validatorsRoot"`
+
+	// The deposit data, in the order it was exported
+	DepositData []beacon.ExtendedDepositData `json:"depositData"`
+}
+
+// Creates a new deposit data cache
+func NewDepositDataCache(sp IStakeWiseServiceProvider) (*DepositDataCache, error) {
+	cache := &DepositDataCache{
+		dataPath: filepath.Join(sp.GetModuleDir(), swconfig.DepositDataCacheFile),
+		sp:       sp,
+		lock:     &sync.Mutex{},
+	}
+	err := cache.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading deposit data cache: %w", err)
+	}
+	return cache, nil
+}
+
+// Reload the cache from disk
+func (c *DepositDataCache) Reload() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data := new(depositDataCacheData)
+	_, err := os.Stat(c.dataPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - start with an empty cache
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking status of deposit data cache file [%s]: %w", c.dataPath, err)
+		}
+	} else {
+		// Read the file
+		bytes, err := os.ReadFile(c.dataPath)
+		if err != nil {
+			return fmt.Errorf("error reading deposit data cache file [%s]: %w", c.dataPath, err)
+		}
+
+		// Deserialize it
+		err = json.Unmarshal(bytes, data)
+		if err != nil {
+			return fmt.Errorf("error deserializing deposit data cache file [%s]: %w", c.dataPath, err)
+		}
+	}
+
+	c.data = data
+	c.entryMap = make(map[depositDataCacheKey]*depositDataCacheEntry, len(data.Entries))
+	for _, entry := range data.Entries {
+		c.entryMap[getDepositDataCacheKey(entry.Vault, entry.DepositData.PublicKey)] = entry
+	}
+	c.exportMap = make(map[depositDataExportKey]*depositDataExport, len(data.Exports))
+	for _, export := range data.Exports {
+		c.exportMap[depositDataExportKey{vault: export.Vault, root: export.ValidatorsRoot}] = export
+	}
+	return nil
+}
+
+// Save a deposit data list that was exported for a vault, so it can be looked up by its validators root once the vault has been given that root.
+// Exports aren't pruned, since the vault can keep using a root long after the keys in it have been used.
+func (c *DepositDataCache) SaveExport(vault common.Address, validatorsRoot common.Hash, depositDatas []beacon.ExtendedDepositData) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := depositDataExportKey{vault: vault, root: validatorsRoot}
+	if _, exists := c.exportMap[key]; exists {
+		return nil
+	}
+	export := &depositDataExport{
+		Vault:          vault,
+		ValidatorsRoot: validatorsRoot,
+		DepositData:    depositDatas,
+	}
+	c.data.Exports = append(c.data.Exports, export)
+	c.exportMap[key] = export
+	return c.saveData()
+}
+
+// Get the deposit data list that was exported for a vault with the provided validators root, in the order it was exported.
+// Returns false if no export has that root.
+func (c *DepositDataCache) GetExport(vault common.Address, validatorsRoot common.Hash) ([]beacon.ExtendedDepositData, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	export, exists := c.exportMap[depositDataExportKey{vault: vault, root: validatorsRoot}]
+	if !exists {
+		return nil, false
+	}
+	return export.DepositData, true
+}
+
+// Get the deposit data for the provided keys and vault, in the same order as the keys.
+// Deposit data that isn't in the cache yet, or was made for a different fork version, withdrawal credentials, or amount, is signed by the validator signer and saved.
+func (c *DepositDataCache) GetDepositData(ctx context.Context, logger *slog.Logger, vault common.Address, pubkeys []beacon.ValidatorPubkey) ([]beacon.ExtendedDepositData, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	res := c.sp.GetResources()
+	settings, err := GetVaultDepositSettings(res, vault)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the keys that aren't cached yet
+	depositDatas := make([]beacon.ExtendedDepositData, len(pubkeys))
+	missingIndices := []int{}
+	missingPubkeys := []beacon.ValidatorPubkey{}
+	for i, pubkey := range pubkeys {
+		entry, exists := c.entryMap[getDepositDataCacheKey(vault, pubkey[:])]
+		if exists && isDepositDataCurrent(entry.DepositData, res, settings) {
+			depositDatas[i] = entry.DepositData
+			continue
+		}
+		missingIndices = append(missingIndices, i)
+		missingPubkeys = append(missingPubkeys, pubkey)
+	}
+	if len(missingPubkeys) == 0 {
+		return depositDatas, nil
+	}
+
+	// Sign and cache them
+	newDepositDatas, err := SignDepositData(ctx, logger, c.sp.GetValidatorSigner(), res, settings.WithdrawalCredentials, settings.Amount, missingPubkeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, depositData := range newDepositDatas {
+		depositDatas[missingIndices[i]] = depositData
+		key := getDepositDataCacheKey(vault, depositData.PublicKey)
+		entry, exists := c.entryMap[key]
+		if exists {
+			entry.DepositData = depositData
+			continue
+		}
+		entry = &depositDataCacheEntry{
+			Vault:       vault,
+			DepositData: depositData,
+		}
+		c.data.Entries = append(c.data.Entries, entry)
+		c.entryMap[key] = entry
+	}
+	c.pruneImpl()
+	err = c.saveData()
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Cached new deposit data", "vault", vault.Hex(), "count", len(newDepositDatas))
+	return depositDatas, nil
+}
+
+// Remove entries for keys that aren't available anymore or were made for an old fork version, so the cache doesn't grow forever.
+// Used when the lock is already held.
+func (c *DepositDataCache) pruneImpl() {
+	res := c.sp.GetResources()
+	availablePubkeys := map[beacon.ValidatorPubkey]bool{}
+	for _, pubkey := range c.sp.GetAvailableKeyManager().GetPubkeys() {
+		availablePubkeys[pubkey] = true
+	}
+
+	entries := make([]*depositDataCacheEntry, 0, len(c.data.Entries))
+	for _, entry := range c.data.Entries {
+		pubkey := beacon.ValidatorPubkey(entry.DepositData.PublicKey)
+		if !availablePubkeys[pubkey] || !bytes.Equal(entry.DepositData.ForkVersion, res.GenesisForkVersion) {
+			delete(c.entryMap, getDepositDataCacheKey(entry.Vault, entry.DepositData.PublicKey))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	c.data.Entries = entries
+}
+
+// Save the cache to disk
+func (c *DepositDataCache) saveData() error {
+	// Serialize the cache
+	bytes, err := json.Marshal(c.data)
+	if err != nil {
+		return fmt.Errorf("error serializing deposit data cache: %w", err)
+	}
+
+	// Write it
+	err = os.WriteFile(c.dataPath, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving deposit data cache to disk: %w", err)
+	}
+	return nil
+}
+
+// Check if cached deposit data still matches the network and vault it would be generated for now
+func isDepositDataCurrent(depositData beacon.ExtendedDepositData, res *swconfig.MergedResources, settings VaultDepositSettings) bool {
+	return bytes.Equal(depositData.ForkVersion, res.GenesisForkVersion) &&
+		depositData.NetworkName == res.EthNetworkName &&
+		bytes.Equal(depositData.WithdrawalCredentials, settings.WithdrawalCredentials[:]) &&
+		depositData.Amount == settings.Amount
+}
+
+// Get the key for a cache entry
+func getDepositDataCacheKey(vault common.Address, pubkey []byte) depositDataCacheKey {
+	return depositDataCacheKey{
+		vault:  vault,
+		pubkey: beacon.ValidatorPubkey(pubkey),
+	}
+}
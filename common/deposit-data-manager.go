@@ -1,14 +1,21 @@
 package swcommon
 
 import (
+	"bytes"
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"math/big"
 	"os"
 	"path/filepath"
+	"sort"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
 	"github.com/prysmaticlabs/prysm/v5/beacon-chain/core/signing"
 	prdeposit "github.com/prysmaticlabs/prysm/v5/contracts/deposit"
@@ -19,10 +26,31 @@ import (
 )
 
 const (
-	// Stakewise validators deposit a full 32 ETH
+	// Stakewise validators deposit a full 32 ETH unless their vault is configured otherwise
 	StakewiseDepositAmount uint64 = 32e9
+
+	// The smallest deposit the Beacon deposit contract accepts, in gwei
+	MinDepositAmount uint64 = 1e9
+
+	// The max effective balance of a compounding validator, in gwei
+	MaxCompoundingBalance uint64 = 2048e9
+
+	// The withdrawal credential prefix for compounding validators
+	compoundingWithdrawalPrefix byte = 0x02
 )
 
+// The withdrawal credentials and deposit amount used for new validators in a vault
+type VaultDepositSettings struct {
+	// The kind of withdrawal credentials the validators use
+	CredentialType swconfig.WithdrawalCredentialType
+
+	// The withdrawal credentials the validators use
+	WithdrawalCredentials common.Hash
+
+	// The amount to deposit for each validator, in gwei
+	Amount uint64
+}
+
 // DEPRECATED: This was only necessary for StakeWise v1 support and now just creates a blank file.
 // Once StakeWise no longer needs the file at all, this can be removed.
 type DepositDataManager struct {
@@ -44,15 +72,78 @@ func NewDepositDataManager(sp IStakeWiseServiceProvider) (*DepositDataManager, e
 	return ddMgr, nil
 }
 
-// Generates deposit data for the provided keys
-func GenerateDepositData(logger *slog.Logger, resources *swconfig.MergedResources, keys []*eth2types.BLSPrivateKey) ([]beacon.ExtendedDepositData, error) {
-	// Stakewise uses the same withdrawal creds for each validator
-	withdrawalCreds := validator.GetWithdrawalCredsFromAddress(resources.Vault)
+// Get the withdrawal credentials and deposit amount for new validators in the provided vault.
+// Vaults that aren't in the vault list use 0x01 credentials and a 32 ETH deposit.
+func GetVaultDepositSettings(resources *swconfig.MergedResources, vault common.Address) (VaultDepositSettings, error) {
+	settings := VaultDepositSettings{
+		CredentialType: swconfig.WithdrawalCredentialType_Eth1,
+		Amount:         StakewiseDepositAmount,
+	}
+	vaultCfg := resources.GetVault(vault)
+	if vaultCfg != nil {
+		if vaultCfg.CredentialType != "" {
+			settings.CredentialType = vaultCfg.CredentialType
+		}
+		if vaultCfg.DepositAmount != 0 {
+			settings.Amount = vaultCfg.DepositAmount
+		}
+	}
+
+	// Make sure the amount works with the credential type.
+	// New validators need at least 32 ETH to activate, and 0x01 validators can't be topped up afterwards, so they need exactly 32 ETH.
+	switch settings.CredentialType {
+	case swconfig.WithdrawalCredentialType_Eth1:
+		if settings.Amount != StakewiseDepositAmount {
+			return VaultDepositSettings{}, fmt.Errorf("vault [%s] has a deposit amount of %d gwei, but %s validators need exactly %d gwei", vault.Hex(), settings.Amount, settings.CredentialType, StakewiseDepositAmount)
+		}
+	case swconfig.WithdrawalCredentialType_Compounding:
+		if settings.Amount < StakewiseDepositAmount || settings.Amount > MaxCompoundingBalance {
+			return VaultDepositSettings{}, fmt.Errorf("vault [%s] has a deposit amount of %d gwei, but %s validators need between %d and %d gwei", vault.Hex(), settings.Amount, settings.CredentialType, StakewiseDepositAmount, MaxCompoundingBalance)
+		}
+	default:
+		return VaultDepositSettings{}, fmt.Errorf("vault [%s] has an unknown credential type [%s]", vault.Hex(), settings.CredentialType)
+	}
+	settings.WithdrawalCredentials = GetWithdrawalCreds(settings.CredentialType, vault)
+	return settings, nil
+}
+
+// Get the withdrawal credentials of the provided type for the provided withdrawal address
+func GetWithdrawalCreds(credentialType swconfig.WithdrawalCredentialType, address common.Address) common.Hash {
+	withdrawalCreds := validator.GetWithdrawalCredsFromAddress(address)
+	if credentialType == swconfig.WithdrawalCredentialType_Compounding {
+		withdrawalCreds[0] = compoundingWithdrawalPrefix
+	}
+	return withdrawalCreds
+}
+
+// Check if the provided withdrawal credentials belong to a compounding validator
+func IsCompoundingWithdrawalCreds(withdrawalCreds common.Hash) bool {
+	return withdrawalCreds[0] == compoundingWithdrawalPrefix
+}
+
+// Generates deposit data for the provided keys, using the provided vault as the withdrawal address and the vault's credential type and deposit amount
+func GenerateDepositData(logger *slog.Logger, resources *swconfig.MergedResources, vault common.Address, keys []*eth2types.BLSPrivateKey) ([]beacon.ExtendedDepositData, error) {
+	// Stakewise uses the same withdrawal creds and amount for each validator in a vault
+	settings, err := GetVaultDepositSettings(resources, vault)
+	if err != nil {
+		return nil, err
+	}
+	return generateDepositData(logger, resources, settings.WithdrawalCredentials, settings.Amount, keys)
+}
 
-	// Create the new aggregated deposit data for all generated keys
+// Generates deposit data that adds the provided amount, in gwei, to existing validators with the provided withdrawal credentials, using the signer for the signatures
+func GenerateTopUpDepositData(ctx context.Context, logger *slog.Logger, signer IValidatorSigner, resources *swconfig.MergedResources, withdrawalCreds common.Hash, amount uint64, pubkeys []beacon.ValidatorPubkey) ([]beacon.ExtendedDepositData, error) {
+	if amount < MinDepositAmount {
+		return nil, fmt.Errorf("top-up amount of %d gwei is less than the minimum deposit of %d gwei", amount, MinDepositAmount)
+	}
+	return SignDepositData(ctx, logger, signer, resources, withdrawalCreds, amount, pubkeys)
+}
+
+// Generates deposit data for the provided keys with the provided withdrawal credentials and amount
+func generateDepositData(logger *slog.Logger, resources *swconfig.MergedResources, withdrawalCreds common.Hash, amount uint64, keys []*eth2types.BLSPrivateKey) ([]beacon.ExtendedDepositData, error) {
 	dataList := make([]beacon.ExtendedDepositData, len(keys))
 	for i, key := range keys {
-		depositData, err := validator.GetDepositData(logger, key, withdrawalCreds, resources.GenesisForkVersion, StakewiseDepositAmount, resources.EthNetworkName)
+		depositData, err := validator.GetDepositData(logger, key, withdrawalCreds, resources.GenesisForkVersion, amount, resources.EthNetworkName)
 		if err != nil {
 			pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
 			return nil, fmt.Errorf("error getting deposit data for key %s: %w", pubkey.HexWithPrefix(), err)
@@ -62,6 +153,179 @@ func GenerateDepositData(logger *slog.Logger, resources *swconfig.MergedResource
 	return dataList, nil
 }
 
+// A multiproof that some deposit data is part of a validators root, in the format OpenZeppelin's MerkleProof.multiProofVerify uses
+type ValidatorsMultiProof struct {
+	// The hashed leaves being proven, in the order the proof consumes them
+	Leaves []common.Hash
+
+	// The sibling hashes needed to rebuild the root
+	Proof []common.Hash
+
+	// For each step up the tree, true if the pair comes from the leaves or earlier steps and false if it comes from the proof
+	ProofFlags []bool
+}
+
+// Calculates the Merkle root of a list of deposit data, the way StakeWise vaults store it in their validators root.
+// This is an OpenZeppelin StandardMerkleTree whose leaves are the ABI-encoded (pubkey || signature || deposit data root, index) tuples.
+func ComputeValidatorsRoot(depositDatas []beacon.ExtendedDepositData) (common.Hash, error) {
+	tree, _, err := buildValidatorsTree(depositDatas)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tree[0], nil
+}
+
+// Builds a multiproof that the deposit data for the provided keys is part of the validators root of the full deposit data list
+func GetValidatorsMultiProof(depositDatas []beacon.ExtendedDepositData, pubkeys []beacon.ValidatorPubkey) (ValidatorsMultiProof, error) {
+	tree, treeIndices, err := buildValidatorsTree(depositDatas)
+	if err != nil {
+		return ValidatorsMultiProof{}, err
+	}
+
+	// Get the tree index of each key, highest first
+	indices := make([]int, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		treeIndex, exists := treeIndices[pubkey]
+		if !exists {
+			return ValidatorsMultiProof{}, fmt.Errorf("key %s isn't in the deposit data list", pubkey.HexWithPrefix())
+		}
+		indices[i] = treeIndex
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indices)))
+
+	// Walk up the tree, taking siblings from the stack when they're being proven and from the tree otherwise
+	proof := ValidatorsMultiProof{
+		Leaves:     make([]common.Hash, len(indices)),
+		Proof:      []common.Hash{},
+		ProofFlags: []bool{},
+	}
+	for i, treeIndex := range indices {
+		proof.Leaves[i] = tree[treeIndex]
+	}
+	stack := append([]int{}, indices...)
+	for len(stack) > 0 && stack[0] > 0 {
+		index := stack[0]
+		stack = stack[1:]
+		sibling := index + 1
+		if index%2 == 0 {
+			sibling = index - 1
+		}
+		if len(stack) > 0 && stack[0] == sibling {
+			proof.ProofFlags = append(proof.ProofFlags, true)
+			stack = stack[1:]
+		} else {
+			proof.ProofFlags = append(proof.ProofFlags, false)
+			proof.Proof = append(proof.Proof, tree[sibling])
+		}
+		stack = append(stack, (index-1)/2)
+	}
+	if len(indices) == 0 {
+		proof.Proof = append(proof.Proof, tree[0])
+	}
+	return proof, nil
+}
+
+// Calculates the root the multiproof's leaves belong to. The leaves are part of a validators root if this matches it.
+func (p ValidatorsMultiProof) GetRoot() (common.Hash, error) {
+	total := len(p.ProofFlags)
+	if len(p.Leaves)+len(p.Proof) != total+1 {
+		return common.Hash{}, fmt.Errorf("multiproof has %d leaves and %d proof hashes, which doesn't match its %d flags", len(p.Leaves), len(p.Proof), total)
+	}
+
+	// Take the next hash from the leaves until they run out, then from the hashes built so far
+	hashes := make([]common.Hash, total)
+	leafPos, hashPos, proofPos := 0, 0, 0
+	next := func() common.Hash {
+		if leafPos < len(p.Leaves) {
+			leafPos++
+			return p.Leaves[leafPos-1]
+		}
+		hashPos++
+		return hashes[hashPos-1]
+	}
+	for i := 0; i < total; i++ {
+		a := next()
+		var b common.Hash
+		if p.ProofFlags[i] {
+			b = next()
+		} else {
+			if proofPos >= len(p.Proof) {
+				return common.Hash{}, fmt.Errorf("multiproof ran out of proof hashes")
+			}
+			b = p.Proof[proofPos]
+			proofPos++
+		}
+		hashes[i] = hashValidatorsTreePair(a, b)
+	}
+
+	if total > 0 {
+		return hashes[total-1], nil
+	}
+	if len(p.Leaves) > 0 {
+		return p.Leaves[0], nil
+	}
+	return p.Proof[0], nil
+}
+
+// Builds the StakeWise validators tree for a list of deposit data, returning the tree with the root first and the tree index of each key's leaf
+func buildValidatorsTree(depositDatas []beacon.ExtendedDepositData) ([]common.Hash, map[beacon.ValidatorPubkey]int, error) {
+	if len(depositDatas) == 0 {
+		return nil, nil, fmt.Errorf("can't compute the validators root of an empty deposit data list")
+	}
+
+	// Set up the leaf encoding
+	bytesType, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating bytes ABI type: %w", err)
+	}
+	uintType, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating uint256 ABI type: %w", err)
+	}
+	leafArgs := abi.Arguments{{Type: bytesType}, {Type: uintType}}
+
+	// Hash the leaves
+	leaves := make([]common.Hash, len(depositDatas))
+	leafKeys := map[common.Hash]beacon.ValidatorPubkey{}
+	for i, depositData := range depositDatas {
+		pubkey := beacon.ValidatorPubkey(depositData.PublicKey)
+		txData := make([]byte, 0, len(depositData.PublicKey)+len(depositData.Signature)+len(depositData.DepositDataRoot))
+		txData = append(txData, depositData.PublicKey...)
+		txData = append(txData, depositData.Signature...)
+		txData = append(txData, depositData.DepositDataRoot...)
+		encoded, err := leafArgs.Pack(txData, big.NewInt(int64(i)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error encoding validators root leaf for key %s: %w", pubkey.HexWithPrefix(), err)
+		}
+		leaves[i] = crypto.Keccak256Hash(crypto.Keccak256(encoded))
+		leafKeys[leaves[i]] = pubkey
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i][:], leaves[j][:]) < 0
+	})
+
+	// Build the tree with the leaves at the end, then hash the sorted pairs up to the root
+	tree := make([]common.Hash, 2*len(leaves)-1)
+	treeIndices := make(map[beacon.ValidatorPubkey]int, len(leaves))
+	for i, leaf := range leaves {
+		treeIndex := len(tree) - 1 - i
+		tree[treeIndex] = leaf
+		treeIndices[leafKeys[leaf]] = treeIndex
+	}
+	for i := len(tree) - 1 - len(leaves); i >= 0; i-- {
+		tree[i] = hashValidatorsTreePair(tree[2*i+1], tree[2*i+2])
+	}
+	return tree, treeIndices, nil
+}
+
+// Hashes a pair of nodes in the validators tree, sorting them first
+func hashValidatorsTreePair(a common.Hash, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
 // Calculates the deposit domain for Beacon deposits
 func GetGenesisDepositDomain(genesisForkVersion []byte) ([]byte, error) {
 	return signing.ComputeDomain(eth2types.DomainDeposit, genesisForkVersion, eth2types.ZeroGenesisValidatorsRoot)
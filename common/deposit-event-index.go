@@ -0,0 +1,449 @@
+package swcommon
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// The size of a single deposit event record in the index file: pubkey, block number, transaction index, and transaction hash
+	depositRecordSize int64 = int64(beacon.ValidatorPubkeyLength) + 8 + 4 + common.HashLength
+
+	// The number of block hash checkpoints to keep for reorg detection
+	maxIndexCheckpoints int = 32
+)
+
+// A deposit event stored in the deposit event index
+type IndexedDepositEvent struct {
+	// The pubkey of the validator the deposit was for
+	Pubkey beacon.ValidatorPubkey
+
+	// The block the deposit was included in
+	BlockNumber uint64
+
+	// The index of the deposit transaction within the block
+	TxIndex uint32
+
+	// The hash of the deposit transaction
+	TxHash common.Hash
+}
+
+// A block number and hash pair used to detect reorgs between index syncs
+type indexCheckpoint struct {
+	Block uint64      `json:"block"`
+	Hash  common.Hash `json:"hash"`
+}
+
+// Metadata for the deposit event index, stored next to the event records
+type depositEventIndexData struct {
+	// The next block to add to the index
+	NextBlock uint64 `json:"nextBlock"`
+
+	// The number of event records in the records file that belong to the index
+	RecordCount int64 `json:"recordCount"`
+
+	// Hashes of recently indexed blocks, oldest first
+	Checkpoints []indexCheckpoint `json:"checkpoints"`
+}
+
+// DepositEventIndex keeps a local copy of every Beacon deposit contract event so deposit checks don't have to scan the logs each time.
+// Events are appended to a binary records file in block order, and the metadata tracks how far the index has been built.
+// The records are only sorted by block, not by pubkey: lookups binary search for the start of their block range and then read every record in it,
+// so a lookup over the whole history reads the whole file.
+type DepositEventIndex struct {
+	metadataPath string
+	recordsPath  string
+	genesisBlock uint64
+	sp           IStakeWiseServiceProvider
+
+	// Serializes syncs so only one can fetch new events at a time
+	syncLock *sync.Mutex
+
+	// Protects the metadata and the records file
+	lock *sync.Mutex
+
+	data *depositEventIndexData
+}
+
+// Creates a new deposit event index
+func NewDepositEventIndex(sp IStakeWiseServiceProvider) (*DepositEventIndex, error) {
+	moduleDir := sp.GetModuleDir()
+	var genesisBlock uint64
+	if res := sp.GetResources(); res.DepositContractGenesisBlock != nil {
+		genesisBlock = res.DepositContractGenesisBlock.Uint64()
+	}
+	index := &DepositEventIndex{
+		metadataPath: filepath.Join(moduleDir, swconfig.DepositEventIndexFile),
+		recordsPath:  filepath.Join(moduleDir, swconfig.DepositEventRecordsFile),
+		genesisBlock: genesisBlock,
+		sp:           sp,
+		syncLock:     &sync.Mutex{},
+		lock:         &sync.Mutex{},
+	}
+	err := index.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading deposit event index: %w", err)
+	}
+	return index, nil
+}
+
+// Reload the index metadata from disk, discarding any records that were written after the metadata was last saved
+func (i *DepositEventIndex) Reload() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	// Initialize the metadata
+	data := &depositEventIndexData{
+		NextBlock: i.genesisBlock,
+	}
+	_, err := os.Stat(i.metadataPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - start a new index
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking status of deposit event index file [%s]: %w", i.metadataPath, err)
+		}
+	} else {
+		// Read the file
+		bytes, err := os.ReadFile(i.metadataPath)
+		if err != nil {
+			return fmt.Errorf("error reading deposit event index file [%s]: %w", i.metadataPath, err)
+		}
+
+		// Deserialize it
+		err = json.Unmarshal(bytes, data)
+		if err != nil {
+			return fmt.Errorf("error deserializing deposit event index file [%s]: %w", i.metadataPath, err)
+		}
+	}
+	i.data = data
+
+	// Make sure the records file matches the metadata
+	var recordsSize int64
+	info, err := os.Stat(i.recordsPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking status of deposit event records file [%s]: %w", i.recordsPath, err)
+		}
+	} else {
+		recordsSize = info.Size()
+	}
+	expectedSize := i.data.RecordCount * depositRecordSize
+	if recordsSize < expectedSize {
+		// Records are missing so the index can't be trusted, start over
+		return i.resetImpl()
+	}
+	if recordsSize > expectedSize {
+		// Records were appended but the metadata wasn't saved, so drop them and they'll be fetched again
+		err = os.Truncate(i.recordsPath, expectedSize)
+		if err != nil {
+			return fmt.Errorf("error truncating deposit event records file [%s]: %w", i.recordsPath, err)
+		}
+	}
+	return nil
+}
+
+// Get the next block that will be added to the index
+func (i *DepositEventIndex) GetNextBlock() uint64 {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.data.NextBlock
+}
+
+// Add all of the deposit events up to the target block to the index.
+// If the chain has reorged since the last sync, the index is rewound to the last block that's still canonical first.
+// Progress is saved after each interval, so an interrupted sync picks up where it left off.
+func (i *DepositEventIndex) Sync(ctx context.Context, logger *slog.Logger, targetBlock uint64) error {
+	i.syncLock.Lock()
+	defer i.syncLock.Unlock()
+
+	// Rewind if there's been a reorg
+	err := i.handleReorg(ctx, logger, targetBlock)
+	if err != nil {
+		return fmt.Errorf("error checking deposit event index for reorgs: %w", err)
+	}
+
+	// Add the events for each interval
+	depositContract := i.sp.GetBeaconDepositContract()
+	ec := i.sp.GetEthClient()
+	startBlock := i.GetNextBlock()
+	if startBlock <= targetBlock {
+		logger.Debug("Syncing deposit event index", "start", startBlock, "target", targetBlock)
+	}
+	for startBlock <= targetBlock {
+		endBlock := startBlock + IntervalSize - 1
+		if endBlock > targetBlock {
+			endBlock = targetBlock
+		}
+		events, err := depositContract.DepositEvent(new(big.Int).SetUint64(startBlock), new(big.Int).SetUint64(endBlock), intervalSizeBig)
+		if err != nil {
+			return fmt.Errorf("error getting deposit events for blocks %d to %d: %w", startBlock, endBlock, err)
+		}
+		header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(endBlock))
+		if err != nil {
+			return fmt.Errorf("error getting header for block %d: %w", endBlock, err)
+		}
+		err = i.appendEvents(events, indexCheckpoint{Block: endBlock, Hash: header.Hash()})
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			logger.Debug("Indexed deposit events", "start", startBlock, "end", endBlock, "count", len(events))
+		}
+		startBlock = endBlock + 1
+	}
+	return nil
+}
+
+// Get the indexed deposit events for the provided pubkeys between the start and end blocks, inclusive.
+// Events for each pubkey are in the order they happened.
+// The first record in range is found with a binary search on block number, then every record up to the end block is read and filtered by pubkey,
+// so the cost grows with the number of deposits in the range rather than the number of pubkeys requested.
+func (i *DepositEventIndex) GetDepositEvents(pubkeys []beacon.ValidatorPubkey, startBlock uint64, endBlock uint64) (map[beacon.ValidatorPubkey][]IndexedDepositEvent, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	requestedPubkeys := make(map[beacon.ValidatorPubkey]struct{}, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		requestedPubkeys[pubkey] = struct{}{}
+	}
+	depositMap := make(map[beacon.ValidatorPubkey][]IndexedDepositEvent, len(pubkeys))
+	if i.data.RecordCount == 0 || len(pubkeys) == 0 {
+		return depositMap, nil
+	}
+
+	// Open the records
+	file, err := os.Open(i.recordsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening deposit event records file [%s]: %w", i.recordsPath, err)
+	}
+	defer file.Close()
+
+	// Records are only sorted by block, so find the first one in range
+	var searchErr error
+	buffer := make([]byte, depositRecordSize)
+	first := sort.Search(int(i.data.RecordCount), func(index int) bool {
+		if searchErr != nil {
+			return true
+		}
+		_, searchErr = file.ReadAt(buffer, int64(index)*depositRecordSize)
+		if searchErr != nil {
+			return true
+		}
+		return decodeDepositRecord(buffer).BlockNumber >= startBlock
+	})
+	if searchErr != nil {
+		return nil, fmt.Errorf("error reading deposit event records file [%s]: %w", i.recordsPath, searchErr)
+	}
+
+	// Read everything from there until the end block
+	_, err = file.Seek(int64(first)*depositRecordSize, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("error seeking in deposit event records file [%s]: %w", i.recordsPath, err)
+	}
+	reader := bufio.NewReader(file)
+	for index := int64(first); index < i.data.RecordCount; index++ {
+		_, err = io.ReadFull(reader, buffer)
+		if err != nil {
+			return nil, fmt.Errorf("error reading deposit event records file [%s]: %w", i.recordsPath, err)
+		}
+		event := decodeDepositRecord(buffer)
+		if event.BlockNumber > endBlock {
+			break
+		}
+		if _, exists := requestedPubkeys[event.Pubkey]; exists {
+			depositMap[event.Pubkey] = append(depositMap[event.Pubkey], event)
+		}
+	}
+	return depositMap, nil
+}
+
+// Check the saved block hashes against the canonical chain, rewinding the index to the newest one that still matches
+func (i *DepositEventIndex) handleReorg(ctx context.Context, logger *slog.Logger, targetBlock uint64) error {
+	i.lock.Lock()
+	checkpoints := make([]indexCheckpoint, len(i.data.Checkpoints))
+	copy(checkpoints, i.data.Checkpoints)
+	i.lock.Unlock()
+	if len(checkpoints) == 0 {
+		return nil
+	}
+
+	// Walk back from the newest checkpoint until one is still canonical
+	ec := i.sp.GetEthClient()
+	validCount := 0
+	for j := len(checkpoints) - 1; j >= 0; j-- {
+		checkpoint := checkpoints[j]
+		if checkpoint.Block > targetBlock {
+			continue
+		}
+		header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(checkpoint.Block))
+		if err != nil {
+			return fmt.Errorf("error getting header for block %d: %w", checkpoint.Block, err)
+		}
+		if header.Hash() == checkpoint.Hash {
+			validCount = j + 1
+			break
+		}
+	}
+	if validCount == len(checkpoints) {
+		return nil
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if validCount == 0 {
+		logger.Warn("None of the deposit event index's checkpoints are canonical anymore, rebuilding the index", "oldNextBlock", i.data.NextBlock)
+		return i.resetImpl()
+	}
+	rewindBlock := checkpoints[validCount-1].Block
+	logger.Warn("Chain reorg detected, rewinding deposit event index", "oldNextBlock", i.data.NextBlock, "newNextBlock", rewindBlock+1)
+	return i.rewindImpl(rewindBlock, checkpoints[:validCount])
+}
+
+// Append a batch of events to the records file and save the new progress
+func (i *DepositEventIndex) appendEvents(events []swcontracts.DepositEvent, checkpoint indexCheckpoint) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if len(events) > 0 {
+		buffer := make([]byte, 0, int64(len(events))*depositRecordSize)
+		for _, event := range events {
+			buffer = appendDepositRecord(buffer, event)
+		}
+		file, err := os.OpenFile(i.recordsPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileMode)
+		if err != nil {
+			return fmt.Errorf("error opening deposit event records file [%s]: %w", i.recordsPath, err)
+		}
+		_, err = file.Write(buffer)
+		if err == nil {
+			err = file.Sync()
+		}
+		closeErr := file.Close()
+		if err != nil {
+			return fmt.Errorf("error writing deposit event records: %w", err)
+		}
+		if closeErr != nil {
+			return fmt.Errorf("error closing deposit event records file [%s]: %w", i.recordsPath, closeErr)
+		}
+	}
+
+	i.data.RecordCount += int64(len(events))
+	i.data.NextBlock = checkpoint.Block + 1
+	i.data.Checkpoints = append(i.data.Checkpoints, checkpoint)
+	if len(i.data.Checkpoints) > maxIndexCheckpoints {
+		i.data.Checkpoints = i.data.Checkpoints[len(i.data.Checkpoints)-maxIndexCheckpoints:]
+	}
+	return i.saveData()
+}
+
+// Drop every record after the provided block
+func (i *DepositEventIndex) rewindImpl(block uint64, checkpoints []indexCheckpoint) error {
+	// Find the first record past the block
+	keepCount := i.data.RecordCount
+	if keepCount > 0 {
+		file, err := os.Open(i.recordsPath)
+		if err != nil {
+			return fmt.Errorf("error opening deposit event records file [%s]: %w", i.recordsPath, err)
+		}
+		defer file.Close()
+
+		var searchErr error
+		buffer := make([]byte, depositRecordSize)
+		keepCount = int64(sort.Search(int(i.data.RecordCount), func(index int) bool {
+			if searchErr != nil {
+				return true
+			}
+			_, searchErr = file.ReadAt(buffer, int64(index)*depositRecordSize)
+			if searchErr != nil {
+				return true
+			}
+			return decodeDepositRecord(buffer).BlockNumber > block
+		}))
+		if searchErr != nil {
+			return fmt.Errorf("error reading deposit event records file [%s]: %w", i.recordsPath, searchErr)
+		}
+	}
+
+	// Save the metadata first so a crash before truncating just drops the extra records on the next load
+	i.data.RecordCount = keepCount
+	i.data.NextBlock = block + 1
+	i.data.Checkpoints = checkpoints
+	err := i.saveData()
+	if err != nil {
+		return err
+	}
+	err = os.Truncate(i.recordsPath, keepCount*depositRecordSize)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error truncating deposit event records file [%s]: %w", i.recordsPath, err)
+	}
+	return nil
+}
+
+// Clear the index so it gets rebuilt from the deposit contract's genesis block
+func (i *DepositEventIndex) resetImpl() error {
+	i.data = &depositEventIndexData{
+		NextBlock: i.genesisBlock,
+	}
+	err := i.saveData()
+	if err != nil {
+		return err
+	}
+	err = os.Truncate(i.recordsPath, 0)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error truncating deposit event records file [%s]: %w", i.recordsPath, err)
+	}
+	return nil
+}
+
+// Save the index metadata to disk
+func (i *DepositEventIndex) saveData() error {
+	// Serialize the metadata
+	bytes, err := json.Marshal(i.data)
+	if err != nil {
+		return fmt.Errorf("error serializing deposit event index data: %w", err)
+	}
+
+	// Write it
+	err = os.WriteFile(i.metadataPath, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving deposit event index data to disk: %w", err)
+	}
+	return nil
+}
+
+// Serialize an event into a record and append it to the buffer
+func appendDepositRecord(buffer []byte, event swcontracts.DepositEvent) []byte {
+	buffer = append(buffer, event.Pubkey[:]...)
+	buffer = binary.BigEndian.AppendUint64(buffer, event.BlockNumber)
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(event.TxIndex))
+	buffer = append(buffer, event.TxHash[:]...)
+	return buffer
+}
+
+// Deserialize a record into an event
+func decodeDepositRecord(record []byte) IndexedDepositEvent {
+	offset := beacon.ValidatorPubkeyLength
+	event := IndexedDepositEvent{
+		Pubkey:      beacon.ValidatorPubkey(record[:offset]),
+		BlockNumber: binary.BigEndian.Uint64(record[offset : offset+8]),
+		TxIndex:     binary.BigEndian.Uint32(record[offset+8 : offset+12]),
+	}
+	copy(event.TxHash[:], record[offset+12:])
+	return event
+}
@@ -0,0 +1,58 @@
+package swcommon
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+const (
+	// The current version of the exit archive format
+	ExitArchiveVersion int = 1
+
+	// The EIP-2335 keystore version used for the encrypted archive
+	encryptedExitArchiveVersion int = 4
+)
+
+// Serialize an archive of presigned exits and encrypt it with the provided password
+func EncryptExitArchive(archive *swapi.ExitArchive, password string) (*swapi.EncryptedExitArchive, error) {
+	bytes, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing exit archive: %w", err)
+	}
+
+	encryptor := eth2ks.New(eth2ks.WithCipher("scrypt"))
+	crypto, err := encryptor.Encrypt(bytes, password)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting exit archive: %w", err)
+	}
+	return &swapi.EncryptedExitArchive{
+		Version: encryptedExitArchiveVersion,
+		Crypto:  crypto,
+	}, nil
+}
+
+// Decrypt an archive of presigned exits with the provided password
+func DecryptExitArchive(encryptedArchive *swapi.EncryptedExitArchive, password string) (*swapi.ExitArchive, error) {
+	if encryptedArchive.Version != encryptedExitArchiveVersion {
+		return nil, fmt.Errorf("unsupported encrypted exit archive version %d", encryptedArchive.Version)
+	}
+
+	encryptor := eth2ks.New()
+	bytes, err := encryptor.Decrypt(encryptedArchive.Crypto, password)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting exit archive: %w", err)
+	}
+
+	archive := new(swapi.ExitArchive)
+	err = json.Unmarshal(bytes, archive)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing exit archive: %w", err)
+	}
+	if archive.Version != ExitArchiveVersion {
+		return nil, fmt.Errorf("unsupported exit archive version %d", archive.Version)
+	}
+	return archive, nil
+}
@@ -0,0 +1,275 @@
+package swcommon
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+var (
+	// The order of the lifecycle states; keys can only move forward through them
+	keyLifecycleStateOrder = map[swapi.KeyLifecycleState]int{
+		swapi.KeyLifecycleState_Generated: 0,
+		swapi.KeyLifecycleState_Scanned:   1,
+		swapi.KeyLifecycleState_Offered:   2,
+		swapi.KeyLifecycleState_Deposited: 3,
+		swapi.KeyLifecycleState_Pending:   4,
+		swapi.KeyLifecycleState_Active:    5,
+		swapi.KeyLifecycleState_Exited:    6,
+	}
+)
+
+// A change to the lifecycle state of a key
+type KeyTransition struct {
+	// The key that changed state
+	Pubkey beacon.ValidatorPubkey
+
+	// The details of the new state. The time will be set when the transition is recorded.
+	Event swapi.KeyLifecycleEvent
+}
+
+// KeyLifecycleManager keeps a durable record of every state each validator key has gone through, from generation to exit
+type KeyLifecycleManager struct {
+	dataPath string
+	lock     *sync.Mutex
+
+	data   *keyLifecycleManagerData
+	keyMap map[beacon.ValidatorPubkey]*swapi.KeyLifecycle
+}
+
+type keyLifecycleManagerData struct {
+	// The lifecycle of each key, in the order they were first seen
+	Keys []*swapi.KeyLifecycle `json:"keys"`
+}
+
+// Creates a new manager
+func NewKeyLifecycleManager(sp IStakeWiseServiceProvider) (*KeyLifecycleManager, error) {
+	dataPath := filepath.Join(sp.GetModuleDir(), swconfig.KeyLifecycleFile)
+	mgr := &KeyLifecycleManager{
+		dataPath: dataPath,
+		lock:     &sync.Mutex{},
+	}
+	err := mgr.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading key lifecycles: %w", err)
+	}
+	return mgr, nil
+}
+
+// Reload the key lifecycles from disk
+func (m *KeyLifecycleManager) Reload() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	data := new(keyLifecycleManagerData)
+	_, err := os.Stat(m.dataPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - use the empty list
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking status of key lifecycle file [%s]: %w", m.dataPath, err)
+		}
+	} else {
+		// Read the file
+		bytes, err := os.ReadFile(m.dataPath)
+		if err != nil {
+			return fmt.Errorf("error reading key lifecycle file [%s]: %w", m.dataPath, err)
+		}
+
+		// Deserialize it
+		err = json.Unmarshal(bytes, data)
+		if err != nil {
+			return fmt.Errorf("error deserializing key lifecycle file [%s]: %w", m.dataPath, err)
+		}
+	}
+
+	m.data = data
+	m.keyMap = make(map[beacon.ValidatorPubkey]*swapi.KeyLifecycle, len(data.Keys))
+	for _, key := range data.Keys {
+		m.keyMap[key.Pubkey] = key
+	}
+	return nil
+}
+
+// Record a set of lifecycle transitions and save them to disk.
+// Transitions that would move a key back to an earlier state are ignored, as are ones that repeat the key's current state with the same details.
+// Use RewindDeposits to move a key back after a reorg removes its deposit.
+// Keys that haven't been seen before are added, regardless of which state they start in.
+func (m *KeyLifecycleManager) RecordTransitions(transitions []KeyTransition) error {
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now().UTC()
+	updated := false
+	for _, transition := range transitions {
+		event := transition.Event
+		event.Time = now
+		key, exists := m.keyMap[transition.Pubkey]
+		if !exists {
+			key = &swapi.KeyLifecycle{
+				Pubkey:  transition.Pubkey,
+				State:   event.State,
+				History: []swapi.KeyLifecycleEvent{event},
+			}
+			m.data.Keys = append(m.data.Keys, key)
+			m.keyMap[transition.Pubkey] = key
+			updated = true
+			continue
+		}
+
+		// Ignore regressions and duplicates
+		if keyLifecycleStateOrder[event.State] < keyLifecycleStateOrder[key.State] {
+			continue
+		}
+		if len(key.History) > 0 && isSameLifecycleEvent(key.History[len(key.History)-1], event) {
+			continue
+		}
+		key.State = event.State
+		key.History = append(key.History, event)
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	return m.saveData()
+}
+
+// Move keys that were recorded as deposited back to scanned because their deposits were removed from the chain by a reorg.
+// Only deposits in blocks at or after the first unsettled block are rewound, since anything before it is settled and can't be reorged out.
+// Returns the pubkeys of the keys that were rewound.
+func (m *KeyLifecycleManager) RewindDeposits(pubkeys []beacon.ValidatorPubkey, firstUnsettledBlock uint64) ([]beacon.ValidatorPubkey, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now().UTC()
+	rewound := []beacon.ValidatorPubkey{}
+	for _, pubkey := range pubkeys {
+		key, exists := m.keyMap[pubkey]
+		if !exists || key.State != swapi.KeyLifecycleState_Deposited || len(key.History) == 0 {
+			continue
+		}
+		deposit := key.History[len(key.History)-1]
+		if deposit.BlockNumber < firstUnsettledBlock {
+			continue
+		}
+		key.State = swapi.KeyLifecycleState_Scanned
+		key.History = append(key.History, swapi.KeyLifecycleEvent{
+			State: swapi.KeyLifecycleState_Scanned,
+			Time:  now,
+		})
+		rewound = append(rewound, pubkey)
+	}
+	if len(rewound) == 0 {
+		return rewound, nil
+	}
+	return rewound, m.saveData()
+}
+
+// Get the lifecycle of the keys with the provided pubkeys.
+// Pubkeys that don't have a lifecycle are returned separately.
+func (m *KeyLifecycleManager) GetLifecycles(pubkeys []beacon.ValidatorPubkey) ([]swapi.KeyLifecycle, []beacon.ValidatorPubkey) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	lifecycles := make([]swapi.KeyLifecycle, 0, len(pubkeys))
+	unknown := []beacon.ValidatorPubkey{}
+	for _, pubkey := range pubkeys {
+		key, exists := m.keyMap[pubkey]
+		if !exists {
+			unknown = append(unknown, pubkey)
+			continue
+		}
+		lifecycles = append(lifecycles, copyKeyLifecycle(key))
+	}
+	return lifecycles, unknown
+}
+
+// Get the lifecycle of every key that has one
+func (m *KeyLifecycleManager) GetAllLifecycles() []swapi.KeyLifecycle {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	lifecycles := make([]swapi.KeyLifecycle, len(m.data.Keys))
+	for i, key := range m.data.Keys {
+		lifecycles[i] = copyKeyLifecycle(key)
+	}
+	return lifecycles
+}
+
+// Get the pubkeys of all keys that are currently in one of the provided states
+func (m *KeyLifecycleManager) GetPubkeysInStates(states ...swapi.KeyLifecycleState) []beacon.ValidatorPubkey {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, key := range m.data.Keys {
+		for _, state := range states {
+			if key.State == state {
+				pubkeys = append(pubkeys, key.Pubkey)
+				break
+			}
+		}
+	}
+	return pubkeys
+}
+
+// Get the lifecycle state that corresponds to a validator's status on Beacon
+func GetKeyLifecycleStateForStatus(status beacon.ValidatorState) swapi.KeyLifecycleState {
+	switch status {
+	case beacon.ValidatorState_PendingInitialized, beacon.ValidatorState_PendingQueued:
+		return swapi.KeyLifecycleState_Pending
+	case beacon.ValidatorState_ActiveOngoing, beacon.ValidatorState_ActiveExiting, beacon.ValidatorState_ActiveSlashed:
+		return swapi.KeyLifecycleState_Active
+	default:
+		return swapi.KeyLifecycleState_Exited
+	}
+}
+
+// Save the lifecycles to disk
+func (m *KeyLifecycleManager) saveData() error {
+	// Serialize the lifecycles
+	bytes, err := json.Marshal(m.data)
+	if err != nil {
+		return fmt.Errorf("error serializing key lifecycle data: %w", err)
+	}
+
+	// Write it
+	err = os.WriteFile(m.dataPath, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving key lifecycle data to disk: %w", err)
+	}
+	return nil
+}
+
+// Check if two lifecycle events have the same state and details, ignoring their times
+func isSameLifecycleEvent(a swapi.KeyLifecycleEvent, b swapi.KeyLifecycleEvent) bool {
+	return a.State == b.State &&
+		a.Vault == b.Vault &&
+		a.DepositRoot == b.DepositRoot &&
+		a.TxHash == b.TxHash &&
+		a.BlockNumber == b.BlockNumber &&
+		a.ValidatorIndex == b.ValidatorIndex
+}
+
+// Make a copy of a lifecycle so callers can't modify the manager's history
+func copyKeyLifecycle(key *swapi.KeyLifecycle) swapi.KeyLifecycle {
+	history := make([]swapi.KeyLifecycleEvent, len(key.History))
+	copy(history, key.History)
+	return swapi.KeyLifecycle{
+		Pubkey:  key.Pubkey,
+		State:   key.State,
+		History: history,
+	}
+}
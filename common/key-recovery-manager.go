@@ -0,0 +1,440 @@
+package swcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// The number of indices to derive before saving a checkpoint
+	keyRecoveryBatchSize uint64 = 100
+
+	// The number of keys to derive at once if one isn't provided
+	DefaultKeyRecoveryConcurrency uint64 = 8
+
+	// The number of indices in a row without a match before a gap limit search stops, if one isn't provided
+	DefaultKeyRecoveryGapLimit uint64 = 100
+
+	// The most indices past the start a gap limit search will go, if a limit isn't provided
+	DefaultKeyRecoverySearchLimit uint64 = 10000
+
+	// The most keys that can be derived at once, so the HD daemon isn't overwhelmed
+	MaxKeyRecoveryConcurrency uint64 = 64
+)
+
+var (
+	// A key recovery is already running
+	ErrKeyRecoveryRunning error = errors.New("a key recovery is already running")
+
+	// There's no checkpoint to resume a key recovery from
+	ErrNoKeyRecoveryCheckpoint error = errors.New("there is no interrupted key recovery to resume")
+)
+
+// The settings and progress of a key recovery, saved after each batch so it can be resumed if it's interrupted
+type keyRecoveryCheckpoint struct {
+	// The keys that haven't been found yet
+	Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+
+	// The first index to search
+	StartIndex uint64 `json:"startIndex"`
+
+	// The last index to search
+	SearchEnd uint64 `json:"searchEnd"`
+
+	// The number of indices in a row without a match before the search stops, or 0 to search up to the end
+	GapLimit uint64 `json:"gapLimit"`
+
+	// The last index to search unless another key is found, when using a gap limit
+	GapEnd uint64 `json:"gapEnd"`
+
+	// The number of keys to recover before stopping
+	Count uint64 `json:"count"`
+
+	// The number of keys to derive at once
+	Concurrency uint64 `json:"concurrency"`
+
+	// True to restart the VC once the recovery is done
+	RestartVc bool `json:"restartVc"`
+
+	// The next index to search; every index before it has been searched
+	NextIndex uint64 `json:"nextIndex"`
+
+	// The keys that have been recovered so far
+	Keys []swapi.RecoveredKey `json:"keys"`
+
+	// The time the recovery was first started
+	StartTime time.Time `json:"startTime"`
+}
+
+// KeyRecoveryManager searches the HD wallet for validator keys in the background, so callers can poll its progress instead of waiting on it
+type KeyRecoveryManager struct {
+	sp             IStakeWiseServiceProvider
+	checkpointPath string
+	lock           *sync.Mutex
+
+	// The checkpoint of the current or last recovery, if it hasn't finished
+	checkpoint *keyRecoveryCheckpoint
+
+	// The progress of the current or last recovery
+	progress swapi.KeyRecoveryProgress
+}
+
+// Creates a new manager
+func NewKeyRecoveryManager(sp IStakeWiseServiceProvider) (*KeyRecoveryManager, error) {
+	checkpointPath := filepath.Join(sp.GetModuleDir(), swconfig.KeyRecoveryFile)
+	mgr := &KeyRecoveryManager{
+		sp:             sp,
+		checkpointPath: checkpointPath,
+		lock:           &sync.Mutex{},
+	}
+	err := mgr.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading key recovery checkpoint: %w", err)
+	}
+	return mgr, nil
+}
+
+// Reload the checkpoint from disk. If there is one, the recovery it was saved for is marked as interrupted.
+func (m *KeyRecoveryManager) Reload() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.progress.State == swapi.KeyRecoveryState_Running {
+		return ErrKeyRecoveryRunning
+	}
+
+	m.checkpoint = nil
+	m.progress = swapi.KeyRecoveryProgress{
+		State: swapi.KeyRecoveryState_Idle,
+		Keys:  []swapi.RecoveredKey{},
+	}
+	bytes, err := os.ReadFile(m.checkpointPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - there's nothing to resume
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading key recovery checkpoint [%s]: %w", m.checkpointPath, err)
+	}
+
+	checkpoint := new(keyRecoveryCheckpoint)
+	err = json.Unmarshal(bytes, checkpoint)
+	if err != nil {
+		return fmt.Errorf("error deserializing key recovery checkpoint [%s]: %w", m.checkpointPath, err)
+	}
+	m.checkpoint = checkpoint
+	m.updateProgress(swapi.KeyRecoveryState_Interrupted, nil)
+	return nil
+}
+
+// Get the progress of the current or last recovery
+func (m *KeyRecoveryManager) GetProgress() swapi.KeyRecoveryProgress {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.getProgressImpl()
+}
+
+// Start a new recovery in the background, searching from the start index until the provided keys have been found,
+// the count has been reached, or the search limit has been passed. Any interrupted recovery is discarded.
+func (m *KeyRecoveryManager) Start(logger *slog.Logger, pubkeys []beacon.ValidatorPubkey, startIndex uint64, count uint64, searchLimit uint64, concurrency uint64, restartVc bool) (swapi.KeyRecoveryProgress, error) {
+	if count == 0 {
+		return swapi.KeyRecoveryProgress{}, fmt.Errorf("count must be greater than 0")
+	}
+	return m.startNew(logger, pubkeys, startIndex, count, searchLimit, 0, concurrency, restartVc)
+}
+
+// Start a new recovery in the background that searches from the start index until all of the provided keys have been found,
+// the gap limit has been passed without finding one, or the search limit has been passed. Any interrupted recovery is discarded.
+func (m *KeyRecoveryManager) StartGapSearch(logger *slog.Logger, pubkeys []beacon.ValidatorPubkey, startIndex uint64, gapLimit uint64, searchLimit uint64, concurrency uint64, restartVc bool) (swapi.KeyRecoveryProgress, error) {
+	if gapLimit == 0 {
+		gapLimit = DefaultKeyRecoveryGapLimit
+	}
+	if searchLimit == 0 {
+		searchLimit = DefaultKeyRecoverySearchLimit
+	}
+	return m.startNew(logger, pubkeys, startIndex, uint64(len(pubkeys)), searchLimit, gapLimit, concurrency, restartVc)
+}
+
+// Save the checkpoint for a new recovery and start running it
+func (m *KeyRecoveryManager) startNew(logger *slog.Logger, pubkeys []beacon.ValidatorPubkey, startIndex uint64, count uint64, searchLimit uint64, gapLimit uint64, concurrency uint64, restartVc bool) (swapi.KeyRecoveryProgress, error) {
+	// Sanity checking
+	if len(pubkeys) == 0 {
+		return swapi.KeyRecoveryProgress{}, fmt.Errorf("no keys to search for")
+	}
+	if concurrency == 0 {
+		concurrency = DefaultKeyRecoveryConcurrency
+	}
+	if concurrency > MaxKeyRecoveryConcurrency {
+		return swapi.KeyRecoveryProgress{}, fmt.Errorf("concurrency can't be more than %d", MaxKeyRecoveryConcurrency)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.progress.State == swapi.KeyRecoveryState_Running {
+		return m.getProgressImpl(), ErrKeyRecoveryRunning
+	}
+
+	// Only search for each key once
+	remaining := []beacon.ValidatorPubkey{}
+	seen := map[beacon.ValidatorPubkey]bool{}
+	for _, pubkey := range pubkeys {
+		if !seen[pubkey] {
+			seen[pubkey] = true
+			remaining = append(remaining, pubkey)
+		}
+	}
+	if count > uint64(len(remaining)) {
+		count = uint64(len(remaining))
+	}
+
+	checkpoint := &keyRecoveryCheckpoint{
+		Pubkeys:     remaining,
+		StartIndex:  startIndex,
+		SearchEnd:   startIndex + searchLimit,
+		Count:       count,
+		Concurrency: concurrency,
+		RestartVc:   restartVc,
+		NextIndex:   startIndex,
+		Keys:        []swapi.RecoveredKey{},
+		StartTime:   time.Now().UTC(),
+	}
+	if gapLimit > 0 {
+		checkpoint.GapLimit = gapLimit
+		checkpoint.GapEnd = startIndex + gapLimit - 1
+	}
+	err := m.saveCheckpoint(checkpoint)
+	if err != nil {
+		return swapi.KeyRecoveryProgress{}, err
+	}
+	m.checkpoint = checkpoint
+	m.start(logger)
+	return m.getProgressImpl(), nil
+}
+
+// Resume an interrupted or failed recovery from its checkpoint in the background
+func (m *KeyRecoveryManager) Resume(logger *slog.Logger) (swapi.KeyRecoveryProgress, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.progress.State == swapi.KeyRecoveryState_Running {
+		return m.getProgressImpl(), ErrKeyRecoveryRunning
+	}
+	if m.checkpoint == nil {
+		return m.getProgressImpl(), ErrNoKeyRecoveryCheckpoint
+	}
+
+	m.start(logger)
+	return m.getProgressImpl(), nil
+}
+
+// Start running the recovery for the current checkpoint, used when the lock is already held
+func (m *KeyRecoveryManager) start(logger *slog.Logger) {
+	m.updateProgress(swapi.KeyRecoveryState_Running, nil)
+	logger.Info("Starting key recovery", "keys", len(m.checkpoint.Pubkeys), "start", m.checkpoint.NextIndex, "end", m.checkpoint.SearchEnd, "gapLimit", m.checkpoint.GapLimit, "concurrency", m.checkpoint.Concurrency)
+	go func() {
+		err := m.run(m.sp.GetBaseContext(), logger)
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		switch {
+		case err == nil:
+			m.updateProgress(swapi.KeyRecoveryState_Complete, nil)
+			logger.Info("Key recovery complete", "recovered", len(m.progress.Keys))
+		case errors.Is(err, context.Canceled):
+			m.updateProgress(swapi.KeyRecoveryState_Interrupted, nil)
+			logger.Warn("Key recovery was interrupted, it can be resumed later", "next", m.progress.NextIndex)
+		default:
+			m.updateProgress(swapi.KeyRecoveryState_Failed, err)
+			logger.Error("Key recovery failed, it can be resumed later", "next", m.progress.NextIndex, log.Err(err))
+		}
+	}()
+}
+
+// Run the recovery for the current checkpoint until it's done
+func (m *KeyRecoveryManager) run(ctx context.Context, logger *slog.Logger) error {
+	wallet := m.sp.GetWallet()
+
+	// Work on a copy of the checkpoint so progress can be read while a batch is running
+	m.lock.Lock()
+	checkpoint := *m.checkpoint
+	checkpoint.Pubkeys = append([]beacon.ValidatorPubkey{}, m.checkpoint.Pubkeys...)
+	checkpoint.Keys = append([]swapi.RecoveredKey{}, m.checkpoint.Keys...)
+	m.lock.Unlock()
+
+	searchMap := make(map[beacon.ValidatorPubkey]struct{}, len(checkpoint.Pubkeys))
+	for _, pubkey := range checkpoint.Pubkeys {
+		searchMap[pubkey] = struct{}{}
+	}
+
+	for !isKeyRecoveryDone(&checkpoint, len(searchMap)) {
+		// Derive the next batch of keys
+		batchSize := keyRecoveryBatchSize
+		batchEnd := checkpoint.SearchEnd
+		if checkpoint.GapLimit > 0 && checkpoint.GapEnd < batchEnd {
+			batchEnd = checkpoint.GapEnd
+		}
+		if batchEnd-checkpoint.NextIndex+1 < batchSize {
+			batchSize = batchEnd - checkpoint.NextIndex + 1
+		}
+		keys, err := wallet.DeriveValidatorKeys(ctx, checkpoint.NextIndex, batchSize, checkpoint.Concurrency)
+		if err != nil {
+			return fmt.Errorf("error deriving keys %d to %d: %w", checkpoint.NextIndex, checkpoint.NextIndex+batchSize-1, err)
+		}
+
+		// Save the ones being searched for, in index order so the count stops at the lowest matching indices
+		for _, key := range keys {
+			index := checkpoint.NextIndex
+			pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+			_, exists := searchMap[pubkey]
+			if exists {
+				err = wallet.SaveRecoveredKey(key, index)
+				if err != nil {
+					return fmt.Errorf("error saving recovered key %s: %w", pubkey.HexWithPrefix(), err)
+				}
+				delete(searchMap, pubkey)
+				checkpoint.Keys = append(checkpoint.Keys, swapi.RecoveredKey{
+					Pubkey: pubkey,
+					Index:  index,
+				})
+				logger.Info("Recovered key", "pubkey", pubkey.HexWithPrefix(), "index", index)
+
+				// Finding a key resets the gap
+				if checkpoint.GapLimit > 0 {
+					checkpoint.GapEnd = index + checkpoint.GapLimit
+				}
+			}
+			checkpoint.NextIndex++
+			if isKeyRecoveryDone(&checkpoint, len(searchMap)) {
+				break
+			}
+		}
+
+		// Save the checkpoint
+		checkpoint.Pubkeys = checkpoint.Pubkeys[:0]
+		for pubkey := range searchMap {
+			checkpoint.Pubkeys = append(checkpoint.Pubkeys, pubkey)
+		}
+		err = m.commitBatch(&checkpoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Restart the VC so it loads the recovered keys
+	if checkpoint.RestartVc && len(checkpoint.Keys) > 0 {
+		_, err := m.sp.GetHyperdriveClient().Service.RestartContainer(string(swconfig.ContainerID_StakewiseValidator))
+		if err != nil {
+			return fmt.Errorf("error restarting the VC: %w", err)
+		}
+		m.lock.Lock()
+		m.progress.VcRestarted = true
+		m.lock.Unlock()
+	}
+
+	// The recovery is done, so the checkpoint isn't needed anymore
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	err := os.Remove(m.checkpointPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing key recovery checkpoint [%s]: %w", m.checkpointPath, err)
+	}
+	m.checkpoint = nil
+	return nil
+}
+
+// Save a checkpoint after a batch and publish its progress
+func (m *KeyRecoveryManager) commitBatch(checkpoint *keyRecoveryCheckpoint) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	saved := *checkpoint
+	saved.Pubkeys = append([]beacon.ValidatorPubkey{}, checkpoint.Pubkeys...)
+	saved.Keys = append([]swapi.RecoveredKey{}, checkpoint.Keys...)
+	err := m.saveCheckpoint(&saved)
+	if err != nil {
+		return err
+	}
+	m.checkpoint = &saved
+	m.updateProgress(swapi.KeyRecoveryState_Running, nil)
+	return nil
+}
+
+// Update the progress from the current checkpoint, used when the lock is already held
+func (m *KeyRecoveryManager) updateProgress(state swapi.KeyRecoveryState, err error) {
+	progress := swapi.KeyRecoveryProgress{
+		State:       state,
+		VcRestarted: m.progress.VcRestarted,
+		StartTime:   m.progress.StartTime,
+		Keys:        m.progress.Keys,
+		UpdateTime:  time.Now().UTC(),
+	}
+	if state == swapi.KeyRecoveryState_Running && m.progress.State != swapi.KeyRecoveryState_Running {
+		progress.VcRestarted = false
+	}
+	if m.checkpoint != nil {
+		progress.StartIndex = m.checkpoint.StartIndex
+		progress.NextIndex = m.checkpoint.NextIndex
+		progress.SearchEnd = m.checkpoint.SearchEnd
+		progress.GapLimit = m.checkpoint.GapLimit
+		progress.GapEnd = m.checkpoint.GapEnd
+		progress.Count = m.checkpoint.Count
+		progress.Concurrency = m.checkpoint.Concurrency
+		progress.KeysToFind = len(m.checkpoint.Pubkeys)
+		progress.Keys = append([]swapi.RecoveredKey{}, m.checkpoint.Keys...)
+		progress.StartTime = m.checkpoint.StartTime
+	} else if state == swapi.KeyRecoveryState_Complete {
+		// The checkpoint was removed, so the last batch's progress is the final result
+		progress.StartIndex = m.progress.StartIndex
+		progress.NextIndex = m.progress.NextIndex
+		progress.SearchEnd = m.progress.SearchEnd
+		progress.GapLimit = m.progress.GapLimit
+		progress.GapEnd = m.progress.GapEnd
+		progress.Count = m.progress.Count
+		progress.Concurrency = m.progress.Concurrency
+		progress.KeysToFind = m.progress.KeysToFind
+	}
+	if err != nil {
+		progress.Error = err.Error()
+	}
+	m.progress = progress
+}
+
+// Get a copy of the progress, used when the lock is already held
+func (m *KeyRecoveryManager) getProgressImpl() swapi.KeyRecoveryProgress {
+	progress := m.progress
+	progress.Keys = append([]swapi.RecoveredKey{}, m.progress.Keys...)
+	return progress
+}
+
+// Write a checkpoint to disk
+func (m *KeyRecoveryManager) saveCheckpoint(checkpoint *keyRecoveryCheckpoint) error {
+	bytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("error serializing key recovery checkpoint: %w", err)
+	}
+	err = os.WriteFile(m.checkpointPath, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving key recovery checkpoint [%s]: %w", m.checkpointPath, err)
+	}
+	return nil
+}
+
+// Check if a recovery has found all of its keys, reached its count, or passed its search or gap limit
+func isKeyRecoveryDone(checkpoint *keyRecoveryCheckpoint, keysToFind int) bool {
+	return keysToFind == 0 ||
+		uint64(len(checkpoint.Keys)) >= checkpoint.Count ||
+		checkpoint.NextIndex > checkpoint.SearchEnd ||
+		(checkpoint.GapLimit > 0 && checkpoint.NextIndex > checkpoint.GapEnd)
+}
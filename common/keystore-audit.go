@@ -0,0 +1,422 @@
+package swcommon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-json"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	nmcconfig "github.com/rocket-pool/node-manager-core/config"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// The result of checking a keystore for a key
+type keystoreCheck struct {
+	// True if there's a keystore for the key
+	exists bool
+
+	// The derivation path saved in the keystore, if it could be read
+	derivationPath string
+
+	// The error reading or decrypting the keystore, if there was one
+	err error
+
+	// The key the keystore actually decrypts to, if it isn't the key it's saved for
+	mismatchedPubkey *beacon.ValidatorPubkey
+}
+
+// A VC's keystore store, as far as an audit is concerned
+type vcKeystoreChecker interface {
+	// Get the pubkeys of every key the VC has a keystore for
+	getPubkeys() ([]beacon.ValidatorPubkey, error)
+
+	// Check the VC's keystore for a key
+	checkKey(pubkey beacon.ValidatorPubkey) keystoreCheck
+}
+
+// Checks Prysm's account store, which holds every key in one keystore
+type prysmKeystoreChecker struct {
+	keystorePath string
+	passwordPath string
+	loaded       bool
+	loadErr      error
+	publicKeys   [][]byte
+	privateKeys  [][]byte
+}
+
+// Check every key in the available keys, the StakeWise keystore folder, the selected VC's keystore folder, and the provided list of keys
+// registered with NodeSet for missing, orphaned, undecryptable, and mismatched keystores.
+// If registeredPubkeys is nil, NodeSet's list isn't known and keys won't be checked for being orphaned.
+// If repair is set, keys with broken or missing keystores are re-derived from a derivation path saved in one of their keystores and saved again.
+// Only keys in the available keys or NodeSet's list that aren't orphaned are repaired.
+func (w *Wallet) AuditKeys(registeredPubkeys []beacon.ValidatorPubkey, repair bool) (string, []swapi.KeyAuditInfo, error) {
+	if w.sp.GetConfig().IsRemoteSignerEnabled() {
+		return "", nil, fmt.Errorf("validator keys are held by a remote signer, so there are no local keystores to audit")
+	}
+
+	keyMgr := w.sp.GetAvailableKeyManager()
+	validatorPath := filepath.Join(w.sp.GetModuleDir(), config.ValidatorsDirectory)
+	vcName, vc := getVcKeystoreChecker(w.sp.GetHyperdriveConfig().GetSelectedBeaconNode(), validatorPath)
+
+	// Get every key from each source, keeping the order they were found in
+	availableMap := map[beacon.ValidatorPubkey]bool{}
+	registeredMap := map[beacon.ValidatorPubkey]bool{}
+	allPubkeys := []beacon.ValidatorPubkey{}
+	seen := map[beacon.ValidatorPubkey]bool{}
+	addPubkeys := func(pubkeys []beacon.ValidatorPubkey, sourceMap map[beacon.ValidatorPubkey]bool) {
+		for _, pubkey := range pubkeys {
+			if sourceMap != nil {
+				sourceMap[pubkey] = true
+			}
+			if !seen[pubkey] {
+				seen[pubkey] = true
+				allPubkeys = append(allPubkeys, pubkey)
+			}
+		}
+	}
+	addPubkeys(keyMgr.GetPubkeys(), availableMap)
+
+	w.keystoreLock.Lock()
+	ks := w.stakewiseKeystoreManager
+	stakewisePubkeys, err := ks.GetStoredPubkeys()
+	if err != nil {
+		w.keystoreLock.Unlock()
+		return "", nil, err
+	}
+	addPubkeys(stakewisePubkeys, nil)
+	vcPubkeys, err := vc.getPubkeys()
+	if err != nil {
+		w.keystoreLock.Unlock()
+		return "", nil, fmt.Errorf("error getting %s keystores: %w", vcName, err)
+	}
+	addPubkeys(vcPubkeys, nil)
+	addPubkeys(registeredPubkeys, registeredMap)
+
+	// Check each key's keystores
+	results := make([]swapi.KeyAuditInfo, len(allPubkeys))
+	derivationPaths := make([][]string, len(allPubkeys))
+	for i, pubkey := range allPubkeys {
+		info := &results[i]
+		info.Pubkey = pubkey
+		info.InAvailableKeys = availableMap[pubkey]
+		info.RegisteredWithNodeSet = registeredMap[pubkey]
+		info.Issues = []swapi.KeyAuditIssue{}
+		info.Errors = []string{}
+		tracked := info.InAvailableKeys || info.RegisteredWithNodeSet
+
+		swCheck := checkKeystoreFile(ks.getKeystorePath(pubkey), func() (string, error) { return ks.password, nil }, pubkey)
+		vcCheck := vc.checkKey(pubkey)
+		info.HasKeystore = swCheck.exists
+		info.HasVcKeystore = vcCheck.exists
+
+		// Check the StakeWise keystore
+		if !swCheck.exists && tracked {
+			info.Issues = append(info.Issues, swapi.KeyAuditIssue_MissingKeystore)
+		}
+		if swCheck.err != nil {
+			info.Issues = append(info.Issues, swapi.KeyAuditIssue_Undecryptable)
+			info.Errors = append(info.Errors, fmt.Sprintf("StakeWise keystore: %s", swCheck.err.Error()))
+		}
+		if swCheck.mismatchedPubkey != nil {
+			info.Issues = append(info.Issues, swapi.KeyAuditIssue_PubkeyMismatch)
+			info.Errors = append(info.Errors, fmt.Sprintf("StakeWise keystore decrypts to key %s", swCheck.mismatchedPubkey.HexWithPrefix()))
+		}
+
+		// Check the VC keystore
+		if !vcCheck.exists && (swCheck.exists || tracked) {
+			info.Issues = append(info.Issues, swapi.KeyAuditIssue_MissingVcKeystore)
+		}
+		if vcCheck.err != nil {
+			info.Issues = append(info.Issues, swapi.KeyAuditIssue_VcUndecryptable)
+			info.Errors = append(info.Errors, fmt.Sprintf("%s keystore: %s", vcName, vcCheck.err.Error()))
+		}
+		if vcCheck.mismatchedPubkey != nil {
+			if swCheck.mismatchedPubkey == nil {
+				info.Issues = append(info.Issues, swapi.KeyAuditIssue_PubkeyMismatch)
+			}
+			info.Errors = append(info.Errors, fmt.Sprintf("%s keystore decrypts to key %s", vcName, vcCheck.mismatchedPubkey.HexWithPrefix()))
+		}
+
+		// Keys that aren't tracked anywhere can only be found to be orphaned if NodeSet's list is known
+		if registeredPubkeys != nil && !tracked && (swCheck.exists || vcCheck.exists) {
+			info.Issues = append(info.Issues, swapi.KeyAuditIssue_Orphaned)
+		}
+
+		// Prefer paths from keystores that decrypted to the right key
+		paths := []string{}
+		for _, check := range []keystoreCheck{swCheck, vcCheck} {
+			if check.err == nil && check.mismatchedPubkey == nil && check.derivationPath != "" {
+				paths = append(paths, check.derivationPath)
+			}
+		}
+		for _, check := range []keystoreCheck{swCheck, vcCheck} {
+			if check.derivationPath != "" {
+				paths = append(paths, check.derivationPath)
+			}
+		}
+		if len(paths) > 0 {
+			info.DerivationPath = paths[0]
+		}
+		derivationPaths[i] = paths
+	}
+	w.keystoreLock.Unlock()
+
+	if !repair {
+		return vcName, results, nil
+	}
+
+	// Repair the keys that can be re-derived
+	for i := range results {
+		info := &results[i]
+		if len(info.Issues) == 0 {
+			continue
+		}
+		if !info.InAvailableKeys && !info.RegisteredWithNodeSet {
+			info.RepairError = "the key isn't in the available keys or registered with NodeSet, so it won't be added to the VC in case it's being used somewhere else"
+			continue
+		}
+		if !isRepairable(info.Issues) {
+			continue
+		}
+		err := w.repairKey(info.Pubkey, derivationPaths[i])
+		if err != nil {
+			info.RepairError = err.Error()
+			continue
+		}
+		info.Repaired = true
+	}
+	return vcName, results, nil
+}
+
+// Re-derive a key from the first of the provided paths that derives it, and save it to the keystores again
+func (w *Wallet) repairKey(pubkey beacon.ValidatorPubkey, derivationPaths []string) error {
+	if len(derivationPaths) == 0 {
+		return fmt.Errorf("no derivation path was found in any keystore for the key, use recover-keys to search for it instead")
+	}
+
+	client := w.sp.GetHyperdriveClient()
+	tried := map[string]bool{}
+	for _, path := range derivationPaths {
+		if tried[path] {
+			continue
+		}
+		tried[path] = true
+
+		// Ask the HD daemon to derive the key
+		response, err := client.Wallet.GenerateValidatorKey(path)
+		if err != nil {
+			return fmt.Errorf("error generating validator key for path [%s]: %w", path, err)
+		}
+		key, err := eth2types.BLSPrivateKeyFromBytes(response.Data.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("error converting BLS private key for path %s: %w", path, err)
+		}
+		if beacon.ValidatorPubkey(key.PublicKey().Marshal()) != pubkey {
+			continue
+		}
+
+		// Save it again
+		err = w.sp.GetValidatorSigner().ImportKey(w.sp.GetBaseContext(), key, path)
+		if err != nil {
+			return fmt.Errorf("error saving re-derived key: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("none of the derivation paths found in the key's keystores derive it")
+}
+
+// Check if a key with the provided issues can be repaired by re-deriving it.
+// Orphaned keys need a person to decide what to do with them, since putting a key that might be running somewhere else into the VC could get it slashed.
+func isRepairable(issues []swapi.KeyAuditIssue) bool {
+	for _, issue := range issues {
+		if issue == swapi.KeyAuditIssue_Orphaned {
+			return false
+		}
+	}
+	return len(issues) > 0
+}
+
+// Get the keystore checker for the selected VC
+func getVcKeystoreChecker(bn nmcconfig.BeaconNode, validatorPath string) (string, vcKeystoreChecker) {
+	if bn == nmcconfig.BeaconNode_Prysm {
+		keystorePath, passwordPath := getPrysmKeystorePaths(validatorPath)
+		return string(bn), &prysmKeystoreChecker{
+			keystorePath: keystorePath,
+			passwordPath: passwordPath,
+		}
+	}
+	layouts := getVcKeystoreLayouts(validatorPath)
+	for _, layout := range layouts {
+		if layout.name == string(bn) {
+			return layout.name, layout
+		}
+	}
+	return layouts[0].name, layouts[0]
+}
+
+// Get the pubkeys of every key the VC has a keystore for
+func (l vcKeystoreLayout) getPubkeys() ([]beacon.ValidatorPubkey, error) {
+	entries, err := os.ReadDir(l.keysDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []beacon.ValidatorPubkey{}, nil
+		}
+		return nil, fmt.Errorf("error enumerating keystore folder [%s]: %w", l.keysDir, err)
+	}
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, entry := range entries {
+		pubkey, ok := l.getEntryPubkey(entry)
+		if ok {
+			pubkeys = append(pubkeys, pubkey)
+		}
+	}
+	return pubkeys, nil
+}
+
+// Check the VC's keystore for a key
+func (l vcKeystoreLayout) checkKey(pubkey beacon.ValidatorPubkey) keystoreCheck {
+	return checkKeystoreFile(l.keystorePath(pubkey), func() (string, error) {
+		password, err := os.ReadFile(l.passwordPath(pubkey))
+		if err != nil {
+			return "", fmt.Errorf("error reading keystore password: %w", err)
+		}
+		return string(password), nil
+	}, pubkey)
+}
+
+// Get the pubkeys of every key in the account store
+func (c *prysmKeystoreChecker) getPubkeys() ([]beacon.ValidatorPubkey, error) {
+	c.load()
+	if c.loadErr != nil {
+		// The account store itself is broken, so the keys in it can't be listed; the errors are reported for each key instead
+		return []beacon.ValidatorPubkey{}, nil
+	}
+	pubkeys := make([]beacon.ValidatorPubkey, len(c.publicKeys))
+	for i, pubkey := range c.publicKeys {
+		pubkeys[i] = beacon.ValidatorPubkey(pubkey)
+	}
+	return pubkeys, nil
+}
+
+// Check the account store for a key
+func (c *prysmKeystoreChecker) checkKey(pubkey beacon.ValidatorPubkey) keystoreCheck {
+	c.load()
+	if c.loadErr != nil {
+		_, err := os.Stat(c.keystorePath)
+		return keystoreCheck{
+			exists: !errors.Is(err, fs.ErrNotExist),
+			err:    c.loadErr,
+		}
+	}
+	for i, storedPubkey := range c.publicKeys {
+		if !bytes.Equal(storedPubkey, pubkey[:]) {
+			continue
+		}
+		check := keystoreCheck{
+			exists: true,
+		}
+		key, err := eth2types.BLSPrivateKeyFromBytes(c.privateKeys[i])
+		if err != nil {
+			check.err = fmt.Errorf("error recreating private key: %w", err)
+			return check
+		}
+		actualPubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+		if actualPubkey != pubkey {
+			check.mismatchedPubkey = &actualPubkey
+		}
+		return check
+	}
+	return keystoreCheck{}
+}
+
+// Decrypt the account store the first time it's needed
+func (c *prysmKeystoreChecker) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+
+	keystoreBytes, err := os.ReadFile(c.keystorePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.loadErr = fmt.Errorf("error reading account store: %w", err)
+		}
+		return
+	}
+	password, err := os.ReadFile(c.passwordPath)
+	if err != nil {
+		c.loadErr = fmt.Errorf("error reading account store password: %w", err)
+		return
+	}
+	secret, _, err := decryptKeystore(keystoreBytes, string(password))
+	if err != nil {
+		c.loadErr = fmt.Errorf("error decrypting account store: %w", err)
+		return
+	}
+	var accountStore struct {
+		PrivateKeys [][]byte `json:"private_keys"`
+		PublicKeys  [][]byte `json:"public_keys"`
+	}
+	err = json.Unmarshal(secret, &accountStore)
+	if err != nil {
+		c.loadErr = fmt.Errorf("error deserializing account store: %w", err)
+		return
+	}
+	if len(accountStore.PrivateKeys) != len(accountStore.PublicKeys) {
+		c.loadErr = fmt.Errorf("account store has %d private keys but %d public keys", len(accountStore.PrivateKeys), len(accountStore.PublicKeys))
+		return
+	}
+	c.privateKeys = accountStore.PrivateKeys
+	c.publicKeys = accountStore.PublicKeys
+}
+
+// Check that a keystore file exists, can be decrypted, and holds the key it's saved for
+func checkKeystoreFile(keystorePath string, getPassword func() (string, error), pubkey beacon.ValidatorPubkey) keystoreCheck {
+	check := keystoreCheck{}
+	keystoreBytes, err := os.ReadFile(keystorePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return check
+	}
+	check.exists = true
+	if err != nil {
+		check.err = fmt.Errorf("error reading keystore: %w", err)
+		return check
+	}
+
+	// Get the path first so the key can be re-derived even if the keystore can't be decrypted
+	var keystore map[string]any
+	err = json.Unmarshal(keystoreBytes, &keystore)
+	if err != nil {
+		check.err = fmt.Errorf("error deserializing keystore: %w", err)
+		return check
+	}
+	check.derivationPath, _ = keystore["path"].(string)
+
+	// Decrypt it
+	password, err := getPassword()
+	if err != nil {
+		check.err = err
+		return check
+	}
+	secret, _, err := decryptKeystore(keystoreBytes, password)
+	if err != nil {
+		check.err = fmt.Errorf("error decrypting keystore: %w", err)
+		return check
+	}
+	key, err := eth2types.BLSPrivateKeyFromBytes(secret)
+	if err != nil {
+		check.err = fmt.Errorf("error recreating private key: %w", err)
+		return check
+	}
+	actualPubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+	if actualPubkey != pubkey {
+		check.mismatchedPubkey = &actualPubkey
+	}
+	return check
+}
@@ -0,0 +1,502 @@
+package swcommon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	"github.com/rocket-pool/node-manager-core/utils"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+const (
+	// The suffix for re-encrypted files before they replace the originals
+	rotationStagingSuffix string = ".rotating"
+
+	// How many times to try re-encrypting the keystores while keys are still being saved before holding the keystore lock for the whole rotation
+	maxRotationAttempts int = 3
+)
+
+// The keystores that were re-encrypted by a keystore password rotation
+type KeystoreRotationResult struct {
+	// The keys in the StakeWise keystore folder that were re-encrypted
+	Pubkeys []beacon.ValidatorPubkey
+
+	// The number of VC keystores that were re-encrypted
+	VcKeystoreCount int
+}
+
+// A file replaced by a keystore password rotation
+type keystoreRotationFile struct {
+	// The file being replaced
+	Target string `json:"target"`
+
+	// The re-encrypted file that replaces it
+	Staged string `json:"staged"`
+}
+
+// Journal of a committed keystore password rotation.
+// If it exists on startup, the daemon stopped partway through swapping in the staged files and the swap needs to be finished.
+type keystoreRotationJournal struct {
+	Files []keystoreRotationFile `json:"files"`
+}
+
+// Where a VC keeps each validator's keystore and the password for it.
+// These match the layouts node-manager-core's keystore managers save keys with.
+type vcKeystoreLayout struct {
+	name         string
+	encryptor    *eth2ks.Encryptor
+	keystorePath func(pubkey beacon.ValidatorPubkey) string
+	passwordPath func(pubkey beacon.ValidatorPubkey) string
+
+	// The folder with the keystores, and how to get the pubkey from each entry's name in it
+	keysDir        string
+	getEntryPubkey func(entry fs.DirEntry) (beacon.ValidatorPubkey, bool)
+}
+
+// Re-encrypts keystores with new passwords in a way that can be recovered from if the daemon stops partway through.
+// Every re-encrypted file is staged next to its original and verified first; the rotation is committed by writing a journal of the staged
+// files, which are then swapped in. An interrupted rotation is rolled back if it wasn't committed yet, and finished if it was.
+type keystoreRotation struct {
+	moduleDir string
+	files     []keystoreRotationFile
+}
+
+// Re-encrypt every keystore in the StakeWise keystore folder with a new random password, optionally re-encrypting the VC keystores as well.
+// The keystores are re-encrypted without holding the keystore lock so keys can still be loaded in the meantime; the lock is only taken to swap them in.
+// If a keystore was written while they were being re-encrypted, the rotation starts over, and the last attempt holds the lock the whole time so it can't be interrupted.
+func (w *Wallet) RotateKeystorePassword(rotateVc bool) (*KeystoreRotationResult, error) {
+	w.rotationLock.Lock()
+	defer w.rotationLock.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		lastAttempt := attempt == maxRotationAttempts
+		w.keystoreLock.Lock()
+		generation := w.keystoreGeneration
+		ks := w.stakewiseKeystoreManager
+		if !lastAttempt {
+			w.keystoreLock.Unlock()
+		}
+
+		// Stage the re-encrypted files
+		rotation, result, err := w.stageKeystoreRotation(ks, rotateVc)
+		if err != nil {
+			if lastAttempt {
+				w.keystoreLock.Unlock()
+			}
+			return nil, err
+		}
+
+		// Start over if any keystores changed in the meantime, since they weren't staged with the new passwords
+		if !lastAttempt {
+			w.keystoreLock.Lock()
+			if w.keystoreGeneration != generation {
+				w.keystoreLock.Unlock()
+				err = rotation.discard(nil)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		result, err = w.commitKeystoreRotation(rotation, result)
+		w.keystoreLock.Unlock()
+		return result, err
+	}
+}
+
+// Stage re-encrypted copies of the keystores and their passwords
+func (w *Wallet) stageKeystoreRotation(ks *stakewiseKeystoreManager, rotateVc bool) (*keystoreRotation, *KeystoreRotationResult, error) {
+	moduleDir := w.sp.GetModuleDir()
+	pubkeys, err := ks.GetStoredPubkeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	rotation := &keystoreRotation{
+		moduleDir: moduleDir,
+	}
+	result := &KeystoreRotationResult{
+		Pubkeys: pubkeys,
+	}
+
+	// Stage the StakeWise keystores and the new password
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating new keystore password: %w", err)
+	}
+	for _, pubkey := range pubkeys {
+		err = rotation.stageKeystore(ks.encryptor, ks.getKeystorePath(pubkey), ks.password, password)
+		if err != nil {
+			return nil, nil, rotation.discard(err)
+		}
+	}
+	err = rotation.stageFile(ks.passwordPath, []byte(password))
+	if err != nil {
+		return nil, nil, rotation.discard(err)
+	}
+
+	// Stage the VC keystores
+	if rotateVc {
+		validatorPath := filepath.Join(moduleDir, config.ValidatorsDirectory)
+		for _, layout := range getVcKeystoreLayouts(validatorPath) {
+			count, err := rotation.stageVcKeystores(layout, pubkeys)
+			if err != nil {
+				return nil, nil, rotation.discard(fmt.Errorf("error re-encrypting %s keystores: %w", layout.name, err))
+			}
+			result.VcKeystoreCount += count
+		}
+		count, err := rotation.stagePrysmKeystore(validatorPath)
+		if err != nil {
+			return nil, nil, rotation.discard(fmt.Errorf("error re-encrypting prysm keystore: %w", err))
+		}
+		result.VcKeystoreCount += count
+	}
+	return rotation, result, nil
+}
+
+// Swap in the staged files and reload the keystore managers so they use the new passwords; the keystore lock must be held
+func (w *Wallet) commitKeystoreRotation(rotation *keystoreRotation, result *KeystoreRotationResult) (*KeystoreRotationResult, error) {
+	moduleDir := w.sp.GetModuleDir()
+	err := rotation.commit()
+	if err != nil {
+		return nil, rotation.discard(err)
+	}
+	err = finishKeystoreRotation(moduleDir)
+	if err != nil {
+		return nil, err
+	}
+
+	stakewiseKeystoreMgr, err := newStakewiseKeystoreManager(moduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reloading Stakewise keystore manager: %w", err)
+	}
+	w.stakewiseKeystoreManager = stakewiseKeystoreMgr
+	w.validatorManager = validator.NewValidatorManager(filepath.Join(moduleDir, config.ValidatorsDirectory))
+	w.keystoreGeneration++
+	return result, nil
+}
+
+// Finish a committed keystore password rotation that was interrupted, and remove any staged files from one that wasn't committed
+func recoverKeystoreRotation(moduleDir string) error {
+	err := finishKeystoreRotation(moduleDir)
+	if err != nil {
+		return err
+	}
+
+	// Anything still staged belongs to a rotation that never committed
+	tempJournalPath := filepath.Join(moduleDir, swconfig.KeystoreRotationFile) + rotationStagingSuffix
+	err = os.Remove(tempJournalPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing uncommitted keystore rotation journal [%s]: %w", tempJournalPath, err)
+	}
+	validatorPath := filepath.Join(moduleDir, config.ValidatorsDirectory)
+	err = filepath.WalkDir(validatorPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), rotationStagingSuffix) {
+			return nil
+		}
+		err = os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("error removing staged keystore file [%s]: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error removing staged keystore files: %w", err)
+	}
+	return nil
+}
+
+// Swap in the staged files listed in the rotation journal if there is one, then remove it
+func finishKeystoreRotation(moduleDir string) error {
+	journalPath := filepath.Join(moduleDir, swconfig.KeystoreRotationFile)
+	bytes, err := os.ReadFile(journalPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading keystore rotation journal [%s]: %w", journalPath, err)
+	}
+	var journal keystoreRotationJournal
+	err = json.Unmarshal(bytes, &journal)
+	if err != nil {
+		return fmt.Errorf("error deserializing keystore rotation journal [%s]: %w", journalPath, err)
+	}
+
+	// Files that were already swapped in won't have a staged copy anymore
+	for _, file := range journal.Files {
+		err = os.Rename(file.Staged, file.Target)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error replacing [%s] with re-encrypted file: %w", file.Target, err)
+		}
+	}
+	err = os.Remove(journalPath)
+	if err != nil {
+		return fmt.Errorf("error removing keystore rotation journal [%s]: %w", journalPath, err)
+	}
+	return nil
+}
+
+// Stage the keystore and password of each key a VC has, with a new random password per key like the VC keystore managers use.
+// Returns the number of keystores staged.
+func (r *keystoreRotation) stageVcKeystores(layout vcKeystoreLayout, pubkeys []beacon.ValidatorPubkey) (int, error) {
+	count := 0
+	for _, pubkey := range pubkeys {
+		keystorePath := layout.keystorePath(pubkey)
+		passwordPath := layout.passwordPath(pubkey)
+		_, err := os.Stat(keystorePath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		oldPassword, err := os.ReadFile(passwordPath)
+		if err != nil {
+			return 0, fmt.Errorf("error reading password for key %s: %w", pubkey.HexWithPrefix(), err)
+		}
+		newPassword, err := utils.GenerateRandomPassword()
+		if err != nil {
+			return 0, fmt.Errorf("error generating new keystore password: %w", err)
+		}
+		err = r.stageKeystore(layout.encryptor, keystorePath, string(oldPassword), newPassword)
+		if err != nil {
+			return 0, err
+		}
+		err = r.stageFile(passwordPath, []byte(newPassword))
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Stage Prysm's account store, which holds every key in one keystore, and its password.
+// Returns the number of keystores staged.
+func (r *keystoreRotation) stagePrysmKeystore(validatorPath string) (int, error) {
+	keystorePath, passwordPath := getPrysmKeystorePaths(validatorPath)
+	_, err := os.Stat(keystorePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	oldPassword, err := os.ReadFile(passwordPath)
+	if err != nil {
+		return 0, fmt.Errorf("error reading account store password: %w", err)
+	}
+	newPassword, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return 0, fmt.Errorf("error generating new keystore password: %w", err)
+	}
+	err = r.stageKeystore(eth2ks.New(), keystorePath, string(oldPassword), newPassword)
+	if err != nil {
+		return 0, err
+	}
+	err = r.stageFile(passwordPath, []byte(newPassword))
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// Re-encrypt a keystore with a new password and stage it, making sure the staged copy decrypts to the same secret
+func (r *keystoreRotation) stageKeystore(encryptor *eth2ks.Encryptor, keystorePath string, oldPassword string, newPassword string) error {
+	// Decrypt the keystore
+	keystoreBytes, err := os.ReadFile(keystorePath)
+	if err != nil {
+		return fmt.Errorf("error reading keystore [%s]: %w", keystorePath, err)
+	}
+	secret, keystore, err := decryptKeystore(keystoreBytes, oldPassword)
+	if err != nil {
+		return fmt.Errorf("error decrypting keystore [%s]: %w", keystorePath, err)
+	}
+
+	// Re-encrypt it, keeping everything else in the keystore the same
+	keystore["crypto"], err = encryptor.Encrypt(secret, newPassword)
+	if err != nil {
+		return fmt.Errorf("error re-encrypting keystore [%s]: %w", keystorePath, err)
+	}
+	keystoreBytes, err = json.Marshal(keystore)
+	if err != nil {
+		return fmt.Errorf("error serializing re-encrypted keystore [%s]: %w", keystorePath, err)
+	}
+	err = r.stageFile(keystorePath, keystoreBytes)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the staged copy can be decrypted with the new password
+	stagedPath := keystorePath + rotationStagingSuffix
+	stagedBytes, err := os.ReadFile(stagedPath)
+	if err != nil {
+		return fmt.Errorf("error reading re-encrypted keystore [%s]: %w", stagedPath, err)
+	}
+	stagedSecret, _, err := decryptKeystore(stagedBytes, newPassword)
+	if err != nil {
+		return fmt.Errorf("error verifying re-encrypted keystore [%s]: %w", stagedPath, err)
+	}
+	if !bytes.Equal(secret, stagedSecret) {
+		return fmt.Errorf("re-encrypted keystore [%s] doesn't match the original", stagedPath)
+	}
+	return nil
+}
+
+// Write the new contents of a file next to it, with the same permissions, to be swapped in when the rotation is committed
+func (r *keystoreRotation) stageFile(targetPath string, contents []byte) error {
+	mode := fileMode
+	info, err := os.Stat(targetPath)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+	stagedPath := targetPath + rotationStagingSuffix
+	r.files = append(r.files, keystoreRotationFile{
+		Target: targetPath,
+		Staged: stagedPath,
+	})
+	err = writeFileSynced(stagedPath, contents, mode)
+	if err != nil {
+		return fmt.Errorf("error staging [%s]: %w", targetPath, err)
+	}
+	return nil
+}
+
+// Commit the rotation by saving the journal of staged files, so the swap will be finished even if it's interrupted
+func (r *keystoreRotation) commit() error {
+	bytes, err := json.Marshal(keystoreRotationJournal{
+		Files: r.files,
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing keystore rotation journal: %w", err)
+	}
+
+	// Write it to a temporary file first so a partial journal is never mistaken for a committed one
+	journalPath := filepath.Join(r.moduleDir, swconfig.KeystoreRotationFile)
+	tempPath := journalPath + rotationStagingSuffix
+	err = writeFileSynced(tempPath, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving keystore rotation journal: %w", err)
+	}
+	err = os.Rename(tempPath, journalPath)
+	if err != nil {
+		return fmt.Errorf("error committing keystore rotation journal: %w", err)
+	}
+	return nil
+}
+
+// Remove the staged files after a failure, returning the error that caused it
+func (r *keystoreRotation) discard(cause error) error {
+	errs := []error{cause}
+	for _, file := range r.files {
+		err := os.Remove(file.Staged)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("error removing staged file [%s]: %w", file.Staged, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Get the layouts of the VCs that keep a keystore and password per key
+func getVcKeystoreLayouts(validatorPath string) []vcKeystoreLayout {
+	perKeyFolder := func(client string, keyFileName string) vcKeystoreLayout {
+		dir := filepath.Join(validatorPath, client)
+		encryptor := eth2ks.New(eth2ks.WithCipher("scrypt"))
+		if client == "nimbus" {
+			encryptor = eth2ks.New()
+		}
+		return vcKeystoreLayout{
+			name:      client,
+			encryptor: encryptor,
+			keystorePath: func(pubkey beacon.ValidatorPubkey) string {
+				return filepath.Join(dir, "validators", pubkey.HexWithPrefix(), keyFileName)
+			},
+			passwordPath: func(pubkey beacon.ValidatorPubkey) string {
+				return filepath.Join(dir, "secrets", pubkey.HexWithPrefix())
+			},
+			keysDir: filepath.Join(dir, "validators"),
+			getEntryPubkey: func(entry fs.DirEntry) (beacon.ValidatorPubkey, bool) {
+				if !entry.IsDir() {
+					return beacon.ValidatorPubkey{}, false
+				}
+				pubkey, err := beacon.HexToValidatorPubkey(entry.Name())
+				return pubkey, err == nil
+			},
+		}
+	}
+
+	tekuDir := filepath.Join(validatorPath, "teku")
+	return []vcKeystoreLayout{
+		perKeyFolder("lighthouse", "voting-keystore.json"),
+		perKeyFolder("lodestar", "voting-keystore.json"),
+		perKeyFolder("nimbus", "keystore.json"),
+		{
+			name:      "teku",
+			encryptor: eth2ks.New(eth2ks.WithCipher("scrypt")),
+			keystorePath: func(pubkey beacon.ValidatorPubkey) string {
+				return filepath.Join(tekuDir, "keys", pubkey.HexWithPrefix()+".json")
+			},
+			passwordPath: func(pubkey beacon.ValidatorPubkey) string {
+				return filepath.Join(tekuDir, "passwords", pubkey.HexWithPrefix()+".txt")
+			},
+			keysDir: filepath.Join(tekuDir, "keys"),
+			getEntryPubkey: func(entry fs.DirEntry) (beacon.ValidatorPubkey, bool) {
+				name := entry.Name()
+				if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+					return beacon.ValidatorPubkey{}, false
+				}
+				pubkey, err := beacon.HexToValidatorPubkey(strings.TrimSuffix(name, ".json"))
+				return pubkey, err == nil
+			},
+		},
+	}
+}
+
+// Get the paths of Prysm's account store and its password
+func getPrysmKeystorePaths(validatorPath string) (string, string) {
+	accountsDir := filepath.Join(validatorPath, "prysm-non-hd", "direct", "accounts")
+	return filepath.Join(accountsDir, "all-accounts.keystore.json"), filepath.Join(accountsDir, "secret")
+}
+
+// Decrypt a keystore, returning its secret and its deserialized contents
+func decryptKeystore(keystoreBytes []byte, password string) ([]byte, map[string]any, error) {
+	var keystore map[string]any
+	err := json.Unmarshal(keystoreBytes, &keystore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error deserializing keystore: %w", err)
+	}
+	crypto, ok := keystore["crypto"].(map[string]any)
+	if !ok {
+		return nil, nil, fmt.Errorf("keystore doesn't have a crypto section")
+	}
+	secret, err := eth2ks.New().Decrypt(crypto, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret, keystore, nil
+}
+
+// Write a file and flush it to disk before returning
+func writeFileSynced(path string, contents []byte, mode fs.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(contents)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/goccy/go-json"
 	"github.com/google/uuid"
@@ -24,9 +25,10 @@ const (
 
 // Keystore manager for the Stakewise operator
 type stakewiseKeystoreManager struct {
-	encryptor   *eth2ks.Encryptor
-	keystoreDir string
-	password    string
+	encryptor    *eth2ks.Encryptor
+	keystoreDir  string
+	passwordPath string
+	password     string
 }
 
 // Create new Stakewise keystore manager
@@ -54,9 +56,10 @@ func newStakewiseKeystoreManager(moduleDir string) (*stakewiseKeystoreManager, e
 	}
 
 	return &stakewiseKeystoreManager{
-		encryptor:   eth2ks.New(eth2ks.WithCipher("scrypt")),
-		keystoreDir: keystoreDir,
-		password:    password,
+		encryptor:    eth2ks.New(eth2ks.WithCipher("scrypt")),
+		keystoreDir:  keystoreDir,
+		passwordPath: passwordPath,
+		password:     password,
 	}, nil
 }
 
@@ -65,6 +68,37 @@ func (ks *stakewiseKeystoreManager) GetKeystoreDir() string {
 	return ks.keystoreDir
 }
 
+// Get the pubkeys of every keystore in the keystore directory
+func (ks *stakewiseKeystoreManager) GetStoredPubkeys() ([]beacon.ValidatorPubkey, error) {
+	files, err := os.ReadDir(ks.keystoreDir)
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating Stakewise keystore folder [%s]: %w", ks.keystoreDir, err)
+	}
+
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, file := range files {
+		filename := file.Name()
+		if !strings.HasPrefix(filename, keystorePrefix) || !strings.HasSuffix(filename, keystoreSuffix) {
+			continue
+		}
+
+		// Get the pubkey from the filename
+		trimmed := strings.TrimPrefix(filename, keystorePrefix)
+		trimmed = strings.TrimSuffix(trimmed, keystoreSuffix)
+		pubkey, err := beacon.HexToValidatorPubkey(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("error getting pubkey for keystore file [%s]: %w", filename, err)
+		}
+		pubkeys = append(pubkeys, pubkey)
+	}
+	return pubkeys, nil
+}
+
+// Get the path of the keystore for a pubkey
+func (ks *stakewiseKeystoreManager) getKeystorePath(pubkey beacon.ValidatorPubkey) string {
+	return filepath.Join(ks.keystoreDir, keystorePrefix+pubkey.HexWithPrefix()+keystoreSuffix)
+}
+
 // Store a validator key
 func (ks *stakewiseKeystoreManager) StoreValidatorKey(key *eth2types.BLSPrivateKey, derivationPath string) error {
 	// Get validator pubkey
@@ -92,7 +126,7 @@ func (ks *stakewiseKeystoreManager) StoreValidatorKey(key *eth2types.BLSPrivateK
 	}
 
 	// Get key file path
-	keyFilePath := filepath.Join(ks.keystoreDir, keystorePrefix+pubkey.HexWithPrefix()+keystoreSuffix)
+	keyFilePath := ks.getKeystorePath(pubkey)
 
 	// Write key store to disk
 	if err := os.WriteFile(keyFilePath, keyStoreBytes, fileMode); err != nil {
@@ -106,7 +140,7 @@ func (ks *stakewiseKeystoreManager) StoreValidatorKey(key *eth2types.BLSPrivateK
 // Load a private key
 func (ks *stakewiseKeystoreManager) LoadValidatorKey(pubkey beacon.ValidatorPubkey) (*eth2types.BLSPrivateKey, error) {
 	// Get key file path
-	keyFilePath := filepath.Join(ks.keystoreDir, keystorePrefix+pubkey.HexWithPrefix()+keystoreSuffix)
+	keyFilePath := ks.getKeystorePath(pubkey)
 
 	// Read the key file
 	_, err := os.Stat(keyFilePath)
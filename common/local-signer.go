@@ -0,0 +1,117 @@
+package swcommon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// LocalSigner signs with validator keys stored as keystores in the module's data folder.
+// Keys are saved to the StakeWise keystore folder and the VC keystore folders when they're imported.
+// Loaded keys are kept in memory so they don't have to be decrypted for each signature.
+type LocalSigner struct {
+	sp   IStakeWiseServiceProvider
+	lock *sync.Mutex
+	keys map[beacon.ValidatorPubkey]*eth2types.BLSPrivateKey
+}
+
+// Creates a new local signer
+func NewLocalSigner(sp IStakeWiseServiceProvider) *LocalSigner {
+	return &LocalSigner{
+		sp:   sp,
+		lock: &sync.Mutex{},
+		keys: map[beacon.ValidatorPubkey]*eth2types.BLSPrivateKey{},
+	}
+}
+
+// The name of the backend, for logging
+func (s *LocalSigner) GetName() string {
+	return "local"
+}
+
+// Save a new validator key to the StakeWise and VC keystore folders
+func (s *LocalSigner) ImportKey(ctx context.Context, key *eth2types.BLSPrivateKey, derivationPath string) error {
+	wallet := s.sp.GetWallet()
+	wallet.keystoreLock.Lock()
+	defer wallet.keystoreLock.Unlock()
+	wallet.keystoreGeneration++
+
+	err := wallet.validatorManager.StoreKey(key, derivationPath)
+	if err != nil {
+		return fmt.Errorf("error saving validator key: %w", err)
+	}
+	err = wallet.stakewiseKeystoreManager.StoreValidatorKey(key, derivationPath)
+	if err != nil {
+		return fmt.Errorf("error saving validator key to the StakeWise store: %w", err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.keys[beacon.ValidatorPubkey(key.PublicKey().Marshal())] = key
+	return nil
+}
+
+// Get the pubkeys of every keystore in the StakeWise keystore folder
+func (s *LocalSigner) GetPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	return s.sp.GetWallet().GetStoredPubkeys()
+}
+
+// Decrypt the keystores for the provided keys and keep them in memory, releasing any other keys that were loaded.
+// Keys that don't have a keystore or can't be decrypted are left out.
+func (s *LocalSigner) LoadKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey) (map[beacon.ValidatorPubkey]bool, error) {
+	wallet := s.sp.GetWallet()
+	keys := make(map[beacon.ValidatorPubkey]*eth2types.BLSPrivateKey, len(pubkeys))
+	loaded := make(map[beacon.ValidatorPubkey]bool, len(pubkeys))
+	errs := []string{}
+	for _, pubkey := range pubkeys {
+		key, err := wallet.GetPrivateKeyForPubkey(pubkey)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if key == nil {
+			continue
+		}
+		keys[pubkey] = key
+		loaded[pubkey] = true
+	}
+
+	s.lock.Lock()
+	s.keys = keys
+	s.lock.Unlock()
+	if len(errs) > 0 {
+		return loaded, fmt.Errorf("error loading validator keys:\n%s", strings.Join(errs, "\n"))
+	}
+	return loaded, nil
+}
+
+// Remove the provided keys from memory
+func (s *LocalSigner) UnloadKeys(pubkeys []beacon.ValidatorPubkey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, pubkey := range pubkeys {
+		delete(s.keys, pubkey)
+	}
+}
+
+// Sign a message with the key for the provided pubkey, decrypting its keystore if it isn't loaded
+func (s *LocalSigner) Sign(ctx context.Context, pubkey beacon.ValidatorPubkey, request SigningRequest) (beacon.ValidatorSignature, error) {
+	s.lock.Lock()
+	key, exists := s.keys[pubkey]
+	s.lock.Unlock()
+	if !exists {
+		var err error
+		key, err = s.sp.GetWallet().GetPrivateKeyForPubkey(pubkey)
+		if err != nil {
+			return beacon.ValidatorSignature{}, err
+		}
+		if key == nil {
+			return beacon.ValidatorSignature{}, fmt.Errorf("no keystore found for validator %s", pubkey.HexWithPrefix())
+		}
+	}
+	return beacon.ValidatorSignature(key.Sign(request.SigningRoot[:]).Marshal()), nil
+}
@@ -0,0 +1,176 @@
+package swcommon
+
+import (
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/rocket-pool/node-manager-core/eth"
+)
+
+const (
+	// The namespace for all of the daemon's metrics
+	metricsNamespace string = "stakewise"
+)
+
+// MetricsManager holds the Prometheus metrics the daemon exposes on its metrics endpoint
+type MetricsManager struct {
+	registry *prometheus.Registry
+
+	// Relay metrics
+	relayRequests        *prometheus.CounterVec
+	relayRequestDuration *prometheus.HistogramVec
+	signatureFailures    *prometheus.CounterVec
+
+	// Available key metrics
+	availableKeys        prometheus.Gauge
+	ineligibleKeys       *prometheus.GaugeVec
+	lastScannedBlock     prometheus.Gauge
+	lookbackScanDuration prometheus.Histogram
+
+	// Vault metrics
+	vaultRegisteredValidators *prometheus.GaugeVec
+	vaultMaxValidators        *prometheus.GaugeVec
+	vaultBalance              *prometheus.GaugeVec
+}
+
+// Creates a new metrics manager
+func NewMetricsManager() *MetricsManager {
+	m := &MetricsManager{
+		registry: prometheus.NewRegistry(),
+
+		relayRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "relay",
+			Name:      "requests_total",
+			Help:      "The number of requests the relay has handled, by path and response status code",
+		}, []string{"path", "code"}),
+		relayRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "relay",
+			Name:      "request_duration_seconds",
+			Help:      "How long the relay took to handle requests, by path and response status code",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"path", "code"}),
+		signatureFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "relay",
+			Name:      "nodeset_signature_failures_total",
+			Help:      "The number of times the relay failed to get a validators manager signature from NodeSet, by reason",
+		}, []string{"reason"}),
+
+		availableKeys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "keys",
+			Name:      "available",
+			Help:      "The number of keys that were eligible for new deposits during the last scan",
+		}),
+		ineligibleKeys: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "keys",
+			Name:      "ineligible",
+			Help:      "The number of keys that were ineligible for new deposits during the last scan, by reason",
+		}, []string{"reason"}),
+		lastScannedBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "keys",
+			Name:      "last_scanned_block",
+			Help:      "The last block that was scanned for deposit events",
+		}),
+		lookbackScanDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "keys",
+			Name:      "lookback_scan_duration_seconds",
+			Help:      "How long lookback scans for deposit events took",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600},
+		}),
+
+		vaultRegisteredValidators: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "vault",
+			Name:      "registered_validators",
+			Help:      "The number of validators the node has registered with NodeSet for each vault",
+		}, []string{"vault", "name"}),
+		vaultMaxValidators: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "vault",
+			Name:      "max_validators",
+			Help:      "The maximum number of validators NodeSet will let the node register for each vault",
+		}, []string{"vault", "name"}),
+		vaultBalance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "vault",
+			Name:      "balance_eth",
+			Help:      "The withdrawable assets of each vault, in ETH",
+		}, []string{"vault", "name"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.relayRequests,
+		m.relayRequestDuration,
+		m.signatureFailures,
+		m.availableKeys,
+		m.ineligibleKeys,
+		m.lastScannedBlock,
+		m.lookbackScanDuration,
+		m.vaultRegisteredValidators,
+		m.vaultMaxValidators,
+		m.vaultBalance,
+	)
+	return m
+}
+
+// Get the registry that holds all of the metrics
+func (m *MetricsManager) GetRegistry() *prometheus.Registry {
+	return m.registry
+}
+
+// Record a request handled by the relay
+func (m *MetricsManager) RecordRelayRequest(path string, code int, duration time.Duration) {
+	codeString := strconv.Itoa(code)
+	m.relayRequests.WithLabelValues(path, codeString).Inc()
+	m.relayRequestDuration.WithLabelValues(path, codeString).Observe(duration.Seconds())
+}
+
+// Record a failure to get a validators manager signature from NodeSet
+func (m *MetricsManager) RecordSignatureFailure(reason string) {
+	m.signatureFailures.WithLabelValues(reason).Inc()
+}
+
+// Record the results of a scan for available keys
+func (m *MetricsManager) RecordKeyScan(eligibleCount int, ineligibleKeys map[*AvailableKey]IneligibleReason, lastScannedBlock uint64) {
+	m.availableKeys.Set(float64(eligibleCount))
+
+	// Reset every reason so ones that no longer apply drop to zero
+	counts := map[IneligibleReason]int{}
+	for reason := IneligibleReason_NoPrivateKey; reason <= IneligibleReason_ReservedForOtherVault; reason++ {
+		counts[reason] = 0
+	}
+	for _, reason := range ineligibleKeys {
+		counts[reason]++
+	}
+	for reason, count := range counts {
+		m.ineligibleKeys.WithLabelValues(reason.String()).Set(float64(count))
+	}
+	m.lastScannedBlock.Set(float64(lastScannedBlock))
+}
+
+// Record how long a lookback scan took
+func (m *MetricsManager) RecordLookbackScan(duration time.Duration) {
+	m.lookbackScanDuration.Observe(duration.Seconds())
+}
+
+// Record the validator counts and balance of a vault
+func (m *MetricsManager) RecordVault(address common.Address, name string, registeredValidators int, maxValidators int, balance *big.Int) {
+	addressString := address.Hex()
+	m.vaultRegisteredValidators.WithLabelValues(addressString, name).Set(float64(registeredValidators))
+	m.vaultMaxValidators.WithLabelValues(addressString, name).Set(float64(maxValidators))
+	if balance != nil {
+		m.vaultBalance.WithLabelValues(addressString, name).Set(eth.WeiToEth(balance))
+	}
+}
@@ -0,0 +1,79 @@
+package swcommon
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	batch "github.com/rocket-pool/batch-query"
+)
+
+// Get the node's validator counts from NodeSet and the balance of each vault on the deployment.
+// If NodeSet says the node isn't registered or doesn't have permission to see the vaults, the corresponding flag is set in the data and the vaults are left empty.
+func GetNetworkStatus(sp IStakeWiseServiceProvider, data *swapi.NetworkStatusData) error {
+	client := sp.GetHyperdriveClient()
+	res := sp.GetResources()
+	ec := sp.GetEthClient()
+	qMgr := sp.GetQueryManager()
+	txMgr := sp.GetTransactionManager()
+
+	// Get the list of vaults for this deployment
+	response, err := client.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return fmt.Errorf("failed to get vaults: %w", err)
+	}
+	if response.Data.NotRegistered {
+		data.NotRegisteredWithNodeSet = true
+		return nil
+	}
+	if response.Data.InvalidPermissions {
+		data.InvalidPermissions = true
+		return nil
+	}
+	if response.Data.Vaults == nil {
+		data.Vaults = []*swapi.NetworkVaultInfo{}
+		return nil
+	}
+
+	// For each vault, get the validator keys
+	vaultContracts := make(map[common.Address]*swcontracts.IEthVault)
+	for _, vault := range response.Data.Vaults {
+		vaultInfo := &swapi.NetworkVaultInfo{
+			Name:    vault.Name,
+			Address: vault.Address,
+		}
+		metaResponse, err := client.NodeSet_StakeWise.GetValidatorsInfo(res.DeploymentName, vault.Address)
+		if err != nil {
+			return fmt.Errorf("error getting vault [%s] info: %w", vault.Address.Hex(), err)
+		}
+		if metaResponse.Data.NotRegistered {
+			data.NotRegisteredWithNodeSet = true
+			return nil
+		}
+		vaultInfo.MaxValidators = metaResponse.Data.MaxValidators
+		vaultInfo.RegisteredValidators = metaResponse.Data.RegisteredValidators
+		vaultInfo.AvailableValidators = metaResponse.Data.AvailableValidators
+
+		// Create the vault contract
+		vaultContract, err := swcontracts.NewIEthVault(vault.Address, ec, txMgr)
+		if err != nil {
+			return fmt.Errorf("error creating binding for vault [%s]: %w", vault.Address.Hex(), err)
+		}
+		vaultContracts[vault.Address] = vaultContract
+		data.Vaults = append(data.Vaults, vaultInfo)
+	}
+
+	// Get the balances
+	err = qMgr.Query(func(mc *batch.MultiCaller) error {
+		for _, vault := range data.Vaults {
+			contract := vaultContracts[vault.Address]
+			contract.WithdrawableAssets(mc, &vault.Balance)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("error getting vault balances: %w", err)
+	}
+	return nil
+}
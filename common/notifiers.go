@@ -0,0 +1,155 @@
+package swcommon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// Delivers alerts by POSTing them as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// Creates a new webhook notifier
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{},
+	}
+}
+
+func (n *WebhookNotifier) GetName() string {
+	return "webhook"
+}
+
+// POST the alert to the webhook
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("error serializing alert: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("webhook returned status %d: %s", response.StatusCode, string(responseBody))
+	}
+	return nil
+}
+
+// Delivers alerts by email through an SMTP server
+type SmtpNotifier struct {
+	server     string
+	username   string
+	password   string
+	from       string
+	recipients []string
+}
+
+// Creates a new SMTP notifier
+func NewSmtpNotifier(server string, username string, password string, from string, recipients []string) *SmtpNotifier {
+	return &SmtpNotifier{
+		server:     server,
+		username:   username,
+		password:   password,
+		from:       from,
+		recipients: recipients,
+	}
+}
+
+func (n *SmtpNotifier) GetName() string {
+	return "smtp"
+}
+
+// Email the alert to the recipients
+func (n *SmtpNotifier) Notify(ctx context.Context, alert Alert) error {
+	var auth smtp.Auth
+	if n.username != "" {
+		host, _, err := net.SplitHostPort(n.server)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP server [%s]: %w", n.server, err)
+		}
+		auth = smtp.PlainAuth("", n.username, n.password, host)
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", n.from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(n.recipients, ", "))
+	fmt.Fprintf(&message, "Subject: [StakeWise %s] %s\r\n", alert.Severity, alert.Title)
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&message, "%s\r\n\r\nType: %s\r\nSubject: %s\r\nTime: %s\r\n", alert.Message, alert.Type, alert.Subject, alert.Time.Format("2006-01-02 15:04:05 MST"))
+
+	// net/smtp doesn't take a context, so give up waiting on it if the context ends first
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- smtp.SendMail(n.server, auth, n.from, n.recipients, []byte(message.String()))
+	}()
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return fmt.Errorf("error sending alert email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error sending alert email: %w", ctx.Err())
+	}
+}
+
+// Delivers alerts by appending them to a JSON lines file
+type FileNotifier struct {
+	path string
+	lock *sync.Mutex
+}
+
+// Creates a new file notifier
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{
+		path: path,
+		lock: &sync.Mutex{},
+	}
+}
+
+func (n *FileNotifier) GetName() string {
+	return "file"
+}
+
+// Append the alert to the file
+func (n *FileNotifier) Notify(ctx context.Context, alert Alert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("error serializing alert: %w", err)
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("error opening alert file [%s]: %w", n.path, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("error writing alert to [%s]: %w", n.path, err)
+	}
+	return nil
+}
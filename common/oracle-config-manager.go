@@ -0,0 +1,344 @@
+package swcommon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	batch "github.com/rocket-pool/batch-query"
+)
+
+const (
+	// The max number of oracle config changes to keep in the history
+	maxOracleConfigChanges int = 256
+)
+
+// OracleConfigManager follows the StakeWise Keeper's config updates and oracle set changes, saving the latest state to disk
+type OracleConfigManager struct {
+	dataPath string
+	sp       IStakeWiseServiceProvider
+
+	// Serializes syncs so only one can scan the Keeper at a time
+	syncLock *sync.Mutex
+
+	// Protects the data
+	lock *sync.Mutex
+
+	data *oracleConfigManagerData
+}
+
+type oracleConfigManagerData struct {
+	// The next block to scan for Keeper events
+	NextBlock uint64 `json:"nextBlock"`
+
+	// The config as of the last scanned block
+	Config swapi.OracleConfig `json:"config"`
+
+	// The changes seen so far, oldest first
+	Changes []swapi.OracleConfigChange `json:"changes"`
+}
+
+// Creates a new manager
+func NewOracleConfigManager(sp IStakeWiseServiceProvider) (*OracleConfigManager, error) {
+	mgr := &OracleConfigManager{
+		dataPath: filepath.Join(sp.GetModuleDir(), swconfig.OracleManagerFile),
+		sp:       sp,
+		syncLock: &sync.Mutex{},
+		lock:     &sync.Mutex{},
+	}
+	err := mgr.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading oracle config: %w", err)
+	}
+	return mgr, nil
+}
+
+// Reload the oracle config from disk
+func (m *OracleConfigManager) Reload() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	res := m.sp.GetResources()
+	data := m.getInitialData()
+	_, err := os.Stat(m.dataPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - start a new scan
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error checking status of oracle config file [%s]: %w", m.dataPath, err)
+		}
+	} else {
+		// Read the file
+		bytes, err := os.ReadFile(m.dataPath)
+		if err != nil {
+			return fmt.Errorf("error reading oracle config file [%s]: %w", m.dataPath, err)
+		}
+
+		// Deserialize it
+		err = json.Unmarshal(bytes, data)
+		if err != nil {
+			return fmt.Errorf("error deserializing oracle config file [%s]: %w", m.dataPath, err)
+		}
+
+		// Start over if the file is for a different Keeper
+		if data.Config.Keeper != res.Keeper {
+			data = m.getInitialData()
+		}
+	}
+	m.data = data
+	return nil
+}
+
+// Check if the Keeper has been scanned at least once
+func (m *OracleConfigManager) IsScanned() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.data.Config.ScannedBlock > 0
+}
+
+// Get the oracle config as of the last scanned block, along with the changes seen so far
+func (m *OracleConfigManager) GetOracleConfig() (swapi.OracleConfig, []swapi.OracleConfigChange) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	config := m.data.Config
+	config.Oracles = make([]common.Address, len(m.data.Config.Oracles))
+	copy(config.Oracles, m.data.Config.Oracles)
+	changes := make([]swapi.OracleConfigChange, len(m.data.Changes))
+	copy(changes, m.data.Changes)
+	return config, changes
+}
+
+// Scan the Keeper for config updates and oracle set changes up to the last settled block before the provided one.
+// Progress is saved after each interval, so an interrupted sync picks up where it left off.
+// Returns the changes that were found during this sync.
+func (m *OracleConfigManager) Sync(ctx context.Context, logger *slog.Logger, currentBlock uint64) ([]swapi.OracleConfigChange, error) {
+	m.syncLock.Lock()
+	defer m.syncLock.Unlock()
+
+	res := m.sp.GetResources()
+	if res.Keeper == (common.Address{}) {
+		return nil, fmt.Errorf("the StakeWise Keeper address is not set for this network")
+	}
+
+	// Only scan blocks that can't be reorged out anymore
+	settledHeader, err := getSettledHeader(ctx, m.sp, currentBlock)
+	if err != nil {
+		return nil, fmt.Errorf("error getting settled block: %w", err)
+	}
+	if settledHeader == nil {
+		return nil, nil
+	}
+	settledBlock := settledHeader.Number.Uint64()
+
+	keeper, err := swcontracts.NewIKeeper(res.Keeper, m.sp.GetEthClient(), m.sp.GetTransactionManager())
+	if err != nil {
+		return nil, fmt.Errorf("error creating Keeper binding: %w", err)
+	}
+
+	// Apply the events in each interval
+	newChanges := []swapi.OracleConfigChange{}
+	m.lock.Lock()
+	startBlock := m.data.NextBlock
+	m.lock.Unlock()
+	if startBlock <= settledBlock {
+		logger.Debug("Scanning Keeper for config updates", "start", startBlock, "target", settledBlock)
+	}
+	for startBlock <= settledBlock {
+		endBlock := startBlock + IntervalSize - 1
+		if endBlock > settledBlock {
+			endBlock = settledBlock
+		}
+		changes, err := m.getEventChanges(ctx, keeper, startBlock, endBlock)
+		if err != nil {
+			return nil, err
+		}
+		err = m.applyChanges(changes, endBlock)
+		if err != nil {
+			return nil, err
+		}
+		newChanges = append(newChanges, changes...)
+		startBlock = endBlock + 1
+	}
+
+	// Get the thresholds as of the settled block
+	var totalOracles *big.Int
+	var rewardsMinOracles *big.Int
+	var validatorsMinOracles *big.Int
+	err = m.sp.GetQueryManager().Query(func(mc *batch.MultiCaller) error {
+		keeper.TotalOracles(mc, &totalOracles)
+		keeper.RewardsMinOracles(mc, &rewardsMinOracles)
+		keeper.ValidatorsMinOracles(mc, &validatorsMinOracles)
+		return nil
+	}, &bind.CallOpts{
+		BlockNumber: settledHeader.Number,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting Keeper oracle thresholds: %w", err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	config := &m.data.Config
+	config.TotalOracles = totalOracles.Uint64()
+	if config.RewardsMinOracles != rewardsMinOracles.Uint64() || config.ValidatorsMinOracles != validatorsMinOracles.Uint64() {
+		config.RewardsMinOracles = rewardsMinOracles.Uint64()
+		config.ValidatorsMinOracles = validatorsMinOracles.Uint64()
+		change := swapi.OracleConfigChange{
+			Type:                 swapi.OracleConfigChangeType_ThresholdsUpdated,
+			Time:                 time.Unix(int64(settledHeader.Time), 0).UTC(),
+			BlockNumber:          settledBlock,
+			RewardsMinOracles:    config.RewardsMinOracles,
+			ValidatorsMinOracles: config.ValidatorsMinOracles,
+		}
+		m.addChange(change)
+		newChanges = append(newChanges, change)
+	}
+	if uint64(len(config.Oracles)) != config.TotalOracles {
+		logger.Warn("Oracle set from Keeper events doesn't match the Keeper's oracle count", "oracles", len(config.Oracles), "totalOracles", config.TotalOracles)
+	}
+	err = m.saveData()
+	if err != nil {
+		return nil, err
+	}
+	return newChanges, nil
+}
+
+// Get the config changes from the Keeper's events between the start and end blocks, in the order they happened
+func (m *OracleConfigManager) getEventChanges(ctx context.Context, keeper *swcontracts.IKeeper, startBlock uint64, endBlock uint64) ([]swapi.OracleConfigChange, error) {
+	start := new(big.Int).SetUint64(startBlock)
+	end := new(big.Int).SetUint64(endBlock)
+	configEvents, err := keeper.ConfigUpdated(start, end, intervalSizeBig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Keeper config events for blocks %d to %d: %w", startBlock, endBlock, err)
+	}
+	oracleEvents, err := keeper.OracleUpdated(start, end, intervalSizeBig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Keeper oracle events for blocks %d to %d: %w", startBlock, endBlock, err)
+	}
+
+	changes := make([]swapi.OracleConfigChange, 0, len(configEvents)+len(oracleEvents))
+	for _, event := range configEvents {
+		changes = append(changes, swapi.OracleConfigChange{
+			Type:           swapi.OracleConfigChangeType_ConfigUpdated,
+			BlockNumber:    event.BlockNumber,
+			TxHash:         event.TxHash,
+			ConfigIpfsHash: event.ConfigIPFSHash,
+		})
+	}
+	for _, event := range oracleEvents {
+		changeType := swapi.OracleConfigChangeType_OracleRemoved
+		if event.Added {
+			changeType = swapi.OracleConfigChangeType_OracleAdded
+		}
+		oracle := event.Oracle
+		changes = append(changes, swapi.OracleConfigChange{
+			Type:        changeType,
+			BlockNumber: event.BlockNumber,
+			TxHash:      event.TxHash,
+			Oracle:      &oracle,
+		})
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].BlockNumber < changes[j].BlockNumber
+	})
+
+	// Use the block times for the change times
+	blockTimes := map[uint64]time.Time{}
+	ec := m.sp.GetEthClient()
+	for i, change := range changes {
+		blockTime, exists := blockTimes[change.BlockNumber]
+		if !exists {
+			header, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(change.BlockNumber))
+			if err != nil {
+				return nil, fmt.Errorf("error getting header for block %d: %w", change.BlockNumber, err)
+			}
+			blockTime = time.Unix(int64(header.Time), 0).UTC()
+			blockTimes[change.BlockNumber] = blockTime
+		}
+		changes[i].Time = blockTime
+	}
+	return changes, nil
+}
+
+// Apply a batch of changes to the config and save the new progress
+func (m *OracleConfigManager) applyChanges(changes []swapi.OracleConfigChange, endBlock uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	config := &m.data.Config
+	for _, change := range changes {
+		switch change.Type {
+		case swapi.OracleConfigChangeType_ConfigUpdated:
+			config.ConfigIpfsHash = change.ConfigIpfsHash
+			config.ConfigBlock = change.BlockNumber
+		case swapi.OracleConfigChangeType_OracleAdded:
+			config.Oracles = append(config.Oracles, *change.Oracle)
+		case swapi.OracleConfigChangeType_OracleRemoved:
+			for i, oracle := range config.Oracles {
+				if oracle == *change.Oracle {
+					config.Oracles = append(config.Oracles[:i], config.Oracles[i+1:]...)
+					break
+				}
+			}
+		}
+		m.addChange(change)
+	}
+	config.ScannedBlock = endBlock
+	m.data.NextBlock = endBlock + 1
+	return m.saveData()
+}
+
+// Add a change to the history, dropping the oldest ones if it's full
+func (m *OracleConfigManager) addChange(change swapi.OracleConfigChange) {
+	m.data.Changes = append(m.data.Changes, change)
+	if len(m.data.Changes) > maxOracleConfigChanges {
+		m.data.Changes = m.data.Changes[len(m.data.Changes)-maxOracleConfigChanges:]
+	}
+}
+
+// Get the data for a Keeper that hasn't been scanned yet
+func (m *OracleConfigManager) getInitialData() *oracleConfigManagerData {
+	res := m.sp.GetResources()
+	var genesisBlock uint64
+	if res.KeeperGenesisBlock != nil {
+		genesisBlock = res.KeeperGenesisBlock.Uint64()
+	}
+	return &oracleConfigManagerData{
+		NextBlock: genesisBlock,
+		Config: swapi.OracleConfig{
+			Keeper:  res.Keeper,
+			Oracles: []common.Address{},
+		},
+		Changes: []swapi.OracleConfigChange{},
+	}
+}
+
+// Save the oracle config to disk
+func (m *OracleConfigManager) saveData() error {
+	// Serialize the config
+	bytes, err := json.Marshal(m.data)
+	if err != nil {
+		return fmt.Errorf("error serializing oracle config data: %w", err)
+	}
+
+	// Write it
+	err = os.WriteFile(m.dataPath, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving oracle config data to disk: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package swcommon
+
+import (
+	"fmt"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// Get the pubkeys of every validator NodeSet has registered for this node across all of the deployment's vaults.
+// Returns nil if the node isn't registered with NodeSet.
+func GetRegisteredPubkeys(sp IStakeWiseServiceProvider) ([]beacon.ValidatorPubkey, error) {
+	hd := sp.GetHyperdriveClient()
+	res := sp.GetResources()
+
+	vaultsResponse, err := hd.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting vaults from NodeSet: %w", err)
+	}
+	if vaultsResponse.Data.NotRegistered || vaultsResponse.Data.InvalidPermissions {
+		return nil, nil
+	}
+
+	pubkeys := []beacon.ValidatorPubkey{}
+	for _, vault := range vaultsResponse.Data.Vaults {
+		validatorsResponse, err := hd.NodeSet_StakeWise.GetRegisteredValidators(res.DeploymentName, vault.Address)
+		if err != nil {
+			return nil, fmt.Errorf("error getting registered validators for vault [%s]: %w", vault.Address.Hex(), err)
+		}
+		if validatorsResponse.Data.NotRegistered || validatorsResponse.Data.InvalidPermissions {
+			continue
+		}
+		for _, validator := range validatorsResponse.Data.Validators {
+			pubkeys = append(pubkeys, validator.Pubkey)
+		}
+	}
+	return pubkeys, nil
+}
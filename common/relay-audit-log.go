@@ -0,0 +1,198 @@
+package swcommon
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+)
+
+const (
+	// The size the audit log can grow to before it's rotated
+	maxRelayAuditLogSize int64 = 32 * 1024 * 1024
+)
+
+// RelayAuditLog is an append-only record of every validators request the relay has handled.
+// Records are stored in a JSON lines file, one per line, oldest first.
+// The file is only read when records are requested, so it isn't held in memory.
+// Once the file reaches the max size, it's archived with the next number as a suffix (relay-audit.jsonl.1, relay-audit.jsonl.2, ...)
+// and a new one is started. Archives are never deleted, so no records are lost.
+type RelayAuditLog struct {
+	dataPath string
+	lock     *sync.Mutex
+}
+
+// Creates a new relay audit log
+func NewRelayAuditLog(sp IStakeWiseServiceProvider) *RelayAuditLog {
+	return &RelayAuditLog{
+		dataPath: filepath.Join(sp.GetModuleDir(), swconfig.RelayAuditFile),
+		lock:     &sync.Mutex{},
+	}
+}
+
+// Append a record to the log
+func (l *RelayAuditLog) AddRecord(logger *slog.Logger, record swapi.RelayAuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializing relay audit record: %w", err)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	err = l.rotateIfFull(logger)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.dataPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("error opening relay audit log [%s]: %w", l.dataPath, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("error saving relay audit record to disk: %w", err)
+	}
+	return nil
+}
+
+// Get the records in the log, oldest first, including the ones in archived files.
+// If start or end are provided, only records received at or after start and before end are returned.
+// If vault is provided, only records for that vault are returned.
+// If limit is more than 0, only the latest limit records that match are returned.
+func (l *RelayAuditLog) GetRecords(start *time.Time, end *time.Time, vault *common.Address, limit int) ([]swapi.RelayAuditRecord, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	archives, err := l.getArchives()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(archives)+1)
+	for _, archive := range archives {
+		paths = append(paths, l.getArchivePath(archive))
+	}
+	paths = append(paths, l.dataPath)
+
+	records := []swapi.RelayAuditRecord{}
+	for _, path := range paths {
+		records, err = l.readRecords(path, records, start, end, vault, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Archive the current file with the next number and start a new one if it has reached the max size
+func (l *RelayAuditLog) rotateIfFull(logger *slog.Logger) error {
+	info, err := os.Stat(l.dataPath)
+	if err != nil {
+		// If the file doesn't exist, there's nothing to rotate
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error checking relay audit log [%s]: %w", l.dataPath, err)
+	}
+	if info.Size() < maxRelayAuditLogSize {
+		return nil
+	}
+
+	archives, err := l.getArchives()
+	if err != nil {
+		return err
+	}
+	next := uint64(1)
+	if len(archives) > 0 {
+		next = archives[len(archives)-1] + 1
+	}
+	archivePath := l.getArchivePath(next)
+	err = os.Rename(l.dataPath, archivePath)
+	if err != nil {
+		return fmt.Errorf("error rotating relay audit log [%s]: %w", l.dataPath, err)
+	}
+	logger.Info("Rotated relay audit log", "archive", archivePath, "size", info.Size())
+	return nil
+}
+
+// Get the numbers of the archived log files, oldest first
+func (l *RelayAuditLog) getArchives() ([]uint64, error) {
+	matches, err := filepath.Glob(l.dataPath + ".*")
+	if err != nil {
+		return nil, fmt.Errorf("error finding relay audit log archives: %w", err)
+	}
+	archives := make([]uint64, 0, len(matches))
+	for _, match := range matches {
+		number, err := strconv.ParseUint(strings.TrimPrefix(match, l.dataPath+"."), 10, 64)
+		if err != nil {
+			// Not an archive
+			continue
+		}
+		archives = append(archives, number)
+	}
+	slices.Sort(archives)
+	return archives, nil
+}
+
+// Get the path of the archived log file with the provided number
+func (l *RelayAuditLog) getArchivePath(number uint64) string {
+	return l.dataPath + "." + strconv.FormatUint(number, 10)
+}
+
+// Read the matching records from a log file and append them to the provided ones, keeping only the latest limit records if a limit is set
+func (l *RelayAuditLog) readRecords(path string, records []swapi.RelayAuditRecord, start *time.Time, end *time.Time, vault *common.Address, limit int) ([]swapi.RelayAuditRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		// If the file doesn't exist, the relay hasn't handled any requests yet
+		if errors.Is(err, fs.ErrNotExist) {
+			return records, nil
+		}
+		return nil, fmt.Errorf("error opening relay audit log [%s]: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record swapi.RelayAuditRecord
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			// A partial line from an interrupted write, skip it
+			continue
+		}
+		if start != nil && record.Time.Before(*start) {
+			continue
+		}
+		if end != nil && !record.Time.Before(*end) {
+			continue
+		}
+		if vault != nil && record.Vault != *vault {
+			continue
+		}
+		records = append(records, record)
+		if limit > 0 && len(records) > limit {
+			records = records[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading relay audit log [%s]: %w", path, err)
+	}
+	return records, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
 	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
 	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
@@ -26,12 +27,22 @@ type IStakeWiseWalletProvider interface {
 	GetWallet() *Wallet
 }
 
+// Provides the signer that holds the validator keys
+type IValidatorSignerProvider interface {
+	GetValidatorSigner() IValidatorSigner
+}
+
 // Provides the deposit data manager
 type IDepositDataManagerProvider interface {
 	// Gets the deposit data manager
 	GetDepositDataManager() *DepositDataManager
 }
 
+// Provides the cache of signed deposit data for each available key and vault
+type IDepositDataCacheProvider interface {
+	GetDepositDataCache() *DepositDataCache
+}
+
 // Provides requirements for the StakeWise daemon
 type IStakeWiseRequirementsProvider interface {
 	RequireStakewiseWalletReady(ctx context.Context, status wallet.WalletStatus) error
@@ -49,13 +60,69 @@ type IAvailableKeyManagerProvider interface {
 	GetAvailableKeyManager() *AvailableKeyManager
 }
 
+// Provides the local index of Beacon deposit contract events
+type IDepositEventIndexProvider interface {
+	GetDepositEventIndex() *DepositEventIndex
+}
+
+// Provides the manager for the lifecycle history of validator keys
+type IKeyLifecycleManagerProvider interface {
+	GetKeyLifecycleManager() *KeyLifecycleManager
+}
+
+// Provides the manager that follows the StakeWise Keeper's oracle config
+type IOracleConfigManagerProvider interface {
+	GetOracleConfigManager() *OracleConfigManager
+}
+
+// Provides the store of validator balance and status snapshots
+type IValidatorSnapshotStoreProvider interface {
+	GetValidatorSnapshotStore() *ValidatorSnapshotStore
+}
+
+// Provides the history of vault exchange rates used for APR estimates
+type IVaultRateStoreProvider interface {
+	GetVaultRateStore() *VaultRateStore
+}
+
+// Provides the manager that delivers alerts to the configured notifiers
+type IAlertManagerProvider interface {
+	GetAlertManager() *AlertManager
+}
+
+// Provides the audit log of validators requests handled by the relay
+type IRelayAuditLogProvider interface {
+	GetRelayAuditLog() *RelayAuditLog
+}
+
+// Provides the manager that recovers validator keys in the background
+type IKeyRecoveryManagerProvider interface {
+	GetKeyRecoveryManager() *KeyRecoveryManager
+}
+
+// Provides the manager for the daemon's Prometheus metrics
+type IMetricsManagerProvider interface {
+	GetMetricsManager() *MetricsManager
+}
+
 type IStakeWiseServiceProvider interface {
 	IStakeWiseConfigProvider
 	IStakeWiseWalletProvider
+	IValidatorSignerProvider
 	IDepositDataManagerProvider
+	IDepositDataCacheProvider
 	IStakeWiseRequirementsProvider
 	IBeaconDepositContractProvider
 	IAvailableKeyManagerProvider
+	IDepositEventIndexProvider
+	IKeyLifecycleManagerProvider
+	IOracleConfigManagerProvider
+	IValidatorSnapshotStoreProvider
+	IVaultRateStoreProvider
+	IAlertManagerProvider
+	IRelayAuditLogProvider
+	IMetricsManagerProvider
+	IKeyRecoveryManagerProvider
 
 	services.IModuleServiceProvider
 }
@@ -64,10 +131,21 @@ type stakeWiseServiceProvider struct {
 	services.IModuleServiceProvider
 	swCfg              *swconfig.StakeWiseConfig
 	wallet             *Wallet
+	validatorSigner    IValidatorSigner
 	resources          *swconfig.MergedResources
 	depositDataManager *DepositDataManager
+	depositDataCache   *DepositDataCache
 	depositContract    *swcontracts.BeaconDepositContract
 	keyMgr             *AvailableKeyManager
+	depositEventIndex  *DepositEventIndex
+	lifecycleMgr       *KeyLifecycleManager
+	oracleConfigMgr    *OracleConfigManager
+	snapshotStore      *ValidatorSnapshotStore
+	vaultRateStore     *VaultRateStore
+	alertMgr           *AlertManager
+	relayAuditLog      *RelayAuditLog
+	metricsMgr         *MetricsManager
+	keyRecoveryMgr     *KeyRecoveryManager
 }
 
 // Create a new service provider with Stakewise daemon-specific features
@@ -94,6 +172,9 @@ func NewStakeWiseServiceProvider(sp services.IModuleServiceProvider, settingsLis
 	if selectedResources == nil {
 		return nil, fmt.Errorf("no stakewise resources found for selected network [%s]", hdCfg.Network.Value)
 	}
+	if selectedResources.SplitWarehouse == (common.Address{}) {
+		selectedResources.SplitWarehouse = swconfig.DefaultSplitWarehouse
+	}
 
 	return NewStakeWiseServiceProviderFromCustomServices(sp, swCfg, selectedResources)
 }
@@ -112,6 +193,7 @@ func NewStakeWiseServiceProviderFromCustomServices(sp services.IModuleServicePro
 		swCfg:                  cfg,
 		resources:              resources,
 		depositContract:        depositContract,
+		metricsMgr:             NewMetricsManager(),
 	}
 
 	// Create the wallet
@@ -121,6 +203,9 @@ func NewStakeWiseServiceProviderFromCustomServices(sp services.IModuleServicePro
 	}
 	stakewiseSp.wallet = wallet
 
+	// Create the signer
+	stakewiseSp.validatorSigner = NewValidatorSigner(stakewiseSp)
+
 	// Create the deposit data manager
 	ddMgr, err := NewDepositDataManager(stakewiseSp)
 	if err != nil {
@@ -128,12 +213,67 @@ func NewStakeWiseServiceProviderFromCustomServices(sp services.IModuleServicePro
 	}
 	stakewiseSp.depositDataManager = ddMgr
 
+	// Create the deposit event index
+	depositEventIndex, err := NewDepositEventIndex(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing deposit event index: %w", err)
+	}
+	stakewiseSp.depositEventIndex = depositEventIndex
+
+	// Create the key lifecycle manager
+	lifecycleMgr, err := NewKeyLifecycleManager(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing key lifecycle manager: %w", err)
+	}
+	stakewiseSp.lifecycleMgr = lifecycleMgr
+
 	// Create the available key manager
 	keyMgr, err := NewAvailableKeyManager(stakewiseSp)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing available key manager: %w", err)
 	}
 	stakewiseSp.keyMgr = keyMgr
+
+	// Create the deposit data cache
+	depositDataCache, err := NewDepositDataCache(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing deposit data cache: %w", err)
+	}
+	stakewiseSp.depositDataCache = depositDataCache
+
+	// Create the oracle config manager
+	oracleConfigMgr, err := NewOracleConfigManager(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing oracle config manager: %w", err)
+	}
+	stakewiseSp.oracleConfigMgr = oracleConfigMgr
+
+	// Create the validator snapshot store
+	snapshotStore, err := NewValidatorSnapshotStore(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing validator snapshot store: %w", err)
+	}
+	stakewiseSp.snapshotStore = snapshotStore
+
+	// Create the vault rate store
+	vaultRateStore, err := NewVaultRateStore(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing vault rate store: %w", err)
+	}
+	stakewiseSp.vaultRateStore = vaultRateStore
+
+	// Create the alert manager
+	stakewiseSp.alertMgr = NewAlertManager(stakewiseSp)
+
+	// Create the relay audit log
+	stakewiseSp.relayAuditLog = NewRelayAuditLog(stakewiseSp)
+
+	// Create the key recovery manager
+	keyRecoveryMgr, err := NewKeyRecoveryManager(stakewiseSp)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing key recovery manager: %w", err)
+	}
+	stakewiseSp.keyRecoveryMgr = keyRecoveryMgr
 	return stakewiseSp, nil
 }
 
@@ -149,10 +289,18 @@ func (s *stakeWiseServiceProvider) GetWallet() *Wallet {
 	return s.wallet
 }
 
+func (s *stakeWiseServiceProvider) GetValidatorSigner() IValidatorSigner {
+	return s.validatorSigner
+}
+
 func (s *stakeWiseServiceProvider) GetDepositDataManager() *DepositDataManager {
 	return s.depositDataManager
 }
 
+func (s *stakeWiseServiceProvider) GetDepositDataCache() *DepositDataCache {
+	return s.depositDataCache
+}
+
 func (s *stakeWiseServiceProvider) GetBeaconDepositContract() *swcontracts.BeaconDepositContract {
 	return s.depositContract
 }
@@ -160,3 +308,39 @@ func (s *stakeWiseServiceProvider) GetBeaconDepositContract() *swcontracts.Beaco
 func (s *stakeWiseServiceProvider) GetAvailableKeyManager() *AvailableKeyManager {
 	return s.keyMgr
 }
+
+func (s *stakeWiseServiceProvider) GetDepositEventIndex() *DepositEventIndex {
+	return s.depositEventIndex
+}
+
+func (s *stakeWiseServiceProvider) GetKeyLifecycleManager() *KeyLifecycleManager {
+	return s.lifecycleMgr
+}
+
+func (s *stakeWiseServiceProvider) GetOracleConfigManager() *OracleConfigManager {
+	return s.oracleConfigMgr
+}
+
+func (s *stakeWiseServiceProvider) GetValidatorSnapshotStore() *ValidatorSnapshotStore {
+	return s.snapshotStore
+}
+
+func (s *stakeWiseServiceProvider) GetVaultRateStore() *VaultRateStore {
+	return s.vaultRateStore
+}
+
+func (s *stakeWiseServiceProvider) GetAlertManager() *AlertManager {
+	return s.alertMgr
+}
+
+func (s *stakeWiseServiceProvider) GetRelayAuditLog() *RelayAuditLog {
+	return s.relayAuditLog
+}
+
+func (s *stakeWiseServiceProvider) GetMetricsManager() *MetricsManager {
+	return s.metricsMgr
+}
+
+func (s *stakeWiseServiceProvider) GetKeyRecoveryManager() *KeyRecoveryManager {
+	return s.keyRecoveryMgr
+}
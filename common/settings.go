@@ -5,4 +5,7 @@ import "os"
 const (
 	fileMode os.FileMode = 0600
 	dirMode  os.FileMode = 0700
+
+	// The amount of ETH the node wallet spends per validator deposit
+	ValidatorDepositCost float64 = 0.01
 )
@@ -0,0 +1,247 @@
+package swcommon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/beacon/ssz_types"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// The kind of message a signing request is for
+type SigningType string
+
+const (
+	SigningType_Deposit       SigningType = "DEPOSIT"
+	SigningType_VoluntaryExit SigningType = "VOLUNTARY_EXIT"
+)
+
+// The deposit being signed in a deposit signing request
+type DepositSigningData struct {
+	Pubkey                beacon.ValidatorPubkey
+	WithdrawalCredentials common.Hash
+	Amount                uint64
+	GenesisForkVersion    []byte
+}
+
+// The exit being signed in a voluntary exit signing request
+type VoluntaryExitSigningData struct {
+	Epoch          uint64
+	ValidatorIndex uint64
+}
+
+// The fork a voluntary exit is signed for
+type ForkInfo struct {
+	PreviousVersion       []byte
+	CurrentVersion        []byte
+	Epoch                 uint64
+	GenesisValidatorsRoot []byte
+}
+
+// A message for a signer to sign.
+// The signing root is always provided; the message details are provided for signers that check what they're signing.
+type SigningRequest struct {
+	// The kind of message being signed
+	Type SigningType
+
+	// The root to sign, including the signature domain
+	SigningRoot common.Hash
+
+	// The deposit, for deposit requests
+	Deposit *DepositSigningData
+
+	// The exit, for voluntary exit requests
+	VoluntaryExit *VoluntaryExitSigningData
+
+	// The fork the message is signed for, for voluntary exit requests
+	ForkInfo *ForkInfo
+}
+
+// A backend that holds validator keys and signs messages with them
+type IValidatorSigner interface {
+	// The name of the backend, for logging
+	GetName() string
+
+	// Save a new validator key to the signer so it can sign with it, and so the VC can use it
+	ImportKey(ctx context.Context, key *eth2types.BLSPrivateKey, derivationPath string) error
+
+	// Get the pubkeys of every key the signer can sign with
+	GetPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error)
+
+	// Get ready to sign with the provided keys, returning the ones the signer can sign with.
+	// Any keys loaded by a previous call that aren't in the list are unloaded.
+	LoadKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey) (map[beacon.ValidatorPubkey]bool, error)
+
+	// Release the provided keys if they were loaded, since they won't be needed for a while
+	UnloadKeys(pubkeys []beacon.ValidatorPubkey)
+
+	// Sign a message with the key for the provided pubkey
+	Sign(ctx context.Context, pubkey beacon.ValidatorPubkey, request SigningRequest) (beacon.ValidatorSignature, error)
+}
+
+// Create the signer selected in the config
+func NewValidatorSigner(sp IStakeWiseServiceProvider) IValidatorSigner {
+	cfg := sp.GetConfig()
+	if cfg.RemoteSignerUrl.Value != "" {
+		return NewWeb3Signer(sp, cfg.RemoteSignerUrl.Value)
+	}
+	return NewLocalSigner(sp)
+}
+
+// Generates deposit data for the provided keys with the provided withdrawal credentials and amount, using the signer for the signatures
+func SignDepositData(
+	ctx context.Context,
+	logger *slog.Logger,
+	signer IValidatorSigner,
+	resources *swconfig.MergedResources,
+	withdrawalCreds common.Hash,
+	amount uint64,
+	pubkeys []beacon.ValidatorPubkey,
+) ([]beacon.ExtendedDepositData, error) {
+	depositDomain, err := GetGenesisDepositDomain(resources.GenesisForkVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error computing deposit domain: %w", err)
+	}
+
+	dataList := make([]beacon.ExtendedDepositData, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		// Get the signing root
+		message := ssz_types.DepositDataNoSignature{
+			PublicKey:             pubkey[:],
+			WithdrawalCredentials: withdrawalCreds[:],
+			Amount:                amount,
+		}
+		messageRoot, err := message.HashTreeRoot()
+		if err != nil {
+			return nil, fmt.Errorf("error getting deposit message root for key %s: %w", pubkey.HexWithPrefix(), err)
+		}
+		signingRoot, err := getSigningRoot(messageRoot[:], depositDomain)
+		if err != nil {
+			return nil, fmt.Errorf("error getting deposit signing root for key %s: %w", pubkey.HexWithPrefix(), err)
+		}
+
+		// Sign it
+		signature, err := signer.Sign(ctx, pubkey, SigningRequest{
+			Type:        SigningType_Deposit,
+			SigningRoot: signingRoot,
+			Deposit: &DepositSigningData{
+				Pubkey:                pubkey,
+				WithdrawalCredentials: withdrawalCreds,
+				Amount:                amount,
+				GenesisForkVersion:    resources.GenesisForkVersion,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error signing deposit data for key %s: %w", pubkey.HexWithPrefix(), err)
+		}
+		err = ValidateDepositInfo(logger, depositDomain, amount, pubkey[:], withdrawalCreds[:], signature[:])
+		if err != nil {
+			return nil, fmt.Errorf("deposit data for key %s failed signature validation: %w", pubkey.HexWithPrefix(), err)
+		}
+
+		// Get the deposit data root
+		depositData := ssz_types.DepositData{
+			PublicKey:             pubkey[:],
+			WithdrawalCredentials: withdrawalCreds[:],
+			Amount:                amount,
+			Signature:             signature[:],
+		}
+		depositDataRoot, err := depositData.HashTreeRoot()
+		if err != nil {
+			return nil, fmt.Errorf("error getting deposit data root for key %s: %w", pubkey.HexWithPrefix(), err)
+		}
+		dataList[i] = beacon.ExtendedDepositData{
+			PublicKey:             depositData.PublicKey,
+			WithdrawalCredentials: depositData.WithdrawalCredentials,
+			Amount:                amount,
+			Signature:             depositData.Signature,
+			DepositMessageRoot:    messageRoot[:],
+			DepositDataRoot:       depositDataRoot[:],
+			ForkVersion:           beacon.ByteArray(resources.GenesisForkVersion),
+			NetworkName:           resources.EthNetworkName,
+		}
+	}
+	return dataList, nil
+}
+
+// Get a signed voluntary exit message for the provided validator, using the signer for the signature
+func SignExitMessage(
+	ctx context.Context,
+	signer IValidatorSigner,
+	forkInfo *ForkInfo,
+	pubkey beacon.ValidatorPubkey,
+	validatorIndex string,
+	epoch uint64,
+	signatureDomain []byte,
+) (beacon.ValidatorSignature, error) {
+	// Get the signing root
+	index, err := strconv.ParseUint(validatorIndex, 10, 64)
+	if err != nil {
+		return beacon.ValidatorSignature{}, fmt.Errorf("error parsing validator index (%s): %w", validatorIndex, err)
+	}
+	exitMessage := ssz_types.VoluntaryExit{
+		Epoch:          epoch,
+		ValidatorIndex: index,
+	}
+	messageRoot, err := exitMessage.HashTreeRoot()
+	if err != nil {
+		return beacon.ValidatorSignature{}, fmt.Errorf("error getting exit message root: %w", err)
+	}
+	signingRoot, err := getSigningRoot(messageRoot[:], signatureDomain)
+	if err != nil {
+		return beacon.ValidatorSignature{}, fmt.Errorf("error getting exit signing root: %w", err)
+	}
+
+	// Sign it
+	signature, err := signer.Sign(ctx, pubkey, SigningRequest{
+		Type:        SigningType_VoluntaryExit,
+		SigningRoot: signingRoot,
+		VoluntaryExit: &VoluntaryExitSigningData{
+			Epoch:          epoch,
+			ValidatorIndex: index,
+		},
+		ForkInfo: forkInfo,
+	})
+	if err != nil {
+		return beacon.ValidatorSignature{}, err
+	}
+	err = validator.ValidateExitMessageSignature(pubkey, validatorIndex, signatureDomain, epoch, signature[:])
+	if err != nil {
+		return beacon.ValidatorSignature{}, err
+	}
+	return signature, nil
+}
+
+// Get the fork info voluntary exits are signed for. Exits are always signed with the Capella fork version, per EIP-7044.
+func GetExitForkInfo(ctx context.Context, sp IStakeWiseServiceProvider) (*ForkInfo, error) {
+	res := sp.GetResources()
+	eth2Config, err := sp.GetBeaconClient().GetEth2Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Beacon config: %w", err)
+	}
+	return &ForkInfo{
+		PreviousVersion:       res.CapellaForkVersion,
+		CurrentVersion:        res.CapellaForkVersion,
+		Epoch:                 res.CapellaForkEpoch,
+		GenesisValidatorsRoot: eth2Config.GenesisValidatorsRoot,
+	}, nil
+}
+
+// Get the root to sign for a message root and signature domain
+func getSigningRoot(messageRoot []byte, domain []byte) (common.Hash, error) {
+	signingRoot := ssz_types.SigningRoot{
+		ObjectRoot: messageRoot,
+		Domain:     domain,
+	}
+	root, err := signingRoot.HashTreeRoot()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.Hash(root), nil
+}
@@ -0,0 +1,193 @@
+package swcommon
+
+import (
+	"time"
+
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+const (
+	// Drops larger than this from a validator above its max effective balance are treated as withdrawal sweeps instead of penalties, in gwei
+	withdrawalSweepThreshold uint64 = 1e6
+)
+
+// A validator's entry in a snapshot, along with when the snapshot was taken
+type validatorSample struct {
+	epoch uint64
+	time  time.Time
+	entry ValidatorSnapshotEntry
+}
+
+// Estimate the performance of validators from a series of snapshots, grouping their rewards by the provided period.
+// If no pubkeys are provided, every validator in the snapshots is included.
+// Pubkeys that don't appear in any of the snapshots are returned separately.
+func GetValidatorPerformance(snapshots []ValidatorSnapshot, pubkeys []beacon.ValidatorPubkey, period swapi.PerformancePeriod) ([]swapi.ValidatorPerformance, []beacon.ValidatorPubkey) {
+	// Collect the samples for each validator
+	samples := map[beacon.ValidatorPubkey][]validatorSample{}
+	order := []beacon.ValidatorPubkey{}
+	for _, snapshot := range snapshots {
+		for _, entry := range snapshot.Validators {
+			if _, exists := samples[entry.Pubkey]; !exists {
+				order = append(order, entry.Pubkey)
+			}
+			samples[entry.Pubkey] = append(samples[entry.Pubkey], validatorSample{
+				epoch: snapshot.Epoch,
+				time:  snapshot.Time,
+				entry: entry,
+			})
+		}
+	}
+	if len(pubkeys) > 0 {
+		order = pubkeys
+	}
+
+	performances := make([]swapi.ValidatorPerformance, 0, len(order))
+	unknown := []beacon.ValidatorPubkey{}
+	for _, pubkey := range order {
+		validatorSamples, exists := samples[pubkey]
+		if !exists {
+			unknown = append(unknown, pubkey)
+			continue
+		}
+		performances = append(performances, getPerformanceFromSamples(pubkey, validatorSamples, period))
+	}
+	return performances, unknown
+}
+
+// Estimate the performance of a single validator from its samples
+func getPerformanceFromSamples(pubkey beacon.ValidatorPubkey, samples []validatorSample, period swapi.PerformancePeriod) swapi.ValidatorPerformance {
+	first := samples[0]
+	last := samples[len(samples)-1]
+	performance := swapi.ValidatorPerformance{
+		Pubkey:        pubkey,
+		Index:         last.entry.Index,
+		State:         last.entry.Status,
+		Balance:       last.entry.Balance,
+		Snapshots:     len(samples),
+		FirstEpoch:    first.epoch,
+		LastEpoch:     last.epoch,
+		BalanceDelta:  int64(last.entry.Balance) - int64(first.entry.Balance),
+		PeriodRewards: []swapi.ValidatorPeriodRewards{},
+	}
+
+	for i := 1; i < len(samples); i++ {
+		prev := samples[i-1]
+		cur := samples[i]
+		rewards, withdrawn := getIntervalRewards(prev.entry, cur.entry)
+		performance.Withdrawn += withdrawn
+		performance.Rewards += rewards
+
+		// Balance decreases while the validator is supposed to be attesting usually mean it missed attestations
+		decreased := false
+		if isAttesting(prev.entry) && isAttesting(cur.entry) {
+			performance.ActiveIntervals++
+			if rewards < 0 {
+				decreased = true
+				performance.Decreases++
+				performance.LastDecreaseEpoch = cur.epoch
+			}
+		}
+
+		// Add the rewards to the period the interval ended in
+		var start time.Time
+		if period == swapi.PerformancePeriod_Day {
+			start = cur.time.UTC().Truncate(24 * time.Hour)
+		} else {
+			start = prev.time.UTC()
+		}
+		periodCount := len(performance.PeriodRewards)
+		if periodCount == 0 || !performance.PeriodRewards[periodCount-1].Start.Equal(start) {
+			performance.PeriodRewards = append(performance.PeriodRewards, swapi.ValidatorPeriodRewards{
+				Start:      start,
+				StartEpoch: prev.epoch,
+			})
+			periodCount++
+		}
+		periodRewards := &performance.PeriodRewards[periodCount-1]
+		periodRewards.EndEpoch = cur.epoch
+		periodRewards.Rewards += rewards
+		if decreased {
+			periodRewards.Decreases++
+		}
+	}
+
+	if performance.ActiveIntervals > 0 {
+		performance.Effectiveness = float64(performance.ActiveIntervals-performance.Decreases) / float64(performance.ActiveIntervals) * 100
+	}
+	return performance
+}
+
+// Get the rewards a validator earned between two snapshots and the amount that was withdrawn from it, in gwei.
+// Withdrawals aren't visible on their own, so large drops after the validator is eligible for a withdrawal are treated as one.
+func getIntervalRewards(prev ValidatorSnapshotEntry, cur ValidatorSnapshotEntry) (int64, uint64) {
+	delta := int64(cur.Balance) - int64(prev.Balance)
+	if cur.Balance >= prev.Balance {
+		return delta, 0
+	}
+
+	// A full withdrawal after the validator exited
+	if cur.Status == beacon.ValidatorState_WithdrawalPossible || cur.Status == beacon.ValidatorState_WithdrawalDone {
+		return 0, prev.Balance - cur.Balance
+	}
+
+	// A partial withdrawal sweep of everything above the max effective balance
+	maxEffectiveBalance := getMaxEffectiveBalance(prev)
+	if prev.Balance > maxEffectiveBalance && prev.Balance-cur.Balance >= withdrawalSweepThreshold {
+		withdrawn := prev.Balance - maxEffectiveBalance
+		return delta + int64(withdrawn), withdrawn
+	}
+	return delta, 0
+}
+
+// Get the balance above which a validator's excess gets swept by partial withdrawals, in gwei
+func getMaxEffectiveBalance(entry ValidatorSnapshotEntry) uint64 {
+	if IsCompoundingWithdrawalCreds(entry.WithdrawalCredentials) {
+		return MaxCompoundingBalance
+	}
+	return StakewiseDepositAmount
+}
+
+// Get the validators that lost balance in every interval across the provided snapshots while they were supposed to be attesting,
+// which usually means they're offline. Validators that don't appear in every snapshot are skipped.
+func GetOfflineValidators(snapshots []ValidatorSnapshot) []beacon.ValidatorPubkey {
+	offline := []beacon.ValidatorPubkey{}
+	if len(snapshots) < 2 {
+		return offline
+	}
+
+	// Index the earlier snapshots so each validator can be followed through them
+	entries := make([]map[beacon.ValidatorPubkey]ValidatorSnapshotEntry, len(snapshots))
+	for i, snapshot := range snapshots {
+		entries[i] = map[beacon.ValidatorPubkey]ValidatorSnapshotEntry{}
+		for _, entry := range snapshot.Validators {
+			entries[i][entry.Pubkey] = entry
+		}
+	}
+
+	for _, last := range snapshots[len(snapshots)-1].Validators {
+		isOffline := true
+		for i := 1; i < len(snapshots); i++ {
+			prev, prevExists := entries[i-1][last.Pubkey]
+			cur, curExists := entries[i][last.Pubkey]
+			if !prevExists || !curExists || !isAttesting(prev) || !isAttesting(cur) {
+				isOffline = false
+				break
+			}
+			rewards, _ := getIntervalRewards(prev, cur)
+			if rewards >= 0 {
+				isOffline = false
+				break
+			}
+		}
+		if isOffline {
+			offline = append(offline, last.Pubkey)
+		}
+	}
+	return offline
+}
+
+// Check if a validator is expected to be attesting
+func isAttesting(entry ValidatorSnapshotEntry) bool {
+	return !entry.Slashed && (entry.Status == beacon.ValidatorState_ActiveOngoing || entry.Status == beacon.ValidatorState_ActiveExiting)
+}
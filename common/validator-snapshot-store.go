@@ -0,0 +1,208 @@
+package swcommon
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
+
+// The balance and status of a single validator when a snapshot was taken
+type ValidatorSnapshotEntry struct {
+	Pubkey           beacon.ValidatorPubkey `json:"pubkey"`
+	Index            string                 `json:"index"`
+	Status           beacon.ValidatorState  `json:"status"`
+	Balance          uint64                 `json:"balance"`
+	EffectiveBalance uint64                 `json:"effectiveBalance"`
+	Slashed          bool                   `json:"slashed"`
+
+	// Empty in snapshots taken before credentials were recorded, so those validators are treated as 0x01 validators
+	WithdrawalCredentials common.Hash `json:"withdrawalCredentials"`
+}
+
+// The balances and statuses of the node's NodeSet-registered validators at a single epoch
+type ValidatorSnapshot struct {
+	Epoch      uint64                   `json:"epoch"`
+	Time       time.Time                `json:"time"`
+	Validators []ValidatorSnapshotEntry `json:"validators"`
+}
+
+// ValidatorSnapshotStore keeps a time series of validator snapshots for performance tracking.
+// Snapshots are appended to a JSON lines file, one per line, oldest first.
+type ValidatorSnapshotStore struct {
+	dataPath string
+	sp       IStakeWiseServiceProvider
+	lock     *sync.Mutex
+
+	snapshots []ValidatorSnapshot
+}
+
+// Creates a new snapshot store
+func NewValidatorSnapshotStore(sp IStakeWiseServiceProvider) (*ValidatorSnapshotStore, error) {
+	store := &ValidatorSnapshotStore{
+		dataPath: filepath.Join(sp.GetModuleDir(), swconfig.ValidatorSnapshotsFile),
+		sp:       sp,
+		lock:     &sync.Mutex{},
+	}
+	err := store.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading validator snapshots: %w", err)
+	}
+	return store, nil
+}
+
+// Reload the snapshots from disk
+func (s *ValidatorSnapshotStore) Reload() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	snapshots := []ValidatorSnapshot{}
+	file, err := os.Open(s.dataPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - start with no history
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error opening validator snapshots file [%s]: %w", s.dataPath, err)
+		}
+		s.snapshots = snapshots
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024)
+	truncated := false
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var snapshot ValidatorSnapshot
+		err = json.Unmarshal(line, &snapshot)
+		if err != nil {
+			// A partial line from an interrupted write, drop it and everything after it
+			truncated = true
+			break
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading validator snapshots file [%s]: %w", s.dataPath, err)
+	}
+	s.snapshots = snapshots
+
+	// Rewrite the file without the partial line so new snapshots don't get appended to it
+	if truncated {
+		return s.saveData()
+	}
+	return nil
+}
+
+// Get the epoch of the latest snapshot, and whether there are any snapshots at all
+func (s *ValidatorSnapshotStore) GetLatestEpoch() (uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.snapshots) == 0 {
+		return 0, false
+	}
+	return s.snapshots[len(s.snapshots)-1].Epoch, true
+}
+
+// Add a snapshot to the end of the series and save it to disk, dropping any snapshots that are older than the retention period
+func (s *ValidatorSnapshotStore) AddSnapshot(snapshot ValidatorSnapshot) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.snapshots) > 0 && snapshot.Epoch <= s.snapshots[len(s.snapshots)-1].Epoch {
+		return fmt.Errorf("snapshot for epoch %d is not newer than the latest snapshot (epoch %d)", snapshot.Epoch, s.snapshots[len(s.snapshots)-1].Epoch)
+	}
+	s.snapshots = append(s.snapshots, snapshot)
+
+	// Rewrite the file if there are snapshots to prune, otherwise just append the new one
+	retention := time.Duration(s.sp.GetConfig().PerformanceRetentionDays.Value) * 24 * time.Hour
+	cutoff := snapshot.Time.Add(-retention)
+	pruneCount := 0
+	for pruneCount < len(s.snapshots)-1 && s.snapshots[pruneCount].Time.Before(cutoff) {
+		pruneCount++
+	}
+	if pruneCount > 0 {
+		s.snapshots = s.snapshots[pruneCount:]
+		return s.saveData()
+	}
+	return s.appendSnapshot(snapshot)
+}
+
+// Get the snapshots taken at or after the provided time, oldest first
+func (s *ValidatorSnapshotStore) GetSnapshotsSince(since time.Time) []ValidatorSnapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	snapshots := []ValidatorSnapshot{}
+	for _, snapshot := range s.snapshots {
+		if snapshot.Time.Before(since) {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// Get up to the provided number of the most recent snapshots, oldest first
+func (s *ValidatorSnapshotStore) GetLatestSnapshots(count int) []ValidatorSnapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	start := len(s.snapshots) - count
+	if start < 0 {
+		start = 0
+	}
+	snapshots := make([]ValidatorSnapshot, len(s.snapshots)-start)
+	copy(snapshots, s.snapshots[start:])
+	return snapshots
+}
+
+// Append a single snapshot to the file
+func (s *ValidatorSnapshotStore) appendSnapshot(snapshot ValidatorSnapshot) error {
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error serializing validator snapshot: %w", err)
+	}
+	file, err := os.OpenFile(s.dataPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("error opening validator snapshots file [%s]: %w", s.dataPath, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("error saving validator snapshot to disk: %w", err)
+	}
+	return nil
+}
+
+// Rewrite the whole file with the current snapshots
+func (s *ValidatorSnapshotStore) saveData() error {
+	var buffer bytes.Buffer
+	for _, snapshot := range s.snapshots {
+		line, err := json.Marshal(snapshot)
+		if err != nil {
+			return fmt.Errorf("error serializing validator snapshot: %w", err)
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+	err := os.WriteFile(s.dataPath, buffer.Bytes(), fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving validator snapshots to disk: %w", err)
+	}
+	return nil
+}
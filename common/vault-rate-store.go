@@ -0,0 +1,182 @@
+package swcommon
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+)
+
+// The exchange rate of each vault, in wei of assets per share, at a single block
+type VaultRateSample struct {
+	Block uint64                      `json:"block"`
+	Time  time.Time                   `json:"time"`
+	Rates map[common.Address]*big.Int `json:"rates"`
+}
+
+// VaultRateStore keeps a history of vault exchange rates, so APRs can be estimated without the Execution Client's historical state.
+// Samples are appended to a JSON lines file, one per line, oldest first.
+type VaultRateStore struct {
+	dataPath string
+	sp       IStakeWiseServiceProvider
+	lock     *sync.Mutex
+
+	samples []VaultRateSample
+}
+
+// Creates a new vault rate store
+func NewVaultRateStore(sp IStakeWiseServiceProvider) (*VaultRateStore, error) {
+	store := &VaultRateStore{
+		dataPath: filepath.Join(sp.GetModuleDir(), swconfig.VaultRatesFile),
+		sp:       sp,
+		lock:     &sync.Mutex{},
+	}
+	err := store.Reload()
+	if err != nil {
+		return nil, fmt.Errorf("error loading vault rates: %w", err)
+	}
+	return store, nil
+}
+
+// Reload the samples from disk
+func (s *VaultRateStore) Reload() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	samples := []VaultRateSample{}
+	file, err := os.Open(s.dataPath)
+	if err != nil {
+		// If the file doesn't exist, that's fine - start with no history
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error opening vault rates file [%s]: %w", s.dataPath, err)
+		}
+		s.samples = samples
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	truncated := false
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var sample VaultRateSample
+		err = json.Unmarshal(line, &sample)
+		if err != nil {
+			// A partial line from an interrupted write, drop it and everything after it
+			truncated = true
+			break
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading vault rates file [%s]: %w", s.dataPath, err)
+	}
+	s.samples = samples
+
+	// Rewrite the file without the partial line so new samples don't get appended to it
+	if truncated {
+		return s.saveData()
+	}
+	return nil
+}
+
+// Get the block of the latest sample, and whether there are any samples at all
+func (s *VaultRateStore) GetLatestBlock() (uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.samples) == 0 {
+		return 0, false
+	}
+	return s.samples[len(s.samples)-1].Block, true
+}
+
+// Add a sample to the end of the history and save it to disk.
+// Samples are only kept as long as the APR window needs them, so the newest sample at or before the start of the window is the oldest one left.
+func (s *VaultRateStore) AddSample(sample VaultRateSample) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.samples) > 0 && sample.Block <= s.samples[len(s.samples)-1].Block {
+		return fmt.Errorf("sample for block %d is not newer than the latest sample (block %d)", sample.Block, s.samples[len(s.samples)-1].Block)
+	}
+	s.samples = append(s.samples, sample)
+
+	// Rewrite the file if there are samples to prune, otherwise just append the new one
+	window := s.sp.GetConfig().VaultAprWindow.Value
+	pruneCount := 0
+	if sample.Block > window {
+		cutoff := sample.Block - window
+		for pruneCount < len(s.samples)-1 && s.samples[pruneCount+1].Block <= cutoff {
+			pruneCount++
+		}
+	}
+	if pruneCount > 0 {
+		s.samples = s.samples[pruneCount:]
+		return s.saveData()
+	}
+	return s.appendSample(sample)
+}
+
+// Get the newest sample taken at or before the provided block, or nil if there isn't one
+func (s *VaultRateStore) GetSampleAtOrBefore(block uint64) *VaultRateSample {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i := len(s.samples) - 1; i >= 0; i-- {
+		if s.samples[i].Block <= block {
+			sample := s.samples[i]
+			return &sample
+		}
+	}
+	return nil
+}
+
+// Append a single sample to the file
+func (s *VaultRateStore) appendSample(sample VaultRateSample) error {
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("error serializing vault rate sample: %w", err)
+	}
+	file, err := os.OpenFile(s.dataPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("error opening vault rates file [%s]: %w", s.dataPath, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("error saving vault rate sample to disk: %w", err)
+	}
+	return nil
+}
+
+// Rewrite the whole file with the current samples
+func (s *VaultRateStore) saveData() error {
+	var buffer bytes.Buffer
+	for _, sample := range s.samples {
+		line, err := json.Marshal(sample)
+		if err != nil {
+			return fmt.Errorf("error serializing vault rate sample: %w", err)
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+	err := os.WriteFile(s.dataPath, buffer.Bytes(), fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving vault rates to disk: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package swcommon
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	batch "github.com/rocket-pool/batch-query"
+	"github.com/rocket-pool/node-manager-core/eth"
+)
+
+const (
+	// The number of seconds in a year, used to annualize vault returns
+	secondsPerYear float64 = 365 * 24 * 60 * 60
+)
+
+// Get the on-chain health of each vault on the deployment, along with an APR estimate over the configured block window.
+// The vault values are all read in a single multicall at the current block.
+// The APR uses the exchange rates recorded in the vault rate store, falling back to the Execution Client's historical state for vaults that weren't recorded.
+// If NodeSet says the node isn't registered or doesn't have permission to see the vaults, the corresponding flag is set in the data and the vaults are left empty.
+func GetVaultReport(ctx context.Context, sp IStakeWiseServiceProvider, data *swapi.NetworkVaultReportData) error {
+	client := sp.GetHyperdriveClient()
+	res := sp.GetResources()
+	cfg := sp.GetConfig()
+	ec := sp.GetEthClient()
+	qMgr := sp.GetQueryManager()
+	txMgr := sp.GetTransactionManager()
+
+	// Get the list of vaults for this deployment
+	response, err := client.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return fmt.Errorf("failed to get vaults: %w", err)
+	}
+	if response.Data.NotRegistered {
+		data.NotRegisteredWithNodeSet = true
+		return nil
+	}
+	if response.Data.InvalidPermissions {
+		data.InvalidPermissions = true
+		return nil
+	}
+	data.Vaults = []*swapi.NetworkVaultReport{}
+	if len(response.Data.Vaults) == 0 {
+		return nil
+	}
+
+	// Create the vault contracts
+	vaultContracts := make([]*swcontracts.IEthVault, len(response.Data.Vaults))
+	for i, vault := range response.Data.Vaults {
+		vaultContract, err := swcontracts.NewIEthVault(vault.Address, ec, txMgr)
+		if err != nil {
+			return fmt.Errorf("error creating binding for vault [%s]: %w", vault.Address.Hex(), err)
+		}
+		vaultContracts[i] = vaultContract
+		data.Vaults = append(data.Vaults, &swapi.NetworkVaultReport{
+			Name:    vault.Name,
+			Address: vault.Address,
+		})
+	}
+
+	// Pin the report to the current block
+	currentHeader, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error getting latest block header: %w", err)
+	}
+	data.Block = currentHeader.Number.Uint64()
+
+	// Get the vault details
+	oneShare := eth.EthToWei(1)
+	err = qMgr.Query(func(mc *batch.MultiCaller) error {
+		for i, vault := range data.Vaults {
+			contract := vaultContracts[i]
+			contract.TotalAssets(mc, &vault.TotalAssets)
+			contract.TotalShares(mc, &vault.TotalShares)
+			contract.ConvertToAssets(mc, &vault.ExchangeRate, oneShare)
+			contract.QueuedShares(mc, &vault.QueuedShares)
+			contract.TotalExitingAssets(mc, &vault.TotalExitingAssets)
+			contract.Capacity(mc, &vault.Capacity)
+			contract.FeePercent(mc, &vault.FeePercent)
+			contract.WithdrawableAssets(mc, &vault.WithdrawableAssets)
+		}
+		return nil
+	}, &bind.CallOpts{
+		BlockNumber: currentHeader.Number,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting vault details: %w", err)
+	}
+
+	// Get the exchange rates at the start of the APR window
+	window := cfg.VaultAprWindow.Value
+	if window == 0 || window > data.Block {
+		setAprError(data.Vaults, fmt.Sprintf("the chain doesn't have %d blocks to estimate the APR over yet", window))
+		return nil
+	}
+	data.AprWindowStartBlock = data.Block - window
+	startRates := make([]*big.Int, len(data.Vaults))
+	startBlocks := make([]uint64, len(data.Vaults))
+	startTimes := make([]uint64, len(data.Vaults))
+
+	// Use the recorded history first, since it doesn't need the Execution Client's historical state
+	missingVaults := []int{}
+	sample := sp.GetVaultRateStore().GetSampleAtOrBefore(data.AprWindowStartBlock)
+	for i, vault := range data.Vaults {
+		if sample != nil && sample.Rates[vault.Address] != nil {
+			startRates[i] = sample.Rates[vault.Address]
+			startBlocks[i] = sample.Block
+			startTimes[i] = uint64(sample.Time.Unix())
+			continue
+		}
+		missingVaults = append(missingVaults, i)
+	}
+
+	// Fall back to the state at the start of the window for any vaults that weren't recorded back then; this needs an archive node
+	if len(missingVaults) > 0 {
+		startHeader, err := ec.HeaderByNumber(ctx, new(big.Int).SetUint64(data.AprWindowStartBlock))
+		if err != nil {
+			return fmt.Errorf("error getting header for block %d: %w", data.AprWindowStartBlock, err)
+		}
+		results, err := qMgr.FlexQuery(func(mc *batch.MultiCaller) error {
+			for _, i := range missingVaults {
+				vaultContracts[i].ConvertToAssets(mc, &startRates[i], oneShare)
+			}
+			return nil
+		}, &bind.CallOpts{
+			BlockNumber: startHeader.Number,
+		})
+		for j, i := range missingVaults {
+			if err != nil {
+				// This usually means the client has pruned the state for the start block
+				data.Vaults[i].AprError = fmt.Sprintf("the exchange rate history doesn't go back to block %d yet, and the Execution Client doesn't have the state for it: %s", data.AprWindowStartBlock, err.Error())
+				startRates[i] = nil
+				continue
+			}
+			if !results[j] {
+				startRates[i] = nil
+			}
+			startBlocks[i] = data.AprWindowStartBlock
+			startTimes[i] = startHeader.Time
+		}
+	}
+
+	// Annualize the change in each vault's exchange rate
+	for i, vault := range data.Vaults {
+		if vault.AprError != "" {
+			continue
+		}
+		if startRates[i] == nil || startRates[i].Sign() == 0 {
+			vault.AprError = fmt.Sprintf("vault didn't have an exchange rate at block %d", startBlocks[i])
+			continue
+		}
+		if currentHeader.Time <= startTimes[i] {
+			vault.AprError = "no time has passed over the APR window"
+			continue
+		}
+		vault.AprStartBlock = startBlocks[i]
+		vault.Apr = GetAnnualizedReturn(startRates[i], vault.ExchangeRate, currentHeader.Time-startTimes[i])
+		vault.AprAvailable = true
+	}
+	return nil
+}
+
+// Record the current exchange rate of each vault on the deployment in the vault rate store, for estimating APRs later
+func RecordVaultRates(ctx context.Context, sp IStakeWiseServiceProvider) error {
+	client := sp.GetHyperdriveClient()
+	res := sp.GetResources()
+	ec := sp.GetEthClient()
+	txMgr := sp.GetTransactionManager()
+
+	// Get the list of vaults for this deployment
+	response, err := client.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return fmt.Errorf("failed to get vaults: %w", err)
+	}
+	if response.Data.NotRegistered || response.Data.InvalidPermissions || len(response.Data.Vaults) == 0 {
+		return nil
+	}
+
+	// Get the rates at the current block
+	header, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error getting latest block header: %w", err)
+	}
+	rates := make([]*big.Int, len(response.Data.Vaults))
+	oneShare := eth.EthToWei(1)
+	results, err := sp.GetQueryManager().FlexQuery(func(mc *batch.MultiCaller) error {
+		for i, vault := range response.Data.Vaults {
+			vaultContract, err := swcontracts.NewIEthVault(vault.Address, ec, txMgr)
+			if err != nil {
+				return fmt.Errorf("error creating binding for vault [%s]: %w", vault.Address.Hex(), err)
+			}
+			vaultContract.ConvertToAssets(mc, &rates[i], oneShare)
+		}
+		return nil
+	}, &bind.CallOpts{
+		BlockNumber: header.Number,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting vault exchange rates: %w", err)
+	}
+
+	sample := VaultRateSample{
+		Block: header.Number.Uint64(),
+		Time:  time.Unix(int64(header.Time), 0).UTC(),
+		Rates: map[common.Address]*big.Int{},
+	}
+	for i, vault := range response.Data.Vaults {
+		if results[i] && rates[i] != nil {
+			sample.Rates[vault.Address] = rates[i]
+		}
+	}
+	return sp.GetVaultRateStore().AddSample(sample)
+}
+
+// Get the annualized percent return of going from the start rate to the end rate over the elapsed number of seconds
+func GetAnnualizedReturn(startRate *big.Int, endRate *big.Int, elapsed uint64) float64 {
+	growth, _ := new(big.Float).Quo(new(big.Float).SetInt(endRate), new(big.Float).SetInt(startRate)).Float64()
+	return (growth - 1) * (secondsPerYear / float64(elapsed)) * 100
+}
+
+// Mark the APR as unavailable for all of the vaults
+func setAprError(vaults []*swapi.NetworkVaultReport, message string) {
+	for _, vault := range vaults {
+		vault.AprError = message
+	}
+}
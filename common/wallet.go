@@ -1,17 +1,18 @@
 package swcommon
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/goccy/go-json"
 	"github.com/nodeset-org/hyperdrive-daemon/shared"
 	"github.com/nodeset-org/hyperdrive-daemon/shared/config"
-	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
 	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
 	"github.com/rocket-pool/node-manager-core/beacon"
 	"github.com/rocket-pool/node-manager-core/node/validator"
@@ -36,6 +37,18 @@ type Wallet struct {
 	stakewiseKeystoreManager  *stakewiseKeystoreManager
 	data                      stakewiseWalletData
 	sp                        IStakeWiseServiceProvider
+
+	// Held while keystores are written or swapped in by a rotation, so a key isn't saved with a password that's being rotated out
+	keystoreLock *sync.Mutex
+
+	// Incremented whenever a keystore is written, so a rotation can tell if the keystores changed while it was re-encrypting them
+	keystoreGeneration uint64
+
+	// Held for the whole of a keystore password rotation, so only one runs at a time
+	rotationLock *sync.Mutex
+
+	// Held while the wallet data is read or written, since key generation and key recovery can both update the next account index
+	dataLock *sync.Mutex
 }
 
 // Create a new wallet
@@ -45,6 +58,9 @@ func NewWallet(sp IStakeWiseServiceProvider) (*Wallet, error) {
 		sp:                        sp,
 		stakewiseWalletFilePath:   filepath.Join(moduleDir, swconfig.WalletFilename),
 		stakewisePasswordFilePath: filepath.Join(moduleDir, swconfig.PasswordFilename),
+		keystoreLock:              &sync.Mutex{},
+		rotationLock:              &sync.Mutex{},
+		dataLock:                  &sync.Mutex{},
 	}
 
 	err := wallet.Reload()
@@ -56,7 +72,12 @@ func NewWallet(sp IStakeWiseServiceProvider) (*Wallet, error) {
 
 // Reload the wallet data from disk
 func (w *Wallet) Reload() error {
+	w.keystoreLock.Lock()
+	defer w.keystoreLock.Unlock()
+
 	// Check if the wallet data exists
+	w.dataLock.Lock()
+	defer w.dataLock.Unlock()
 	moduleDir := w.sp.GetModuleDir()
 	dataPath := filepath.Join(moduleDir, walletDataFilename)
 	_, err := os.Stat(dataPath)
@@ -87,12 +108,19 @@ func (w *Wallet) Reload() error {
 		w.data = data
 	}
 
+	// Finish or undo a keystore password rotation that was interrupted
+	err = recoverKeystoreRotation(moduleDir)
+	if err != nil {
+		return fmt.Errorf("error recovering interrupted keystore password rotation: %w", err)
+	}
+
 	// Make the Stakewise keystore manager
 	stakewiseKeystoreMgr, err := newStakewiseKeystoreManager(moduleDir)
 	if err != nil {
 		return fmt.Errorf("error creating Stakewise keystore manager: %w", err)
 	}
 	w.stakewiseKeystoreManager = stakewiseKeystoreMgr
+	w.keystoreGeneration++
 
 	// Make the validator manager
 	validatorPath := filepath.Join(moduleDir, config.ValidatorsDirectory)
@@ -101,45 +129,43 @@ func (w *Wallet) Reload() error {
 	return nil
 }
 
-// Generate a new validator key and save it
-func (w *Wallet) GenerateNewValidatorKey() (*eth2types.BLSPrivateKey, error) {
+// Generate a new validator key and save it, reserving it for the provided vault.
+// If the vault is the zero address, the key won't be reserved and can be used for any vault.
+func (w *Wallet) GenerateNewValidatorKey(vault common.Address) (*eth2types.BLSPrivateKey, error) {
 	keyMgr := w.sp.GetAvailableKeyManager()
 
-	// Get the path for the next validator key
+	// Get the path for the next validator key, holding the data lock until the index is used up so a key recovery can't change it in the meantime
+	w.dataLock.Lock()
 	path := fmt.Sprintf(shared.StakeWiseValidatorPath, w.data.NextAccount)
 
 	// Ask the HD daemon to generate the key
 	client := w.sp.GetHyperdriveClient()
 	response, err := client.Wallet.GenerateValidatorKey(path)
 	if err != nil {
+		w.dataLock.Unlock()
 		return nil, fmt.Errorf("error generating validator key for path [%s]: %w", path, err)
 	}
 
 	// Increment the next account index first for safety
 	w.data.NextAccount++
 	err = w.saveData()
+	w.dataLock.Unlock()
 	if err != nil {
 		return nil, err
 	}
 
-	// Save the key to the VC stores
+	// Save the key to the signer
 	key, err := eth2types.BLSPrivateKeyFromBytes(response.Data.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("error converting BLS private key for path %s: %w", path, err)
 	}
-	err = w.validatorManager.StoreKey(key, path)
+	err = w.sp.GetValidatorSigner().ImportKey(w.sp.GetBaseContext(), key, path)
 	if err != nil {
-		return nil, fmt.Errorf("error saving validator key: %w", err)
-	}
-
-	// Save the key to the StakeWise folder
-	err = w.stakewiseKeystoreManager.StoreValidatorKey(key, path)
-	if err != nil {
-		return nil, fmt.Errorf("error saving validator key to the StakeWise store: %w", err)
+		return nil, err
 	}
 
 	// Add it to the keymanager
-	err = keyMgr.AddNewKey(key)
+	err = keyMgr.AddNewKey(beacon.ValidatorPubkey(key.PublicKey().Marshal()), vault)
 	if err != nil {
 		return nil, fmt.Errorf("error adding new key to available list: %w", err)
 	}
@@ -148,9 +174,18 @@ func (w *Wallet) GenerateNewValidatorKey() (*eth2types.BLSPrivateKey, error) {
 
 // Get the private validator key with the corresponding pubkey
 func (w *Wallet) GetPrivateKeyForPubkey(pubkey beacon.ValidatorPubkey) (*eth2types.BLSPrivateKey, error) {
+	w.keystoreLock.Lock()
+	defer w.keystoreLock.Unlock()
 	return w.stakewiseKeystoreManager.LoadValidatorKey(pubkey)
 }
 
+// Get the pubkeys of every key in the StakeWise keystore folder
+func (w *Wallet) GetStoredPubkeys() ([]beacon.ValidatorPubkey, error) {
+	w.keystoreLock.Lock()
+	defer w.keystoreLock.Unlock()
+	return w.stakewiseKeystoreManager.GetStoredPubkeys()
+}
+
 // Get the private validator key with the corresponding pubkey
 func (w *Wallet) DerivePubKeys(privateKeys []*eth2types.BLSPrivateKey) ([]beacon.ValidatorPubkey, error) {
 	publicKeys := make([]beacon.ValidatorPubkey, 0, len(privateKeys))
@@ -167,41 +202,6 @@ func (w *Wallet) DerivePubKeys(privateKeys []*eth2types.BLSPrivateKey) ([]beacon
 	return publicKeys, nil
 }
 
-// Gets all of the validator private keys that are stored in the Stakewise keystore folder
-func (w *Wallet) GetAllPrivateKeys() ([]*eth2types.BLSPrivateKey, error) {
-	dir := w.stakewiseKeystoreManager.GetKeystoreDir()
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, fmt.Errorf("error enumerating Stakewise keystore folder [%s]: %w", dir, err)
-	}
-
-	// Go through each file
-	keys := []*eth2types.BLSPrivateKey{}
-	for _, file := range files {
-		filename := file.Name()
-		if !strings.HasPrefix(filename, keystorePrefix) || !strings.HasSuffix(filename, keystoreSuffix) {
-			continue
-		}
-
-		// Get the pubkey from the filename
-		trimmed := strings.TrimPrefix(filename, keystorePrefix)
-		trimmed = strings.TrimSuffix(trimmed, keystoreSuffix)
-		pubkey, err := beacon.HexToValidatorPubkey(trimmed)
-		if err != nil {
-			return nil, fmt.Errorf("error getting pubkey for keystore file [%s]: %w", filename, err)
-		}
-
-		// Load it
-		key, err := w.stakewiseKeystoreManager.LoadValidatorKey(pubkey)
-		if err != nil {
-			return nil, fmt.Errorf("error loading validator keystore file [%s]: %w", filename, err)
-		}
-		keys = append(keys, key)
-	}
-
-	return keys, nil
-}
-
 // Saves the Stakewise wallet and password files
 func (w *Wallet) SaveStakewiseWallet(ethKey []byte, password string) error {
 	// Write the wallet to disk
@@ -239,107 +239,84 @@ func (w *Wallet) CheckIfStakewiseWalletExists() (bool, error) {
 	return true, nil
 }
 
-func (w *Wallet) RecoverValidatorKeys(
-	keysToSearchFor []beacon.ValidatorPubkey,
-	startIndex uint64,
-	count uint64,
-	searchLimit uint64,
-) (
-	recoveredKeys []swapi.RecoveredKey,
-	searchEnd uint64,
-	err error,
-) {
-	keyMgr := w.sp.GetAvailableKeyManager()
-
-	// Sanity checking
-	if count == 0 {
-		return nil, 0, fmt.Errorf("count must be greater than 0")
-	}
-	if len(keysToSearchFor) == 0 {
-		return nil, 0, fmt.Errorf("no keys to search for")
-	}
-
-	// Make a map of the keys to search for
-	iteration := uint64(0)
-	searchMap := make(map[beacon.ValidatorPubkey]struct{}, len(keysToSearchFor))
-	for _, key := range keysToSearchFor {
-		searchMap[key] = struct{}{}
+// Derive the validator keys for a range of indices, with up to the provided number of derivations running at once.
+// The keys are returned in index order.
+func (w *Wallet) DeriveValidatorKeys(ctx context.Context, startIndex uint64, count uint64, concurrency uint64) ([]*eth2types.BLSPrivateKey, error) {
+	if concurrency == 0 {
+		concurrency = 1
 	}
 
-	// Run the search for each index
-	recoveredKeys = make([]swapi.RecoveredKey, 0, count)
-	for {
-		index := startIndex + iteration
-
-		// Get the path for the validator key
-		path := fmt.Sprintf(shared.StakeWiseValidatorPath, index)
-
-		// Ask the HD daemon to generate the key
-		client := w.sp.GetHyperdriveClient()
-		response, err := client.Wallet.GenerateValidatorKey(path)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error generating validator key for path [%s]: %w", path, err)
+	client := w.sp.GetHyperdriveClient()
+	keys := make([]*eth2types.BLSPrivateKey, count)
+	errs := make([]error, count)
+	semaphore := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+	for i := uint64(0); i < count; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		case semaphore <- struct{}{}:
 		}
 
-		// Check if this is one of the keys we're looking for
-		privateKey, err := eth2types.BLSPrivateKeyFromBytes(response.Data.PrivateKey)
-		if err != nil {
-			return nil, 0, fmt.Errorf("error converting BLS private key for path %s: %w", path, err)
-		}
-		pubkey := beacon.ValidatorPubkey(privateKey.PublicKey().Marshal())
-		_, exists := searchMap[pubkey]
-		if exists {
-			// Add it to the list of recovered keys
-			recoveredKeys = append(recoveredKeys, swapi.RecoveredKey{
-				Pubkey: pubkey,
-				Index:  index,
-			})
-			delete(searchMap, pubkey)
-
-			// Update the next account index if needed
-			if index >= w.data.NextAccount {
-				w.data.NextAccount = index + 1
-				err = w.saveData()
-				if err != nil {
-					return nil, 0, fmt.Errorf("error saving wallet data: %w", err)
-				}
-			}
+		wg.Add(1)
+		go func(i uint64) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
 
-			// Save the key
-			err = w.validatorManager.StoreKey(privateKey, path)
+			// Ask the HD daemon to generate the key
+			path := fmt.Sprintf(shared.StakeWiseValidatorPath, startIndex+i)
+			response, err := client.Wallet.GenerateValidatorKey(path)
 			if err != nil {
-				return nil, 0, fmt.Errorf("error saving validator key: %w", err)
+				errs[i] = fmt.Errorf("error generating validator key for path [%s]: %w", path, err)
+				return
 			}
-			err = w.stakewiseKeystoreManager.StoreValidatorKey(privateKey, path)
+			keys[i], err = eth2types.BLSPrivateKeyFromBytes(response.Data.PrivateKey)
 			if err != nil {
-				return nil, 0, fmt.Errorf("error saving validator key to the StakeWise store: %w", err)
+				errs[i] = fmt.Errorf("error converting BLS private key for path %s: %w", path, err)
 			}
-			err = keyMgr.AddNewKey(privateKey)
-			if err != nil {
-				return nil, 0, fmt.Errorf("error adding new key to available list: %w", err)
-			}
-		}
-
-		// Stop if we found all the keys
-		if len(searchMap) == 0 {
-			return recoveredKeys, index, nil
-		}
+		}(i)
+	}
+	wg.Wait()
 
-		// Stop if we've hit the recovery limit
-		if uint64(len(recoveredKeys)) >= count {
-			return recoveredKeys, index, nil
-		}
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
 
-		// Stop if we've hit the search limit
-		if iteration >= searchLimit {
-			return recoveredKeys, index, nil
+// Save a validator key that was recovered from the provided index, and add it to the available keys
+func (w *Wallet) SaveRecoveredKey(key *eth2types.BLSPrivateKey, index uint64) error {
+	// Update the next account index if needed
+	w.dataLock.Lock()
+	if index >= w.data.NextAccount {
+		w.data.NextAccount = index + 1
+		err := w.saveData()
+		if err != nil {
+			w.dataLock.Unlock()
+			return fmt.Errorf("error saving wallet data: %w", err)
 		}
+	}
+	w.dataLock.Unlock()
 
-		iteration++
+	// Save the key
+	path := fmt.Sprintf(shared.StakeWiseValidatorPath, index)
+	err := w.sp.GetValidatorSigner().ImportKey(w.sp.GetBaseContext(), key, path)
+	if err != nil {
+		return err
 	}
+	pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+	err = w.sp.GetAvailableKeyManager().AddNewKey(pubkey, common.Address{})
+	if err != nil {
+		return fmt.Errorf("error adding new key to available list: %w", err)
+	}
+	return nil
 }
 
-// Write the wallet data to disk
+// Write the wallet data to disk; the data lock must be held
 func (w *Wallet) saveData() error {
 	// Serialize it
 	dataPath := filepath.Join(w.sp.GetModuleDir(), walletDataFilename)
@@ -0,0 +1,357 @@
+package swcommon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Web3Signer routes
+	web3SignerPublicKeysPath string = "/api/v1/eth2/publicKeys"
+	web3SignerSignPath       string = "/api/v1/eth2/sign/"
+	web3SignerKeystoresPath  string = "/eth/v1/keystores"
+
+	// Lighthouse reads remote signer keys from its validator definitions file instead of a command line flag
+	lighthouseDefinitionsFile string = "validator_definitions.yml"
+)
+
+// Body of a request to import keystores through the Web3Signer key manager API
+type Web3SignerImportRequest struct {
+	Keystores []string `json:"keystores"`
+	Passwords []string `json:"passwords"`
+}
+
+// The result of importing a single keystore through the Web3Signer key manager API
+type Web3SignerImportStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Response to a request to import keystores through the Web3Signer key manager API
+type Web3SignerImportResponse struct {
+	Data []Web3SignerImportStatus `json:"data"`
+}
+
+// Body of a request to sign a message with Web3Signer
+type Web3SignerSignRequest struct {
+	Type          SigningType              `json:"type"`
+	SigningRoot   string                   `json:"signingRoot"`
+	Deposit       *Web3SignerDeposit       `json:"deposit,omitempty"`
+	VoluntaryExit *Web3SignerVoluntaryExit `json:"voluntary_exit,omitempty"`
+	ForkInfo      *Web3SignerForkInfo      `json:"fork_info,omitempty"`
+}
+
+// The deposit in a Web3Signer deposit signing request
+type Web3SignerDeposit struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                string `json:"amount"`
+	GenesisForkVersion    string `json:"genesis_fork_version"`
+}
+
+// The exit in a Web3Signer voluntary exit signing request
+type Web3SignerVoluntaryExit struct {
+	Epoch          string `json:"epoch"`
+	ValidatorIndex string `json:"validator_index"`
+}
+
+// The fork info in a Web3Signer signing request
+type Web3SignerForkInfo struct {
+	Fork                  Web3SignerFork `json:"fork"`
+	GenesisValidatorsRoot string         `json:"genesis_validators_root"`
+}
+
+// The fork in a Web3Signer signing request
+type Web3SignerFork struct {
+	PreviousVersion string `json:"previous_version"`
+	CurrentVersion  string `json:"current_version"`
+	Epoch           string `json:"epoch"`
+}
+
+// Response to a request to sign a message with Web3Signer
+type Web3SignerSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// A remote signer entry in Lighthouse's validator definitions file
+type lighthouseRemoteDefinition struct {
+	Enabled         bool   `yaml:"enabled"`
+	VotingPublicKey string `yaml:"voting_public_key"`
+	Type            string `yaml:"type"`
+	Url             string `yaml:"url"`
+}
+
+// Web3Signer signs with validator keys held by a Web3Signer-compatible remote signer.
+// Keys are imported into it through its key manager API, and nothing is written to the local keystores.
+type Web3Signer struct {
+	sp        IStakeWiseServiceProvider
+	url       string
+	client    *http.Client
+	encryptor *eth2ks.Encryptor
+	lock      *sync.Mutex
+}
+
+// Creates a new Web3Signer client
+func NewWeb3Signer(sp IStakeWiseServiceProvider, url string) *Web3Signer {
+	return &Web3Signer{
+		sp:        sp,
+		url:       strings.TrimSuffix(url, "/"),
+		client:    &http.Client{},
+		encryptor: eth2ks.New(eth2ks.WithCipher("scrypt")),
+		lock:      &sync.Mutex{},
+	}
+}
+
+// The name of the backend, for logging
+func (s *Web3Signer) GetName() string {
+	return "web3signer"
+}
+
+// Import a new validator key into the remote signer, and register it with Lighthouse's validator definitions
+func (s *Web3Signer) ImportKey(ctx context.Context, key *eth2types.BLSPrivateKey, derivationPath string) error {
+	pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+
+	// Encrypt the key with a one-time password
+	password, err := utils.GenerateRandomPassword()
+	if err != nil {
+		return fmt.Errorf("error generating keystore password: %w", err)
+	}
+	encryptedKey, err := s.encryptor.Encrypt(key.Marshal(), password)
+	if err != nil {
+		return fmt.Errorf("error encrypting validator key: %w", err)
+	}
+	keystore := beacon.ValidatorKeystore{
+		Crypto:  encryptedKey,
+		Version: s.encryptor.Version(),
+		UUID:    uuid.New(),
+		Path:    derivationPath,
+		Pubkey:  pubkey,
+	}
+	keystoreBytes, err := json.Marshal(keystore)
+	if err != nil {
+		return fmt.Errorf("error serializing validator keystore: %w", err)
+	}
+
+	// Import it
+	var response Web3SignerImportResponse
+	err = s.sendRequest(ctx, http.MethodPost, web3SignerKeystoresPath, Web3SignerImportRequest{
+		Keystores: []string{string(keystoreBytes)},
+		Passwords: []string{password},
+	}, &response)
+	if err != nil {
+		return fmt.Errorf("error importing key %s into the remote signer: %w", pubkey.HexWithPrefix(), err)
+	}
+	if len(response.Data) != 1 {
+		return fmt.Errorf("remote signer returned %d import results for key %s, expected 1", len(response.Data), pubkey.HexWithPrefix())
+	}
+	status := response.Data[0]
+	if status.Status != "imported" && status.Status != "duplicate" {
+		return fmt.Errorf("remote signer couldn't import key %s: %s (%s)", pubkey.HexWithPrefix(), status.Status, status.Message)
+	}
+
+	return s.addLighthouseDefinition(pubkey)
+}
+
+// Get the pubkeys of every key in the remote signer
+func (s *Web3Signer) GetPubkeys(ctx context.Context) ([]beacon.ValidatorPubkey, error) {
+	var response []string
+	err := s.sendRequest(ctx, http.MethodGet, web3SignerPublicKeysPath, nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("error getting public keys from the remote signer: %w", err)
+	}
+	pubkeys := make([]beacon.ValidatorPubkey, len(response))
+	for i, pubkeyString := range response {
+		pubkeys[i], err = beacon.HexToValidatorPubkey(pubkeyString)
+		if err != nil {
+			return nil, fmt.Errorf("remote signer returned invalid public key [%s]: %w", pubkeyString, err)
+		}
+	}
+	return pubkeys, nil
+}
+
+// Check which of the provided keys the remote signer has
+func (s *Web3Signer) LoadKeys(ctx context.Context, pubkeys []beacon.ValidatorPubkey) (map[beacon.ValidatorPubkey]bool, error) {
+	signerPubkeys, err := s.GetPubkeys(ctx)
+	if err != nil {
+		return map[beacon.ValidatorPubkey]bool{}, err
+	}
+	signerPubkeyMap := make(map[beacon.ValidatorPubkey]bool, len(signerPubkeys))
+	for _, pubkey := range signerPubkeys {
+		signerPubkeyMap[pubkey] = true
+	}
+	loaded := make(map[beacon.ValidatorPubkey]bool, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		if signerPubkeyMap[pubkey] {
+			loaded[pubkey] = true
+		}
+	}
+	return loaded, nil
+}
+
+// Nothing is held locally, so there's nothing to unload
+func (s *Web3Signer) UnloadKeys(pubkeys []beacon.ValidatorPubkey) {
+}
+
+// Sign a message with the remote signer
+func (s *Web3Signer) Sign(ctx context.Context, pubkey beacon.ValidatorPubkey, request SigningRequest) (beacon.ValidatorSignature, error) {
+	body := Web3SignerSignRequest{
+		Type:        request.Type,
+		SigningRoot: request.SigningRoot.Hex(),
+	}
+	if request.Deposit != nil {
+		body.Deposit = &Web3SignerDeposit{
+			Pubkey:                request.Deposit.Pubkey.HexWithPrefix(),
+			WithdrawalCredentials: request.Deposit.WithdrawalCredentials.Hex(),
+			Amount:                strconv.FormatUint(request.Deposit.Amount, 10),
+			GenesisForkVersion:    hexutil.Encode(request.Deposit.GenesisForkVersion),
+		}
+	}
+	if request.VoluntaryExit != nil {
+		body.VoluntaryExit = &Web3SignerVoluntaryExit{
+			Epoch:          strconv.FormatUint(request.VoluntaryExit.Epoch, 10),
+			ValidatorIndex: strconv.FormatUint(request.VoluntaryExit.ValidatorIndex, 10),
+		}
+	}
+	if request.ForkInfo != nil {
+		body.ForkInfo = &Web3SignerForkInfo{
+			Fork: Web3SignerFork{
+				PreviousVersion: hexutil.Encode(request.ForkInfo.PreviousVersion),
+				CurrentVersion:  hexutil.Encode(request.ForkInfo.CurrentVersion),
+				Epoch:           strconv.FormatUint(request.ForkInfo.Epoch, 10),
+			},
+			GenesisValidatorsRoot: hexutil.Encode(request.ForkInfo.GenesisValidatorsRoot),
+		}
+	}
+
+	var response Web3SignerSignResponse
+	err := s.sendRequest(ctx, http.MethodPost, web3SignerSignPath+pubkey.HexWithPrefix(), body, &response)
+	if err != nil {
+		return beacon.ValidatorSignature{}, fmt.Errorf("error signing %s message for key %s with the remote signer: %w", request.Type, pubkey.HexWithPrefix(), err)
+	}
+	signature, err := beacon.HexToValidatorSignature(response.Signature)
+	if err != nil {
+		return beacon.ValidatorSignature{}, fmt.Errorf("remote signer returned invalid signature [%s] for key %s: %w", response.Signature, pubkey.HexWithPrefix(), err)
+	}
+	return signature, nil
+}
+
+// Send a request to the remote signer and deserialize the JSON response
+func (s *Web3Signer) sendRequest(ctx context.Context, method string, path string, body any, response any) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error serializing request: %w", err)
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, s.url+path, bodyReader)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("remote signer returned status %d: %s", resp.StatusCode, string(responseBody))
+	}
+	err = json.Unmarshal(responseBody, response)
+	if err != nil {
+		return fmt.Errorf("error deserializing response [%s]: %w", string(responseBody), err)
+	}
+	return nil
+}
+
+// Add a remote signer entry for the key to Lighthouse's validator definitions file, keeping any entries that are already there
+func (s *Web3Signer) addLighthouseDefinition(pubkey beacon.ValidatorPubkey) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dir := filepath.Join(s.sp.GetModuleDir(), config.ValidatorsDirectory, "lighthouse", "validators")
+	path := filepath.Join(dir, lighthouseDefinitionsFile)
+	definitions := []map[string]any{}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error reading Lighthouse validator definitions [%s]: %w", path, err)
+		}
+	} else {
+		err = yaml.Unmarshal(bytes, &definitions)
+		if err != nil {
+			return fmt.Errorf("error deserializing Lighthouse validator definitions [%s]: %w", path, err)
+		}
+	}
+
+	// Skip it if it's already there
+	pubkeyString := pubkey.HexWithPrefix()
+	for _, definition := range definitions {
+		existing, _ := definition["voting_public_key"].(string)
+		if strings.EqualFold(existing, pubkeyString) {
+			return nil
+		}
+	}
+
+	// Add it
+	entry := lighthouseRemoteDefinition{
+		Enabled:         true,
+		VotingPublicKey: pubkeyString,
+		Type:            "web3signer",
+		Url:             s.url,
+	}
+	entryBytes, err := yaml.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error serializing Lighthouse validator definition: %w", err)
+	}
+	var entryMap map[string]any
+	err = yaml.Unmarshal(entryBytes, &entryMap)
+	if err != nil {
+		return fmt.Errorf("error converting Lighthouse validator definition: %w", err)
+	}
+	definitions = append(definitions, entryMap)
+
+	// Save the file
+	bytes, err = yaml.Marshal(definitions)
+	if err != nil {
+		return fmt.Errorf("error serializing Lighthouse validator definitions: %w", err)
+	}
+	err = os.MkdirAll(dir, dirMode)
+	if err != nil {
+		return fmt.Errorf("error creating Lighthouse validators folder [%s]: %w", dir, err)
+	}
+	err = os.WriteFile(path, bytes, fileMode)
+	if err != nil {
+		return fmt.Errorf("error saving Lighthouse validator definitions [%s]: %w", path, err)
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ require (
 	github.com/nodeset-org/hyperdrive-daemon v1.3.0
 	github.com/nodeset-org/nodeset-client-go v1.3.1
 	github.com/nodeset-org/osha v0.4.0
+	github.com/prometheus/client_golang v1.19.1
 	github.com/prysmaticlabs/prysm/v5 v5.1.0
 	github.com/rocket-pool/batch-query v1.0.0
 	github.com/stretchr/testify v1.10.0
@@ -127,7 +128,6 @@ require (
 	github.com/pbnjay/memory v0.0.0-20210728143218-7b4eea64cf58 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/prometheus/client_golang v1.19.1 // indirect
 	github.com/prometheus/client_model v0.6.1 // indirect
 	github.com/prometheus/common v0.55.0 // indirect
 	github.com/prometheus/procfs v0.15.1 // indirect
@@ -0,0 +1,236 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swtasks "github.com/nodeset-org/hyperdrive-stakewise/tasks"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	// Record the requests the webhook gets
+	received := make(chan swcommon.Alert, 1)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var alert swcommon.Alert
+		err = json.Unmarshal(body, &alert)
+		require.NoError(t, err)
+		received <- alert
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("webhook response"))
+	}))
+	defer server.Close()
+
+	// Send an alert
+	notifier := swcommon.NewWebhookNotifier(server.URL)
+	alert := getTestAlert("webhook")
+	err := notifier.Notify(context.Background(), alert)
+	require.NoError(t, err)
+	require.Equal(t, alert, <-received)
+	t.Log("Webhook received the alert")
+
+	// Errors from the webhook should be returned
+	status = http.StatusInternalServerError
+	err = notifier.Notify(context.Background(), alert)
+	require.ErrorContains(t, err, "webhook returned status 500: webhook response")
+	<-received
+	t.Log("Webhook error was returned")
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	notifier := swcommon.NewFileNotifier(path)
+
+	// Send two alerts
+	first := getTestAlert("file-1")
+	second := getTestAlert("file-2")
+	err := notifier.Notify(context.Background(), first)
+	require.NoError(t, err)
+	err = notifier.Notify(context.Background(), second)
+	require.NoError(t, err)
+
+	// They should be written to the file as JSON lines, in order
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	alerts := []swcommon.Alert{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var alert swcommon.Alert
+		err = json.Unmarshal(scanner.Bytes(), &alert)
+		require.NoError(t, err)
+		alerts = append(alerts, alert)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []swcommon.Alert{first, second}, alerts)
+	t.Log("Alerts were appended to the file")
+}
+
+func TestAlertManager(t *testing.T) {
+	err := testMgr.RevertSnapshot(initSnapshot)
+	if err != nil {
+		fail("Error reverting to initial snapshot: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	alertMgr := sp.GetAlertManager()
+	logger := sp.GetTasksLogger().Logger
+	require.True(t, alertMgr.IsEnabled())
+
+	// The subject is unique to this test since the manager's state isn't part of the snapshot
+	subject := "alert-manager-test"
+	countAlerts := func() int {
+		count := 0
+		for _, alert := range getSentAlerts(t, swcommon.AlertType_RelayFailure) {
+			if alert.Subject == subject {
+				count++
+			}
+		}
+		return count
+	}
+
+	// Send the alert
+	alert := getTestAlert(subject)
+	alertMgr.Send(logger, alert)
+	require.Eventually(t, func() bool {
+		return countAlerts() == 1
+	}, 5*time.Second, 100*time.Millisecond)
+	t.Log("Alert was sent")
+
+	// Repeats should be suppressed
+	alertMgr.Send(logger, alert)
+	require.Never(t, func() bool {
+		return countAlerts() > 1
+	}, time.Second, 100*time.Millisecond)
+	t.Log("Repeat of the alert was suppressed")
+
+	// Once it's resolved, it should be sent again
+	alertMgr.Resolve(alert.Type, subject)
+	alertMgr.Send(logger, alert)
+	require.Eventually(t, func() bool {
+		return countAlerts() == 2
+	}, 5*time.Second, 100*time.Millisecond)
+	t.Log("Alert was sent again after it was resolved")
+}
+
+func TestGetOfflineValidators(t *testing.T) {
+	// Key 0 loses balance every epoch, key 1 recovers in the last one, and key 2 is slashed
+	balances := [][]uint64{
+		{32e9, 32e9, 31e9},
+		{32e9 - 1e3, 32e9 - 1e3, 31e9 - 1e3},
+		{32e9 - 2e3, 32e9, 31e9 - 2e3},
+	}
+	keys := []beacon.ValidatorPubkey{{0x01}, {0x02}, {0x03}}
+	snapshots := []swcommon.ValidatorSnapshot{}
+	for i, epochBalances := range balances {
+		snapshot := swcommon.ValidatorSnapshot{
+			Epoch: uint64(100 + i),
+		}
+		for j, balance := range epochBalances {
+			snapshot.Validators = append(snapshot.Validators, swcommon.ValidatorSnapshotEntry{
+				Pubkey:           keys[j],
+				Status:           beacon.ValidatorState_ActiveOngoing,
+				Balance:          balance,
+				EffectiveBalance: 32e9,
+				Slashed:          j == 2,
+			})
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	// Only key 0 should be offline
+	offline := swcommon.GetOfflineValidators(snapshots)
+	require.Equal(t, []beacon.ValidatorPubkey{keys[0]}, offline)
+	t.Log("Only the validator that lost balance in every snapshot was offline")
+
+	// A validator missing from one of the snapshots can't be called offline
+	snapshots[0].Validators = snapshots[0].Validators[1:]
+	offline = swcommon.GetOfflineValidators(snapshots)
+	require.Empty(t, offline)
+	t.Log("Validator without a full history wasn't offline")
+
+	// A single snapshot isn't enough to tell
+	offline = swcommon.GetOfflineValidators(snapshots[2:])
+	require.Empty(t, offline)
+}
+
+func TestCheckAlerts_Slashed(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	store := sp.GetValidatorSnapshotStore()
+	task := swtasks.NewCheckAlertsTask(context.Background(), sp, sp.GetTasksLogger())
+
+	// Record a slashed validator
+	start := time.Now().UTC().Add(-time.Hour)
+	addSnapshot := func(i int) {
+		err := store.AddSnapshot(swcommon.ValidatorSnapshot{
+			Epoch: uint64(200 + i),
+			Time:  start.Add(time.Duration(i) * time.Minute),
+			Validators: []swcommon.ValidatorSnapshotEntry{
+				{
+					Pubkey:           pubkeys[0],
+					Index:            "1",
+					Status:           beacon.ValidatorState_ActiveSlashed,
+					Balance:          31e9,
+					EffectiveBalance: 31e9,
+					Slashed:          true,
+				},
+			},
+		})
+		require.NoError(t, err)
+	}
+	addSnapshot(0)
+
+	// The first check should alert
+	err = task.Run()
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(getSentAlerts(t, swcommon.AlertType_ValidatorSlashed)) == 1
+	}, 5*time.Second, 100*time.Millisecond)
+	t.Log("Slashing was alerted")
+
+	// Later checks shouldn't repeat it, even after the manager's repeat interval is cleared
+	sp.GetAlertManager().Resolve(swcommon.AlertType_ValidatorSlashed, pubkeys[0].HexWithPrefix())
+	addSnapshot(1)
+	err = task.Run()
+	require.NoError(t, err)
+	require.Never(t, func() bool {
+		return len(getSentAlerts(t, swcommon.AlertType_ValidatorSlashed)) > 1
+	}, time.Second, 100*time.Millisecond)
+	t.Log("Slashing was only alerted once")
+}
+
+// Create an alert for testing notifiers
+func getTestAlert(subject string) swcommon.Alert {
+	return swcommon.Alert{
+		Type:     swcommon.AlertType_RelayFailure,
+		Severity: swcommon.AlertSeverity_Warning,
+		Subject:  subject,
+		Title:    "Test alert",
+		Message:  "This is a test alert.",
+		Time:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
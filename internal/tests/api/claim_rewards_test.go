@@ -0,0 +1,114 @@
+package api_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/eth"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// The SplitWarehouse withdraw function, used to check the claim transaction
+	splitWarehouseWithdrawAbiString string = `[{"type":"function","name":"withdraw","inputs":[{"name":"_owner","type":"address"},{"name":"_tokens","type":"address[]"},{"name":"_amounts","type":"uint256[]"},{"name":"_withdrawer","type":"address"}],"outputs":[],"stateMutability":"nonpayable"}]`
+)
+
+func TestWalletClaimRewards(t *testing.T) {
+	err := testMgr.RevertSnapshot(initSnapshot)
+	if err != nil {
+		fail("Error reverting to initial snapshot: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	client := mainNode.GetApiClient()
+
+	// Add a second enabled vault and a disabled one
+	secondVault := common.HexToAddress("0x5afe00000000000000000000000000000000beef")
+	disabledVault := common.HexToAddress("0x000000000000000000000000000000000badbeef")
+	splitWarehouse := common.HexToAddress("0x5b117000000000000000000000000000000000aa")
+	nativeToken := common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+	oldVaults := res.Vaults
+	oldSplitWarehouse := res.SplitWarehouse
+	defer func() {
+		res.Vaults = oldVaults
+		res.SplitWarehouse = oldSplitWarehouse
+	}()
+	res.Vaults = []*swconfig.StakeWiseVault{
+		{Enabled: true, Address: secondVault},
+		{Enabled: false, Address: disabledVault},
+	}
+	res.SplitWarehouse = splitWarehouse
+
+	// Mock the vault tokens and the SplitWarehouse
+	for i, vault := range []common.Address{res.Vault, secondVault, disabledVault} {
+		err = testMgr.SetMockContract(vault, map[string][]byte{
+			"name()":     packAbiValue(t, "string", "Vault Token "+string(rune('A'+i))),
+			"symbol()":   packAbiValue(t, "string", "VT"+string(rune('A'+i))),
+			"decimals()": packAbiValue(t, "uint8", uint8(18)),
+		})
+		require.NoError(t, err)
+	}
+	rewards := eth.EthToWei(1.5)
+	err = testMgr.SetMockContract(splitWarehouse, map[string][]byte{
+		"NATIVE_TOKEN()":             packAbiValue(t, "address", nativeToken),
+		"balanceOf(address,uint256)": packAbiValue(t, "uint256", rewards),
+	})
+	require.NoError(t, err)
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	require.NoError(t, err)
+
+	// Get the rewards
+	response, err := client.Wallet.ClaimRewards()
+	require.NoError(t, err)
+	data := response.Data
+	require.True(t, data.CanClaim)
+	require.Equal(t, nativeToken, data.NativeToken)
+	require.Equal(t, 0, rewards.Cmp(data.WithdrawableNativeToken))
+	require.Len(t, data.Vaults, 2)
+	require.Equal(t, res.Vault, data.Vaults[0].Vault)
+	require.Equal(t, "Vault Token A", data.Vaults[0].TokenName)
+	require.Equal(t, "VTA", data.Vaults[0].TokenSymbol)
+	require.Equal(t, secondVault, data.Vaults[1].Vault)
+	require.Equal(t, "Vault Token B", data.Vaults[1].TokenName)
+	require.Equal(t, "VTB", data.Vaults[1].TokenSymbol)
+	for _, vault := range data.Vaults {
+		require.Equal(t, 0, rewards.Cmp(vault.WithdrawableToken))
+	}
+	t.Log("Got the claimable balances for both enabled vaults and the native token")
+
+	// Check the withdrawal
+	require.NotNil(t, data.TxInfo)
+	require.Equal(t, splitWarehouse, data.TxInfo.To)
+	require.True(t, data.TxInfo.SimulationResult.IsSimulated)
+	require.Empty(t, data.TxInfo.SimulationResult.SimulationError)
+	withdrawAbi, err := abi.JSON(strings.NewReader(splitWarehouseWithdrawAbiString))
+	require.NoError(t, err)
+	method := withdrawAbi.Methods["withdraw"]
+	require.Equal(t, method.ID, data.TxInfo.Data[:4])
+	args, err := method.Inputs.Unpack(data.TxInfo.Data[4:])
+	require.NoError(t, err)
+	require.Equal(t, mainNodeAddress, args[0])
+	require.Equal(t, []common.Address{res.Vault, secondVault, nativeToken}, args[1])
+	require.Equal(t, []*big.Int{rewards, rewards, rewards}, args[2])
+	require.Equal(t, mainNodeAddress, args[3])
+	t.Log("Withdrawal for all of the rewards was built and simulated successfully")
+}
+
+// ABI-encode a single value of the provided type
+func packAbiValue(t *testing.T, typeName string, value any) []byte {
+	abiType, err := abi.NewType(typeName, "", nil)
+	require.NoError(t, err)
+	bytes, err := abi.Arguments{{Type: abiType}}.Pack(value)
+	require.NoError(t, err)
+	return bytes
+}
@@ -0,0 +1,197 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestDepositDataCache(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	keyMgr := sp.GetAvailableKeyManager()
+	cache := sp.GetDepositDataCache()
+	logger := testMgr.GetLogger()
+
+	// Get the deposit data for all of the keys
+	response, err := apiClient.Wallet.DepositData(nil, nil)
+	require.NoError(t, err)
+	data := response.Data
+	require.Equal(t, res.Vault, data.Vault)
+	require.Empty(t, data.KeysMissingPrivateKey)
+	require.Empty(t, data.KeysReservedForOtherVault)
+	require.Len(t, data.DepositData, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		require.Equal(t, pubkey, beacon.ValidatorPubkey(data.DepositData[i].PublicKey))
+	}
+	validatorsRoot, err := swcommon.ComputeValidatorsRoot(data.DepositData)
+	require.NoError(t, err)
+	require.Equal(t, validatorsRoot, data.ValidatorsRoot)
+	t.Logf("Got deposit data for all keys, validators root = %s", data.ValidatorsRoot.Hex())
+
+	// Make sure it matches freshly generated deposit data, and that the cache returns it again
+	vaultKeys, _ := keyMgr.GetKeysForVault(logger, res.Vault)
+	vaultPubkeys := make([]beacon.ValidatorPubkey, len(vaultKeys))
+	privateKeys := make([]*eth2types.BLSPrivateKey, len(vaultKeys))
+	for i, key := range vaultKeys {
+		vaultPubkeys[i] = key.PublicKey
+		privateKeys[i], err = sp.GetWallet().GetPrivateKeyForPubkey(key.PublicKey)
+		require.NoError(t, err)
+	}
+	generated, err := swcommon.GenerateDepositData(logger, res, res.Vault, privateKeys)
+	require.NoError(t, err)
+	require.Equal(t, generated, data.DepositData)
+	cached, err := cache.GetDepositData(context.Background(), logger, res.Vault, vaultPubkeys)
+	require.NoError(t, err)
+	require.Equal(t, data.DepositData, cached)
+	t.Log("Cached deposit data matched freshly generated deposit data")
+
+	// Deposit data for another vault should use that vault's withdrawal credentials
+	otherVault := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	response, err = apiClient.Wallet.DepositData(&otherVault, []beacon.ValidatorPubkey{pubkeys[0], {0x01}})
+	require.NoError(t, err)
+	data = response.Data
+	require.Equal(t, otherVault, data.Vault)
+	require.Len(t, data.DepositData, 1)
+	require.Equal(t, []beacon.ValidatorPubkey{{0x01}}, data.UnknownPubkeys)
+	require.NotEqual(t, generated[0].WithdrawalCredentials, data.DepositData[0].WithdrawalCredentials)
+	require.NotEqual(t, generated[0].Signature, data.DepositData[0].Signature)
+	t.Log("Deposit data for another vault was generated separately as expected")
+}
+
+func TestDepositDataCache_Compounding(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+
+	// Get the 0x01 deposit data first so it's cached
+	response, err := apiClient.Wallet.DepositData(nil, nil)
+	require.NoError(t, err)
+	eth1Creds := swcommon.GetWithdrawalCreds(swconfig.WithdrawalCredentialType_Eth1, res.Vault)
+	for _, depositData := range response.Data.DepositData {
+		require.Equal(t, eth1Creds[:], depositData.WithdrawalCredentials)
+		require.Equal(t, swcommon.StakewiseDepositAmount, depositData.Amount)
+	}
+
+	// Switch the vault to compounding validators with a larger deposit
+	amount := uint64(64e9)
+	oldVaults := res.Vaults
+	res.Vaults = append(res.Vaults, &swconfig.StakeWiseVault{
+		Enabled:        true,
+		Address:        res.Vault,
+		CredentialType: swconfig.WithdrawalCredentialType_Compounding,
+		DepositAmount:  amount,
+	})
+	defer func() {
+		res.Vaults = oldVaults
+	}()
+
+	// The cached deposit data should be replaced
+	response, err = apiClient.Wallet.DepositData(nil, nil)
+	require.NoError(t, err)
+	require.Len(t, response.Data.DepositData, len(pubkeys))
+	compoundingCreds := swcommon.GetWithdrawalCreds(swconfig.WithdrawalCredentialType_Compounding, res.Vault)
+	require.Equal(t, byte(0x02), compoundingCreds[0])
+	depositDomain, err := swcommon.GetGenesisDepositDomain(res.GenesisForkVersion)
+	require.NoError(t, err)
+	for _, depositData := range response.Data.DepositData {
+		require.Equal(t, compoundingCreds[:], depositData.WithdrawalCredentials)
+		require.Equal(t, amount, depositData.Amount)
+		err = swcommon.ValidateDepositInfo(logger, depositDomain, depositData.Amount, depositData.PublicKey, depositData.WithdrawalCredentials, depositData.Signature)
+		require.NoError(t, err)
+	}
+	t.Log("Deposit data was regenerated with compounding credentials and the vault's deposit amount")
+
+	// An 0x01 vault can't deposit more than 32 ETH
+	res.Vaults[len(res.Vaults)-1].CredentialType = swconfig.WithdrawalCredentialType_Eth1
+	_, err = apiClient.Wallet.DepositData(nil, nil)
+	require.Error(t, err)
+	t.Log("0x01 vault with a deposit over 32 ETH was rejected as expected")
+
+	// New validators can't be created with less than 32 ETH, since they'd never activate
+	res.Vaults[len(res.Vaults)-1].DepositAmount = 1e9
+	_, err = apiClient.Wallet.DepositData(nil, nil)
+	require.Error(t, err)
+	res.Vaults[len(res.Vaults)-1].CredentialType = swconfig.WithdrawalCredentialType_Compounding
+	res.Vaults[len(res.Vaults)-1].DepositAmount = 16e9
+	_, err = apiClient.Wallet.DepositData(nil, nil)
+	require.Error(t, err)
+	t.Log("0x01 and 0x02 vaults with deposits under 32 ETH were rejected as expected")
+}
+
+func TestValidatorTopUp(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	bnMock := testMgr.GetBeaconMockManager()
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+
+	// Make validator 0 an active compounding validator and validator 1 an active 0x01 validator
+	compoundingCreds := swcommon.GetWithdrawalCreds(swconfig.WithdrawalCredentialType_Compounding, res.Vault)
+	bnValidator, err := bnMock.AddValidator(pubkeys[0], compoundingCreds)
+	require.NoError(t, err)
+	bnValidator.Status = beacon.ValidatorState_ActiveOngoing
+	bnValidator.SetBalance(swcommon.StakewiseDepositAmount)
+	bnValidator, err = bnMock.AddValidator(pubkeys[1], swcommon.GetWithdrawalCreds(swconfig.WithdrawalCredentialType_Eth1, res.Vault))
+	require.NoError(t, err)
+	bnValidator.Status = beacon.ValidatorState_ActiveOngoing
+
+	// Generate the top-ups
+	amount := uint64(10e9)
+	response, err := apiClient.Validator.TopUp(pubkeys, amount)
+	require.NoError(t, err)
+	topUps := response.Data.TopUps
+	require.Len(t, topUps, len(pubkeys))
+
+	// Only the compounding validator should have gotten deposit data
+	require.Empty(t, topUps[0].Error)
+	require.Equal(t, res.Vault, topUps[0].Vault)
+	require.NotNil(t, topUps[0].DepositData)
+	require.Equal(t, amount, topUps[0].DepositData.Amount)
+	require.Equal(t, compoundingCreds[:], topUps[0].DepositData.WithdrawalCredentials)
+	require.Equal(t, pubkeys[0], beacon.ValidatorPubkey(topUps[0].DepositData.PublicKey))
+	require.NotEmpty(t, topUps[1].Error)
+	require.Nil(t, topUps[1].DepositData)
+	require.NotEmpty(t, topUps[2].Error)
+	require.Nil(t, topUps[2].DepositData)
+	t.Log("Top-up deposit data was only generated for the compounding validator")
+
+	// Top-ups below the minimum deposit should be rejected
+	_, err = apiClient.Validator.TopUp(pubkeys[:1], 1e8)
+	require.Error(t, err)
+	t.Log("Top-up below the minimum deposit was rejected as expected")
+}
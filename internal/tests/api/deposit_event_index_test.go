@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositEventIndex(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	index := sp.GetDepositEventIndex()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Do a deposit for key 0 to make an event
+	key, err := keygen.GetBlsPrivateKey(0)
+	require.NoError(t, err)
+	_, err = deposit(key, mainNodeOpts)
+	require.NoError(t, err)
+
+	// Get the current block number
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	t.Logf("Current block number: %d", currentBlock)
+
+	// Build the index
+	err = index.Sync(ctx, logger, currentBlock)
+	require.NoError(t, err)
+	require.Equal(t, currentBlock+1, index.GetNextBlock())
+	t.Log("Synced the deposit event index")
+
+	// Only key 0 should have an event
+	events, err := index.GetDepositEvents(pubkeys, 0, currentBlock)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Len(t, events[pubkeys[0]], 1)
+	depositBlock := events[pubkeys[0]][0].BlockNumber
+	require.LessOrEqual(t, depositBlock, currentBlock)
+	t.Logf("Key 0 was found in the index at block %d as expected", depositBlock)
+
+	// Ranges that don't include the deposit shouldn't return anything
+	events, err = index.GetDepositEvents([]beacon.ValidatorPubkey{pubkeys[0]}, depositBlock+1, currentBlock)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	t.Log("Deposit event was excluded from a later block range as expected")
+
+	// Reloading from disk should keep the events
+	err = index.Reload()
+	require.NoError(t, err)
+	events, err = index.GetDepositEvents(pubkeys, 0, currentBlock)
+	require.NoError(t, err)
+	require.Len(t, events[pubkeys[0]], 1)
+	require.Equal(t, depositBlock, events[pubkeys[0]][0].BlockNumber)
+	t.Log("Deposit event was still in the index after reloading")
+}
@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	swtasks "github.com/nodeset-org/hyperdrive-stakewise/tasks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateKeysTask(t *testing.T) {
+	// Start from a node without any keys
+	err := testMgr.RevertSnapshot(initSnapshot)
+	if err != nil {
+		fail("Error reverting to initial snapshot: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	keyMgr := sp.GetAvailableKeyManager()
+	task := swtasks.NewGenerateKeysTask(context.Background(), sp, sp.GetTasksLogger())
+
+	// Enable the task
+	oldEnabled := cfg.AutoGenerateKeys.Value
+	oldLowWaterMark := cfg.KeyLowWaterMark.Value
+	oldTargetCount := cfg.KeyTargetCount.Value
+	oldRestartVc := cfg.AutoGenerateKeysRestartVc.Value
+	defer func() {
+		cfg.AutoGenerateKeys.Value = oldEnabled
+		cfg.KeyLowWaterMark.Value = oldLowWaterMark
+		cfg.KeyTargetCount.Value = oldTargetCount
+		cfg.AutoGenerateKeysRestartVc.Value = oldRestartVc
+	}()
+	cfg.AutoGenerateKeys.Value = true
+	cfg.KeyLowWaterMark.Value = 2
+	cfg.KeyTargetCount.Value = 5
+	cfg.AutoGenerateKeysRestartVc.Value = false
+
+	// NodeSet only allows 3 validators, so the target of 5 should be clamped to 3
+	vault.MaxValidatorsPerUser = 3
+	err = task.Run()
+	require.NoError(t, err)
+	require.Equal(t, 3, getUnusedKeyCount())
+	t.Log("Generated 3 keys, clamped to the NodeSet limit")
+
+	// The pool is above the low-water mark now, so nothing should be generated even if NodeSet allows more
+	vault.MaxValidatorsPerUser = 10
+	err = task.Run()
+	require.NoError(t, err)
+	require.Equal(t, 3, getUnusedKeyCount())
+	t.Log("No keys were generated while the pool was above the low-water mark")
+
+	// Raise the low-water mark so the pool is below it - the pool should be topped up to the target
+	cfg.KeyLowWaterMark.Value = 4
+	err = task.Run()
+	require.NoError(t, err)
+	require.Equal(t, 5, getUnusedKeyCount())
+	require.Len(t, keyMgr.GetPubkeys(), 5)
+	t.Log("Pool was topped up to the target count")
+
+	// Nothing should happen when the task is disabled
+	cfg.AutoGenerateKeys.Value = false
+	cfg.KeyLowWaterMark.Value = 10
+	err = task.Run()
+	require.NoError(t, err)
+	require.Equal(t, 5, getUnusedKeyCount())
+	t.Log("No keys were generated while the task was disabled")
+}
+
+// Get the number of unused keys across all of the available key pools
+func getUnusedKeyCount() int {
+	count := 0
+	for _, poolCount := range mainNode.GetServiceProvider().GetAvailableKeyManager().GetPoolCounts() {
+		count += poolCount
+	}
+	return count
+}
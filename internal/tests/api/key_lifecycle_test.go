@@ -0,0 +1,178 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	batchquery "github.com/rocket-pool/batch-query"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyLifecycle(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	keyMgr := sp.GetAvailableKeyManager()
+	lifecycleMgr := sp.GetKeyLifecycleManager()
+	qMgr := sp.GetQueryManager()
+	bdc := sp.GetBeaconDepositContract()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Set the max validators per node to 1
+	vault.MaxValidatorsPerUser = 1
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+
+	// All of the keys should start out as generated
+	lifecycles, unknown := lifecycleMgr.GetLifecycles(pubkeys)
+	require.Empty(t, unknown)
+	require.Len(t, lifecycles, len(pubkeys))
+	for _, lifecycle := range lifecycles {
+		require.Equal(t, swapi.KeyLifecycleState_Generated, lifecycle.State)
+		require.Len(t, lifecycle.History, 1)
+	}
+	t.Log("All keys were recorded as generated")
+
+	// Run a lookback scan
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+	lifecycles, _ = lifecycleMgr.GetLifecycles(pubkeys)
+	for _, lifecycle := range lifecycles {
+		require.Equal(t, swapi.KeyLifecycleState_Scanned, lifecycle.State)
+	}
+	t.Log("All keys were recorded as scanned")
+
+	// Run the relay - key 0 should be offered
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 1)
+	require.Equal(t, pubkeys[0], resp.Validators[0].PublicKey)
+
+	var depositRoot common.Hash
+	err = qMgr.Query(func(mc *batchquery.MultiCaller) error {
+		bdc.GetDepositRoot(mc, &depositRoot)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	lifecycles, _ = lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[0]})
+	require.Len(t, lifecycles, 1)
+	offered := lifecycles[0]
+	require.Equal(t, swapi.KeyLifecycleState_Offered, offered.State)
+	require.Len(t, offered.History, 3)
+	require.Equal(t, res.Vault, offered.History[2].Vault)
+	require.Equal(t, depositRoot, offered.History[2].DepositRoot)
+	t.Logf("Key 0 was recorded as offered to vault %s with deposit root %s", res.Vault.Hex(), depositRoot.Hex())
+
+	// Deposit key 0 and rescan
+	key, err := keygen.GetBlsPrivateKey(0)
+	require.NoError(t, err)
+	_, err = deposit(key, mainNodeOpts)
+	require.NoError(t, err)
+	currentBlock, err = sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck: true,
+	})
+	require.NoError(t, err)
+	lifecycles, _ = lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[0]})
+	deposited := lifecycles[0]
+	require.Equal(t, swapi.KeyLifecycleState_Deposited, deposited.State)
+	require.Len(t, deposited.History, 4)
+	require.NotEqual(t, common.Hash{}, deposited.History[3].TxHash)
+	require.LessOrEqual(t, deposited.History[3].BlockNumber, currentBlock)
+	t.Logf("Key 0 was recorded as deposited in TX %s", deposited.History[3].TxHash.Hex())
+
+	// The other keys shouldn't have moved, and unknown keys should be reported
+	lifecycles, unknown = lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[1], {0x01}})
+	require.Len(t, lifecycles, 1)
+	require.Equal(t, swapi.KeyLifecycleState_Scanned, lifecycles[0].State)
+	require.Equal(t, []beacon.ValidatorPubkey{{0x01}}, unknown)
+	t.Log("Key 1 is still scanned and the unknown key was reported")
+}
+
+func TestKeyLifecycle_RewindDeposits(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	lifecycleMgr := sp.GetKeyLifecycleManager()
+
+	// Record deposits for key 0 in a settled block and key 1 in an unsettled one
+	firstUnsettledBlock := uint64(100)
+	transitions := []swcommon.KeyTransition{}
+	for i, block := range []uint64{firstUnsettledBlock - 1, firstUnsettledBlock} {
+		transitions = append(transitions, swcommon.KeyTransition{
+			Pubkey: pubkeys[i],
+			Event: swapi.KeyLifecycleEvent{
+				State:       swapi.KeyLifecycleState_Deposited,
+				TxHash:      common.HexToHash("0x01"),
+				BlockNumber: block,
+			},
+		})
+	}
+	err = lifecycleMgr.RecordTransitions(transitions)
+	require.NoError(t, err)
+
+	// Normal transitions can't move a key backwards
+	err = lifecycleMgr.RecordTransitions([]swcommon.KeyTransition{
+		{
+			Pubkey: pubkeys[1],
+			Event: swapi.KeyLifecycleEvent{
+				State: swapi.KeyLifecycleState_Scanned,
+			},
+		},
+	})
+	require.NoError(t, err)
+	lifecycles, _ := lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[1]})
+	require.Equal(t, swapi.KeyLifecycleState_Deposited, lifecycles[0].State)
+	t.Log("Regular transition couldn't move key 1 backwards")
+
+	// Only the unsettled deposit should be rewound, and keys that were never deposited should be left alone
+	rewound, err := lifecycleMgr.RewindDeposits(pubkeys, firstUnsettledBlock)
+	require.NoError(t, err)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkeys[1]}, rewound)
+	lifecycles, _ = lifecycleMgr.GetLifecycles(pubkeys)
+	require.Equal(t, swapi.KeyLifecycleState_Deposited, lifecycles[0].State)
+	require.Equal(t, swapi.KeyLifecycleState_Scanned, lifecycles[1].State)
+	require.Len(t, lifecycles[1].History, 3)
+	require.Equal(t, swapi.KeyLifecycleState_Generated, lifecycles[2].State)
+	t.Log("Only the deposit in the unsettled block was rewound")
+
+	// The rewind should survive a reload
+	err = lifecycleMgr.Reload()
+	require.NoError(t, err)
+	lifecycles, _ = lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[1]})
+	require.Equal(t, swapi.KeyLifecycleState_Scanned, lifecycles[0].State)
+	t.Log("Rewind was saved to disk")
+}
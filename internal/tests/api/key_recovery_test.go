@@ -0,0 +1,248 @@
+package api_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	apiClient := mainNode.GetApiClient()
+	checkpointPath := filepath.Join(sp.GetModuleDir(), swconfig.KeyRecoveryFile)
+
+	// Start a recovery
+	response, err := apiClient.Wallet.RecoverKeys(pubkeys, 0, uint64(len(pubkeys)), 20, 4, false)
+	require.NoError(t, err)
+	require.False(t, response.Data.NotRegisteredWithNodeSet)
+	require.Equal(t, uint64(4), response.Data.Progress.Concurrency)
+	require.Equal(t, uint64(20), response.Data.Progress.SearchEnd)
+	t.Log("Key recovery started")
+
+	// Wait for it to finish
+	progress := waitForKeyRecovery(t)
+	require.Equal(t, swapi.KeyRecoveryState_Complete, progress.State)
+	require.Empty(t, progress.Error)
+	require.Zero(t, progress.KeysToFind)
+	require.Equal(t, getExpectedRecoveredKeys(), progress.Keys)
+	_, err = os.Stat(checkpointPath)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	t.Logf("Recovered %d keys", len(progress.Keys))
+
+	// Simulate a recovery that was interrupted after finding the first key
+	checkpoint := map[string]any{
+		"pubkeys":     []beacon.ValidatorPubkey{pubkeys[1], pubkeys[2]},
+		"startIndex":  0,
+		"searchEnd":   20,
+		"count":       len(pubkeys),
+		"concurrency": 2,
+		"restartVc":   false,
+		"nextIndex":   1,
+		"keys":        getExpectedRecoveredKeys()[:1],
+		"startTime":   time.Now().UTC(),
+	}
+	bytes, err := json.Marshal(checkpoint)
+	require.NoError(t, err)
+	err = os.WriteFile(checkpointPath, bytes, 0600)
+	require.NoError(t, err)
+	err = sp.GetKeyRecoveryManager().Reload()
+	require.NoError(t, err)
+	statusResponse, err := apiClient.Wallet.RecoverKeysStatus()
+	require.NoError(t, err)
+	require.Equal(t, swapi.KeyRecoveryState_Interrupted, statusResponse.Data.Progress.State)
+	require.Equal(t, uint64(1), statusResponse.Data.Progress.NextIndex)
+	require.Equal(t, 2, statusResponse.Data.Progress.KeysToFind)
+	t.Log("Interrupted key recovery was loaded from its checkpoint")
+
+	// Resume it
+	_, err = apiClient.Wallet.ResumeRecoverKeys()
+	require.NoError(t, err)
+	progress = waitForKeyRecovery(t)
+	require.Equal(t, swapi.KeyRecoveryState_Complete, progress.State)
+	require.Equal(t, getExpectedRecoveredKeys(), progress.Keys)
+	_, err = os.Stat(checkpointPath)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	t.Log("Key recovery was resumed and finished")
+
+	// There shouldn't be anything left to resume
+	_, err = apiClient.Wallet.ResumeRecoverKeys()
+	require.Error(t, err)
+	t.Logf("Resuming with no checkpoint failed as expected: %v", err)
+}
+
+func TestRecoverAll(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	node := nsDB.Core.GetUser(nsEmail).GetNode(mainNodeAddress)
+	keystoreDir := filepath.Join(sp.GetModuleDir(), hdconfig.ValidatorsDirectory, swconfig.ModuleName)
+
+	// Register the keys with NodeSet, along with one that can't be derived from the wallet
+	depositDataResponse, err := apiClient.Wallet.DepositData(nil, nil)
+	require.NoError(t, err)
+	for _, depositData := range depositDataResponse.Data.DepositData {
+		vault.AddStakeWiseDepositData(node, depositData)
+	}
+	unknownPubkey := beacon.ValidatorPubkey{0x01}
+	vault.AddStakeWiseDepositData(node, beacon.ExtendedDepositData{
+		PublicKey: unknownPubkey[:],
+	})
+
+	// Nothing should be missing except the unknown key
+	response, err := apiClient.Wallet.RecoverAll(0, 5, 0, 2, false)
+	require.NoError(t, err)
+	require.Len(t, response.Data.RegisteredPubkeys, len(pubkeys)+1)
+	require.Equal(t, []beacon.ValidatorPubkey{unknownPubkey}, response.Data.MissingPubkeys)
+	progress := waitForKeyRecovery(t)
+	require.Empty(t, progress.Keys)
+	require.Equal(t, uint64(5), progress.NextIndex)
+	t.Log("Only the unknown key was searched for")
+
+	// Simulate losing the last two keystores
+	for _, pubkey := range pubkeys[1:] {
+		err = os.Remove(filepath.Join(keystoreDir, "keystore-"+pubkey.HexWithPrefix()+".json"))
+		require.NoError(t, err)
+	}
+
+	// Recover them
+	response, err = apiClient.Wallet.RecoverAll(0, 5, 0, 2, false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []beacon.ValidatorPubkey{pubkeys[1], pubkeys[2], unknownPubkey}, response.Data.MissingPubkeys)
+	require.Equal(t, uint64(5), response.Data.Progress.GapLimit)
+	progress = waitForKeyRecovery(t)
+	require.Equal(t, swapi.KeyRecoveryState_Complete, progress.State)
+	require.Equal(t, getExpectedRecoveredKeys()[1:], progress.Keys)
+	t.Logf("Recovered %d keys", len(progress.Keys))
+
+	// The search should have stopped once the gap limit was passed after the last key
+	require.Equal(t, 1, progress.KeysToFind)
+	require.Equal(t, uint64(7), progress.GapEnd)
+	require.Equal(t, uint64(8), progress.NextIndex)
+	for _, pubkey := range pubkeys[1:] {
+		_, err = os.Stat(filepath.Join(keystoreDir, "keystore-"+pubkey.HexWithPrefix()+".json"))
+		require.NoError(t, err)
+	}
+	t.Log("Search stopped at the gap limit")
+}
+
+// Poll the key recovery status until it isn't running anymore
+func waitForKeyRecovery(t *testing.T) swapi.KeyRecoveryProgress {
+	apiClient := mainNode.GetApiClient()
+	for i := 0; i < 100; i++ {
+		response, err := apiClient.Wallet.RecoverKeysStatus()
+		require.NoError(t, err)
+		if response.Data.Progress.State != swapi.KeyRecoveryState_Running {
+			return response.Data.Progress
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Fail(t, "key recovery didn't finish in time")
+	return swapi.KeyRecoveryProgress{}
+}
+
+// Get the recovered keys expected for the test keys, which were generated in order from the first index
+func getExpectedRecoveredKeys() []swapi.RecoveredKey {
+	keys := make([]swapi.RecoveredKey, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		keys[i] = swapi.RecoveredKey{
+			Pubkey: pubkey,
+			Index:  uint64(i),
+		}
+	}
+	return keys
+}
+
+func TestSaveRecoveredKey_ConcurrentGeneration(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	wallet := sp.GetWallet()
+	ctx := context.Background()
+
+	// Recover a key far ahead of the next index while new keys are generated
+	recoveredIndex := uint64(50)
+	recoveredKeys, err := wallet.DeriveValidatorKeys(ctx, recoveredIndex, 1, 1)
+	require.NoError(t, err)
+	recovered := beacon.ValidatorPubkey(recoveredKeys[0].PublicKey().Marshal())
+	generatorCount := 4
+	keysPerGenerator := 2
+	generated := make([][]beacon.ValidatorPubkey, generatorCount)
+	errs := make([]error, generatorCount+1)
+	var wg sync.WaitGroup
+	for i := 0; i < generatorCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < keysPerGenerator; j++ {
+				key, err := wallet.GenerateNewValidatorKey(common.Address{})
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				generated[i] = append(generated[i], beacon.ValidatorPubkey(key.PublicKey().Marshal()))
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs[generatorCount] = wallet.SaveRecoveredKey(recoveredKeys[0], recoveredIndex)
+	}()
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Every generated key should have its own index, and none of them should reuse the recovered one
+	seen := map[beacon.ValidatorPubkey]bool{}
+	for _, pubkey := range pubkeys {
+		seen[pubkey] = true
+	}
+	seen[recovered] = true
+	for _, keys := range generated {
+		for _, pubkey := range keys {
+			require.False(t, seen[pubkey], "key %s was generated more than once", pubkey.HexWithPrefix())
+			seen[pubkey] = true
+		}
+	}
+	t.Logf("Generated %d unique keys while recovering the key at index %d", generatorCount*keysPerGenerator, recoveredIndex)
+
+	// The next key should come after all of them
+	key, err := wallet.GenerateNewValidatorKey(common.Address{})
+	require.NoError(t, err)
+	require.False(t, seen[beacon.ValidatorPubkey(key.PublicKey().Marshal())])
+}
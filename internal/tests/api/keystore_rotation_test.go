@@ -0,0 +1,166 @@
+package api_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateKeystorePassword(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	wallet := sp.GetWallet()
+	apiClient := mainNode.GetApiClient()
+	validatorPath := filepath.Join(sp.GetModuleDir(), hdconfig.ValidatorsDirectory)
+	passwordPath := filepath.Join(validatorPath, swconfig.ModuleName, swconfig.KeystorePasswordFile)
+	lighthouseSecretPath := filepath.Join(validatorPath, "lighthouse", "secrets", pubkeys[0].HexWithPrefix())
+
+	// Get the original passwords
+	oldPassword, err := os.ReadFile(passwordPath)
+	require.NoError(t, err)
+	oldLighthouseSecret, err := os.ReadFile(lighthouseSecretPath)
+	require.NoError(t, err)
+
+	// Rotate the password
+	response, err := apiClient.Wallet.RotateKeystorePassword(true, false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, pubkeys, response.Data.Pubkeys)
+	require.Equal(t, len(pubkeys)*4+1, response.Data.VcKeystoreCount)
+	require.False(t, response.Data.VcRestarted)
+	t.Logf("Rotated the keystore password for %d keys and %d VC keystores", len(response.Data.Pubkeys), response.Data.VcKeystoreCount)
+
+	// The passwords should have changed
+	newPassword, err := os.ReadFile(passwordPath)
+	require.NoError(t, err)
+	require.NotEqual(t, oldPassword, newPassword)
+	newLighthouseSecret, err := os.ReadFile(lighthouseSecretPath)
+	require.NoError(t, err)
+	require.NotEqual(t, oldLighthouseSecret, newLighthouseSecret)
+
+	// The keys should still load from every store
+	managers := map[string]keystore.IKeystoreManager{
+		"lighthouse": keystore.NewLighthouseKeystoreManager(validatorPath),
+		"lodestar":   keystore.NewLodestarKeystoreManager(validatorPath),
+		"nimbus":     keystore.NewNimbusKeystoreManager(validatorPath),
+		"prysm":      keystore.NewPrysmKeystoreManager(validatorPath),
+		"teku":       keystore.NewTekuKeystoreManager(validatorPath),
+	}
+	for _, pubkey := range pubkeys {
+		key, err := wallet.GetPrivateKeyForPubkey(pubkey)
+		require.NoError(t, err)
+		require.NotNil(t, key)
+		require.Equal(t, pubkey, beacon.ValidatorPubkey(key.PublicKey().Marshal()))
+		for name, mgr := range managers {
+			key, err := mgr.LoadValidatorKey(pubkey)
+			require.NoError(t, err, "loading key from %s", name)
+			require.Equal(t, pubkey, beacon.ValidatorPubkey(key.PublicKey().Marshal()))
+		}
+	}
+	t.Log("All keys could be decrypted with the new passwords")
+
+	// Nothing should be left staged
+	_, err = os.Stat(filepath.Join(sp.GetModuleDir(), swconfig.KeystoreRotationFile))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	keystorePath := filepath.Join(validatorPath, swconfig.ModuleName, "keystore-"+pubkeys[0].HexWithPrefix()+".json")
+	_, err = os.Stat(keystorePath + ".rotating")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Staged files from a rotation that never committed should be discarded on reload, leaving the keystores alone
+	err = os.WriteFile(keystorePath+".rotating", []byte("partial"), 0600)
+	require.NoError(t, err)
+	err = os.WriteFile(passwordPath+".rotating", []byte("uncommitted"), 0600)
+	require.NoError(t, err)
+	err = wallet.Reload()
+	require.NoError(t, err)
+	_, err = os.Stat(keystorePath + ".rotating")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(passwordPath + ".rotating")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	key, err := wallet.GetPrivateKeyForPubkey(pubkeys[0])
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	t.Log("Uncommitted staged files were discarded")
+
+	// A committed rotation should be finished on reload
+	err = os.WriteFile(passwordPath+".rotating", []byte("committed"), 0600)
+	require.NoError(t, err)
+	journal := []byte(`{"files":[{"target":"` + passwordPath + `","staged":"` + passwordPath + `.rotating"}]}`)
+	err = os.WriteFile(filepath.Join(sp.GetModuleDir(), swconfig.KeystoreRotationFile), journal, 0600)
+	require.NoError(t, err)
+	err = wallet.Reload()
+	require.NoError(t, err)
+	password, err := os.ReadFile(passwordPath)
+	require.NoError(t, err)
+	require.Equal(t, "committed", string(password))
+	_, err = os.Stat(filepath.Join(sp.GetModuleDir(), swconfig.KeystoreRotationFile))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	t.Log("Committed rotation was finished")
+}
+
+func TestRotateKeystorePassword_ConcurrentKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	wallet := sp.GetWallet()
+	validatorPath := filepath.Join(sp.GetModuleDir(), hdconfig.ValidatorsDirectory)
+
+	// Rotate the password while new keys are saved and existing ones are loaded
+	var wg sync.WaitGroup
+	var rotateErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, rotateErr = wallet.RotateKeystorePassword(true)
+	}()
+	for i := 0; i < 2; i++ {
+		_, err := wallet.GenerateNewValidatorKey(common.Address{})
+		require.NoError(t, err)
+		_, err = wallet.GetPrivateKeyForPubkey(pubkeys[0])
+		require.NoError(t, err)
+	}
+	wg.Wait()
+	require.NoError(t, rotateErr)
+	t.Log("Rotated the keystore password while keys were being saved")
+
+	// Every key should load with the current passwords, no matter which keys were saved before the rotation swapped them in
+	storedPubkeys, err := wallet.GetStoredPubkeys()
+	require.NoError(t, err)
+	require.Len(t, storedPubkeys, len(pubkeys)+2)
+	managers := map[string]keystore.IKeystoreManager{
+		"lighthouse": keystore.NewLighthouseKeystoreManager(validatorPath),
+		"lodestar":   keystore.NewLodestarKeystoreManager(validatorPath),
+		"nimbus":     keystore.NewNimbusKeystoreManager(validatorPath),
+		"prysm":      keystore.NewPrysmKeystoreManager(validatorPath),
+		"teku":       keystore.NewTekuKeystoreManager(validatorPath),
+	}
+	for _, pubkey := range storedPubkeys {
+		key, err := wallet.GetPrivateKeyForPubkey(pubkey)
+		require.NoError(t, err)
+		require.Equal(t, pubkey, beacon.ValidatorPubkey(key.PublicKey().Marshal()))
+		for name, mgr := range managers {
+			key, err := mgr.LoadValidatorKey(pubkey)
+			require.NoError(t, err, "loading key from %s", name)
+			require.Equal(t, pubkey, beacon.ValidatorPubkey(key.PublicKey().Marshal()))
+		}
+	}
+	t.Log("All keys could be decrypted with the current passwords")
+}
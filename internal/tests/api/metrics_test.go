@@ -0,0 +1,89 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/nodeset-org/hyperdrive-stakewise/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	keyMgr := sp.GetAvailableKeyManager()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Start the metrics server on a random port
+	wg := &sync.WaitGroup{}
+	server := metrics.NewMetricsServer(sp, "localhost", 0)
+	err = server.Start(wg)
+	require.NoError(t, err)
+	defer func() {
+		_ = server.Stop()
+		wg.Wait()
+	}()
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	vault.MaxValidatorsPerUser = 1
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+
+	// Scan the keys and run the relay so there's something to report
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 1)
+
+	// Get the metrics
+	url := fmt.Sprintf("http://localhost:%d%s", server.GetPort(), metrics.MetricsPath)
+	httpResp, err := http.Get(url)
+	require.NoError(t, err)
+	defer httpResp.Body.Close()
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	bytes, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+	body := string(bytes)
+
+	// Make sure the relay, key, and scan series are all there
+	require.Contains(t, body, `stakewise_relay_requests_total{code="200",path="/validators"}`)
+	require.Contains(t, body, `stakewise_relay_request_duration_seconds_count{code="200",path="/validators"}`)
+	require.Contains(t, body, "stakewise_keys_available ")
+	require.Contains(t, body, `stakewise_keys_ineligible{reason="no_private_key"} 0`)
+	lastScannedBlock := uint64(0)
+	if nextBlock := keyMgr.GetNextBlockToScan(); nextBlock > 0 {
+		lastScannedBlock = nextBlock - 1
+	}
+	require.Contains(t, body, fmt.Sprintf("stakewise_keys_last_scanned_block %d", lastScannedBlock))
+	require.Contains(t, body, "stakewise_keys_lookback_scan_duration_seconds_count ")
+	t.Log("Metrics endpoint served the relay, key, and scan series")
+}
@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletMoveKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	keyMgr := sp.GetAvailableKeyManager()
+	apiClient := mainNode.GetApiClient()
+	keys := []beacon.ValidatorPubkey{pubkeys[0]}
+
+	// Reserve a key for the primary vault
+	_, err = apiClient.Wallet.MoveKeys(keys, &res.Vault)
+	require.NoError(t, err)
+	require.Equal(t, 1, keyMgr.GetPoolCounts()[res.Vault])
+	t.Log("Key was reserved for the primary vault")
+
+	// Move it back to the unreserved pool
+	_, err = apiClient.Wallet.MoveKeys(keys, nil)
+	require.NoError(t, err)
+	require.Zero(t, keyMgr.GetPoolCounts()[res.Vault])
+	t.Log("Key was moved back to the unreserved pool")
+}
+
+func TestWalletMoveKeys_InvalidVault(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	keyMgr := sp.GetAvailableKeyManager()
+	apiClient := mainNode.GetApiClient()
+	keys := []beacon.ValidatorPubkey{pubkeys[0]}
+	counts := keyMgr.GetPoolCounts()
+
+	// Keys can't be reserved for a vault NodeSet doesn't know about
+	unknownVault := common.HexToAddress("0x000000000000000000000000000000000badbeef")
+	_, err = apiClient.Wallet.MoveKeys(keys, &unknownVault)
+	require.ErrorContains(t, err, "is not a known vault")
+	require.Equal(t, counts, keyMgr.GetPoolCounts())
+	t.Log("Moving keys to an unknown vault was rejected")
+
+	// Or for a vault that's disabled on this node
+	oldVaults := res.Vaults
+	defer func() {
+		res.Vaults = oldVaults
+	}()
+	res.Vaults = []*swconfig.StakeWiseVault{
+		{Enabled: false, Address: res.Vault},
+	}
+	_, err = apiClient.Wallet.MoveKeys(keys, &res.Vault)
+	require.ErrorContains(t, err, "is disabled on this node")
+	require.Equal(t, counts, keyMgr.GetPoolCounts())
+	t.Log("Moving keys to a disabled vault was rejected")
+}
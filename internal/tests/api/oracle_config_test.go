@@ -0,0 +1,170 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/goccy/go-json"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	swtasks "github.com/nodeset-org/hyperdrive-stakewise/tasks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOracleConfig(t *testing.T) {
+	err := testMgr.RevertSnapshot(initSnapshot)
+	if err != nil {
+		fail("Error reverting to initial snapshot: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	oracleMgr := sp.GetOracleConfigManager()
+	ctx := context.Background()
+	task := swtasks.NewUpdateOracleConfigTask(ctx, sp, sp.GetTasksLogger())
+
+	// Use a short confirmation depth so the test chain has settled blocks
+	confirmationDepth := uint64(2)
+	oldDepth := cfg.ScanConfirmationDepth.Value
+	defer func() {
+		cfg.ScanConfirmationDepth.Value = oldDepth
+	}()
+	cfg.ScanConfirmationDepth.Value = confirmationDepth
+
+	// Mock the Keeper
+	keeper := common.HexToAddress("0x6ee9e900000000000000000000000000000000aa")
+	oldKeeper := res.Keeper
+	oldKeeperGenesisBlock := res.KeeperGenesisBlock
+	defer func() {
+		res.Keeper = oldKeeper
+		res.KeeperGenesisBlock = oldKeeperGenesisBlock
+	}()
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	res.Keeper = keeper
+	res.KeeperGenesisBlock = new(big.Int).SetUint64(currentBlock)
+	err = oracleMgr.Reload()
+	require.NoError(t, err)
+	err = testMgr.SetMockContract(keeper, map[string][]byte{
+		"totalOracles()":         packAbiValue(t, "uint256", big.NewInt(1)),
+		"rewardsMinOracles()":    packAbiValue(t, "uint256", big.NewInt(6)),
+		"validatorsMinOracles()": packAbiValue(t, "uint256", big.NewInt(7)),
+	})
+	require.NoError(t, err)
+	for i := uint64(0); i <= confirmationDepth; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+
+	// Run the first scan - it shouldn't send any alerts
+	err = task.Run()
+	require.NoError(t, err)
+	require.True(t, oracleMgr.IsScanned())
+	config, _ := oracleMgr.GetOracleConfig()
+	require.Empty(t, config.Oracles)
+	require.Equal(t, uint64(6), config.RewardsMinOracles)
+	require.Equal(t, uint64(7), config.ValidatorsMinOracles)
+	t.Log("First scan loaded the Keeper's thresholds")
+
+	// Add two oracles, remove one, and publish a new config
+	oracleA := common.HexToAddress("0x0a")
+	oracleB := common.HexToAddress("0x0b")
+	oracleAddedId := crypto.Keccak256Hash([]byte("OracleAdded(address)"))
+	oracleRemovedId := crypto.Keccak256Hash([]byte("OracleRemoved(address)"))
+	configUpdatedId := crypto.Keccak256Hash([]byte("ConfigUpdated(string)"))
+	ipfsHash := "bafkreidivzimqfqtoqxkrpge6bjyhlvxqs3rhe73owtmdulaxr5do5in7u"
+	err = testMgr.EmitMockEvent(keeper, []common.Hash{oracleAddedId, common.BytesToHash(oracleA.Bytes())}, nil)
+	require.NoError(t, err)
+	err = testMgr.EmitMockEvent(keeper, []common.Hash{oracleAddedId, common.BytesToHash(oracleB.Bytes())}, nil)
+	require.NoError(t, err)
+	err = testMgr.EmitMockEvent(keeper, []common.Hash{oracleRemovedId, common.BytesToHash(oracleA.Bytes())}, nil)
+	require.NoError(t, err)
+	err = testMgr.EmitMockEvent(keeper, []common.Hash{configUpdatedId}, packAbiValue(t, "string", ipfsHash))
+	require.NoError(t, err)
+	for i := uint64(0); i < confirmationDepth; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+
+	// Sync again and check the changes
+	err = task.Run()
+	require.NoError(t, err)
+	config, changes := oracleMgr.GetOracleConfig()
+	require.Equal(t, keeper, config.Keeper)
+	require.Equal(t, []common.Address{oracleB}, config.Oracles)
+	require.Equal(t, ipfsHash, config.ConfigIpfsHash)
+	require.Equal(t, uint64(1), config.TotalOracles)
+	changeTypes := []swapi.OracleConfigChangeType{}
+	for _, change := range changes {
+		changeTypes = append(changeTypes, change.Type)
+	}
+	require.Equal(t, []swapi.OracleConfigChangeType{
+		swapi.OracleConfigChangeType_ThresholdsUpdated,
+		swapi.OracleConfigChangeType_OracleAdded,
+		swapi.OracleConfigChangeType_OracleAdded,
+		swapi.OracleConfigChangeType_OracleRemoved,
+		swapi.OracleConfigChangeType_ConfigUpdated,
+	}, changeTypes)
+	t.Log("Sync applied the oracle set changes and the new config")
+
+	// Check the route
+	response, err := apiClient.Network.OracleConfig()
+	require.NoError(t, err)
+	require.False(t, response.Data.NotScanned)
+	require.Equal(t, config, response.Data.Config)
+	require.Len(t, response.Data.Changes, len(changes))
+	t.Log("Route returned the synced config")
+
+	// Each change should have been sent as an alert
+	var alerts []swcommon.Alert
+	require.Eventually(t, func() bool {
+		alerts = getSentAlerts(t, swcommon.AlertType_OracleConfig)
+		return len(alerts) == 4
+	}, 5*time.Second, 100*time.Millisecond)
+	titles := map[string]int{}
+	for _, alert := range alerts {
+		titles[alert.Title]++
+	}
+	require.Equal(t, 2, titles["Oracle added to the StakeWise Keeper"])
+	require.Equal(t, 1, titles["Oracle removed from the StakeWise Keeper"])
+	require.Equal(t, 1, titles["StakeWise Keeper config updated"])
+	t.Log("Alerts were sent for each oracle set change and the config update")
+}
+
+// Get the alerts of the provided type that have been written to the alerts file
+func getSentAlerts(t *testing.T, alertType swcommon.AlertType) []swcommon.Alert {
+	path := filepath.Join(mainNode.GetServiceProvider().GetModuleDir(), swconfig.AlertsFile)
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer file.Close()
+
+	alerts := []swcommon.Alert{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var alert swcommon.Alert
+		err = json.Unmarshal(scanner.Bytes(), &alert)
+		require.NoError(t, err)
+		if alert.Type == alertType {
+			alerts = append(alerts, alert)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return alerts
+}
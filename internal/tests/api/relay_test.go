@@ -3,11 +3,16 @@ package api_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/nodeset-org/hyperdrive-stakewise/relay"
+	swtesting "github.com/nodeset-org/hyperdrive-stakewise/testing"
 	"github.com/nodeset-org/osha/beacon/db"
 	batchquery "github.com/rocket-pool/batch-query"
 	"github.com/rocket-pool/node-manager-core/beacon"
@@ -36,7 +41,7 @@ func GenerateTestKeys(t *testing.T) {
 	// Generate three validator keys
 	pubkeys = make([]beacon.ValidatorPubkey, 3)
 	for i := 0; i < 3; i++ {
-		key, err := wallet.GenerateNewValidatorKey()
+		key, err := wallet.GenerateNewValidatorKey(common.Address{})
 		require.NoError(t, err)
 		pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
 		pubkeys[i] = pubkey
@@ -646,7 +651,471 @@ func TestRelay_KeysAlreadyExist(t *testing.T) {
 	t.Log("No keys were submitted for deposits as expected")
 }
 
+func TestRelay_SecondVault(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	op := testMgr.GetOperatorMock()
+	keyMgr := sp.GetAvailableKeyManager()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Add a second vault to NodeSet
+	secondVaultAddress := common.HexToAddress("0x5afe00000000000000000000000000000000beef")
+	secondVault := deployment.AddVault(VaultName+"-2", secondVaultAddress)
+	secondVault.MaxValidatorsPerUser = 2
+	op.SetVault(secondVaultAddress)
+	defer op.SetVault(res.Vault)
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+
+	// Get the current block number
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	t.Logf("Current block number: %d", currentBlock)
+
+	// Initialize the key manager
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+
+	// Run the relay against the second vault
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 2)
+	for i := 0; i < 2; i++ {
+		require.Equal(t, resp.Validators[i].PublicKey, pubkeys[i])
+		_, exists := secondVault.Validators[mainNodeAddress][pubkeys[i]]
+		require.True(t, exists)
+	}
+	t.Log("Keys were submitted for deposits into the second vault as expected")
+}
+
+func TestRelay_UnknownVault(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	op := testMgr.GetOperatorMock()
+
+	// Request validators for a vault that NodeSet doesn't know about
+	op.SetVault(common.HexToAddress("0x000000000000000000000000000000000badbeef"))
+	defer op.SetVault(res.Vault)
+	_, err = op.SubmitValidatorsRequest()
+	require.Error(t, err)
+	t.Logf("Request for an unknown vault was rejected as expected: %v", err)
+}
+
+func TestRelay_ReservedKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	keyMgr := sp.GetAvailableKeyManager()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Reserve the first key for another vault and the last key for the primary vault
+	otherVault := common.HexToAddress("0x5afe00000000000000000000000000000000beef")
+	require.NoError(t, keyMgr.MoveKeys([]beacon.ValidatorPubkey{pubkeys[0]}, otherVault))
+	require.NoError(t, keyMgr.MoveKeys([]beacon.ValidatorPubkey{pubkeys[2]}, res.Vault))
+	counts := keyMgr.GetPoolCounts()
+	require.Equal(t, 1, counts[otherVault])
+	require.Equal(t, 1, counts[res.Vault])
+	require.Equal(t, 1, counts[common.Address{}])
+	vault.MaxValidatorsPerUser = 3
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+
+	// Get the current block number
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	t.Logf("Current block number: %d", currentBlock)
+
+	// Initialize the key manager
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+
+	// Run the relay - the reserved key should come first, and the key for the other vault should be skipped
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 2)
+	require.Equal(t, resp.Validators[0].PublicKey, pubkeys[2])
+	require.Equal(t, resp.Validators[1].PublicKey, pubkeys[1])
+	t.Log("Keys were submitted in pool order, and the key reserved for another vault was skipped")
+}
+
 // Perform a deposit to the Beacon deposit contract
+func TestRelay_AuditHistory(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	keyMgr := sp.GetAvailableKeyManager()
+	logger := testMgr.GetLogger()
+	apiClient := mainNode.GetApiClient()
+	ctx := context.Background()
+
+	// Set the max validators per node to 1
+	vault.MaxValidatorsPerUser = 1
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+
+	// Initialize the key manager
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+
+	// Run the relay once successfully and once for an unknown vault
+	start := time.Now().UTC().Add(-time.Second)
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 1)
+	unknownVault := common.HexToAddress("0x000000000000000000000000000000000badbeef")
+	op.SetVault(unknownVault)
+	defer op.SetVault(res.Vault)
+	_, err = op.SubmitValidatorsRequest()
+	require.Error(t, err)
+
+	// Make sure both requests were recorded
+	historyResponse, err := apiClient.Service.RelayHistory(&start, nil, nil, nil)
+	require.NoError(t, err)
+	records := historyResponse.Data.Records
+	require.GreaterOrEqual(t, len(records), 2)
+
+	success := records[len(records)-2]
+	require.Equal(t, res.Vault, success.Vault)
+	require.Equal(t, http.StatusOK, success.StatusCode)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkeys[0]}, success.Keys)
+	require.NotNil(t, success.DepositRoot)
+	require.Equal(t, resp.ValidatorsManagerSignature, success.Signature)
+	require.Empty(t, success.Error)
+	require.NotEmpty(t, success.Request)
+	t.Log("Successful request was recorded as expected")
+
+	failure := records[len(records)-1]
+	require.Equal(t, unknownVault, failure.Vault)
+	require.Equal(t, http.StatusUnprocessableEntity, failure.StatusCode)
+	require.Empty(t, failure.Keys)
+	require.NotEmpty(t, failure.Error)
+	t.Log("Failed request was recorded as expected")
+
+	// Check the vault and time filters
+	historyResponse, err = apiClient.Service.RelayHistory(&start, nil, &unknownVault, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, historyResponse.Data.Records)
+	for _, record := range historyResponse.Data.Records {
+		require.Equal(t, unknownVault, record.Vault)
+	}
+	end := start
+	historyResponse, err = apiClient.Service.RelayHistory(nil, &end, &unknownVault, nil)
+	require.NoError(t, err)
+	require.Empty(t, historyResponse.Data.Records)
+	limit := uint64(1)
+	historyResponse, err = apiClient.Service.RelayHistory(&start, nil, nil, &limit)
+	require.NoError(t, err)
+	require.Len(t, historyResponse.Data.Records, 1)
+	require.Equal(t, failure.Vault, historyResponse.Data.Records[0].Vault)
+	require.Equal(t, failure.StatusCode, historyResponse.Data.Records[0].StatusCode)
+	t.Log("Relay history filters and limit worked as expected")
+}
+
+func TestRelay_ConcurrentRequests(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	keyMgr := sp.GetAvailableKeyManager()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Set the max validators per node to 3
+	vault.MaxValidatorsPerUser = 3
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+
+	// Initialize the key manager
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+
+	// Run several requests for the same vault at once
+	requestCount := 3
+	responses := make([]*relay.ValidatorsResponse, requestCount)
+	errs := make([]error, requestCount)
+	var wg sync.WaitGroup
+	for i := 0; i < requestCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i], errs[i] = op.SubmitValidatorsRequest()
+		}(i)
+	}
+	wg.Wait()
+
+	// None of them should have been rejected, and each one that got keys should have gotten the same ones
+	var shared *relay.ValidatorsResponse
+	for i := 0; i < requestCount; i++ {
+		require.NoError(t, errs[i])
+		if len(responses[i].Validators) == 0 {
+			// This request came in after the others finished, so the keys had already been offered for this deposit root
+			continue
+		}
+		if shared == nil {
+			shared = responses[i]
+		}
+		require.Equal(t, shared, responses[i])
+	}
+	require.NotNil(t, shared)
+	require.Len(t, shared.Validators, 3)
+	for i, pubkey := range pubkeys {
+		require.Equal(t, pubkey, shared.Validators[i].PublicKey)
+	}
+	t.Log("Concurrent requests shared the same result as expected")
+}
+
+func TestRelay_ConcurrentRequests_DifferentIndices(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	auditLog := sp.GetRelayAuditLog()
+
+	// Make a second operator that starts at a different validator index
+	relayUrl := fmt.Sprintf("http://localhost:%d", mainNode.GetRelayServer().GetPort())
+	otherOp, err := swtesting.NewOperatorMock(relayUrl, res, 5)
+	require.NoError(t, err)
+	ops := []*swtesting.OperatorMock{testMgr.GetOperatorMock(), otherOp}
+
+	// Load the keys
+	vault.MaxValidatorsPerUser = 3
+	loadRelayKeys(t)
+
+	// Run a request from each operator at once
+	start := time.Now().UTC().Add(-time.Second)
+	responses := make([]*relay.ValidatorsResponse, len(ops))
+	errs := make([]error, len(ops))
+	var wg sync.WaitGroup
+	for i, op := range ops {
+		wg.Add(1)
+		go func(i int, op *swtesting.OperatorMock) {
+			defer wg.Done()
+			responses[i], errs[i] = op.SubmitValidatorsRequest()
+		}(i, op)
+	}
+	wg.Wait()
+	for i := range ops {
+		require.NoError(t, errs[i])
+	}
+
+	// Neither request should have been given the other's result, since the exits are for different validator indices
+	records, err := auditLog.GetRecords(&start, nil, &res.Vault, 0)
+	require.NoError(t, err)
+	require.Len(t, records, len(ops))
+	for _, record := range records {
+		require.NotContains(t, record.Timings, "sharedWait")
+	}
+	if len(responses[0].Validators) > 0 && len(responses[1].Validators) > 0 {
+		require.NotEqual(t, responses[0], responses[1])
+	}
+	t.Log("Requests with different start indices were processed separately")
+}
+
+func TestRelay_ConcurrentRequests_NoDuplicateKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+
+	// Make a second operator that sends a different request for the same vault
+	relayUrl := fmt.Sprintf("http://localhost:%d", mainNode.GetRelayServer().GetPort())
+	otherOp, err := swtesting.NewOperatorMock(relayUrl, res, 5)
+	require.NoError(t, err)
+	otherOp.SetTotal(swtesting.DefaultTotal + 1)
+	ops := []*swtesting.OperatorMock{testMgr.GetOperatorMock(), otherOp}
+
+	// Load the keys
+	vault.MaxValidatorsPerUser = 3
+	loadRelayKeys(t)
+
+	// Run a request from each operator at once
+	responses := make([]*relay.ValidatorsResponse, len(ops))
+	errs := make([]error, len(ops))
+	var wg sync.WaitGroup
+	for i, op := range ops {
+		wg.Add(1)
+		go func(i int, op *swtesting.OperatorMock) {
+			defer wg.Done()
+			responses[i], errs[i] = op.SubmitValidatorsRequest()
+		}(i, op)
+	}
+	wg.Wait()
+	for i := range ops {
+		require.NoError(t, errs[i])
+	}
+
+	// No key should have been given out twice
+	seen := map[beacon.ValidatorPubkey]bool{}
+	for _, response := range responses {
+		for _, validator := range response.Validators {
+			require.False(t, seen[validator.PublicKey], "key %s was returned twice", validator.PublicKey.HexWithPrefix())
+			seen[validator.PublicKey] = true
+		}
+	}
+	require.NotEmpty(t, seen)
+	t.Logf("Concurrent requests were given %d distinct keys", len(seen))
+}
+
+func TestRelay_PreparedKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	auditLog := sp.GetRelayAuditLog()
+	relayServer := mainNode.GetRelayServer()
+	ctx := context.Background()
+
+	// Load the keys, then let the relay prepare them in the background
+	vault.MaxValidatorsPerUser = 3
+	loadRelayKeys(t)
+	oldPrepare := cfg.RelayPrepareKeys.Value
+	defer func() {
+		cfg.RelayPrepareKeys.Value = oldPrepare
+	}()
+	cfg.RelayPrepareKeys.Value = true
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		block, prepared := relayServer.GetPreparedBlock(res.Vault)
+		return prepared && block == currentBlock
+	}, 30*time.Second, 500*time.Millisecond)
+	t.Logf("Relay prepared the keys for block %d", currentBlock)
+
+	// The request should use the prepared keys
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 3)
+	for i, pubkey := range pubkeys {
+		require.Equal(t, pubkey, resp.Validators[i].PublicKey)
+	}
+	records, err := auditLog.GetRecords(nil, nil, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Contains(t, records[0].Timings, "preparedKeys")
+	require.NotContains(t, records[0].Timings, "availableKeys")
+	t.Log("Relay answered the request with the prepared keys")
+}
+
 func deposit(key *eth2types.BLSPrivateKey, opts *bind.TransactOpts) (beacon.ExtendedDepositData, error) {
 	sp := mainNode.GetServiceProvider()
 	bdc := sp.GetBeaconDepositContract()
@@ -657,6 +1126,7 @@ func deposit(key *eth2types.BLSPrivateKey, opts *bind.TransactOpts) (beacon.Exte
 	depositDatas, err := swcommon.GenerateDepositData(
 		logger,
 		res,
+		res.Vault,
 		[]*eth2types.BLSPrivateKey{key},
 	)
 	if err != nil {
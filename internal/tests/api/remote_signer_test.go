@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swtesting "github.com/nodeset-org/hyperdrive-stakewise/testing"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/node/validator"
+	"github.com/stretchr/testify/require"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	bc := sp.GetBeaconClient()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Start a stand-in remote signer
+	signerMock := swtesting.NewWeb3SignerMock()
+	defer signerMock.Close()
+	signer := swcommon.NewWeb3Signer(sp, signerMock.GetUrl())
+
+	// Import a key into it
+	key, err := keygen.GetBlsPrivateKey(2000)
+	require.NoError(t, err)
+	pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+	err = signer.ImportKey(ctx, key, "m/12381/3600/2000/0/0")
+	require.NoError(t, err)
+	require.True(t, signerMock.HasKey(pubkey))
+	signerPubkeys, err := signer.GetPubkeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkey}, signerPubkeys)
+	t.Log("Key was imported into the remote signer")
+
+	// Importing it again shouldn't fail
+	err = signer.ImportKey(ctx, key, "m/12381/3600/2000/0/0")
+	require.NoError(t, err)
+
+	// Only the imported key should be loadable
+	loaded, err := signer.LoadKeys(ctx, []beacon.ValidatorPubkey{pubkey, pubkeys[0]})
+	require.NoError(t, err)
+	require.True(t, loaded[pubkey])
+	require.False(t, loaded[pubkeys[0]])
+
+	// Lighthouse should have a definition pointing at the signer
+	definitionsPath := filepath.Join(sp.GetModuleDir(), hdconfig.ValidatorsDirectory, "lighthouse", "validators", "validator_definitions.yml")
+	definitions, err := os.ReadFile(definitionsPath)
+	require.NoError(t, err)
+	require.Contains(t, string(definitions), pubkey.HexWithPrefix())
+	require.Contains(t, string(definitions), "web3signer")
+	require.Contains(t, string(definitions), signerMock.GetUrl())
+	t.Log("Lighthouse validator definitions were updated")
+
+	// Deposit data from the signer should match deposit data made with the key directly
+	settings, err := swcommon.GetVaultDepositSettings(res, res.Vault)
+	require.NoError(t, err)
+	signed, err := swcommon.SignDepositData(ctx, logger, signer, res, settings.WithdrawalCredentials, settings.Amount, []beacon.ValidatorPubkey{pubkey})
+	require.NoError(t, err)
+	generated, err := swcommon.GenerateDepositData(logger, res, res.Vault, []*eth2types.BLSPrivateKey{key})
+	require.NoError(t, err)
+	require.Equal(t, generated, signed)
+	t.Log("Deposit data signed by the remote signer matched locally generated deposit data")
+
+	// Exits from the signer should match exits signed with the key directly
+	signatureDomain, err := bc.GetDomainData(ctx, eth2types.DomainVoluntaryExit[:], res.CapellaForkEpoch, false)
+	require.NoError(t, err)
+	forkInfo, err := swcommon.GetExitForkInfo(ctx, sp)
+	require.NoError(t, err)
+	signature, err := swcommon.SignExitMessage(ctx, signer, forkInfo, pubkey, "42", res.CapellaForkEpoch, signatureDomain)
+	require.NoError(t, err)
+	expected, err := validator.GetSignedExitMessage(key, "42", res.CapellaForkEpoch, signatureDomain)
+	require.NoError(t, err)
+	require.Equal(t, expected, signature)
+	t.Log("Exit signed by the remote signer matched a locally signed exit")
+
+	// The signer should have been told what it was signing
+	requests := signerMock.GetSignRequests()
+	require.Len(t, requests, 2)
+	require.Equal(t, swcommon.SigningType_Deposit, requests[0].Type)
+	require.NotNil(t, requests[0].Deposit)
+	require.Equal(t, swcommon.SigningType_VoluntaryExit, requests[1].Type)
+	require.NotNil(t, requests[1].VoluntaryExit)
+	require.Equal(t, "42", requests[1].VoluntaryExit.ValidatorIndex)
+	require.NotNil(t, requests[1].ForkInfo)
+
+	// Signing with a key the signer doesn't have should fail
+	_, err = swcommon.SignDepositData(ctx, logger, signer, res, settings.WithdrawalCredentials, settings.Amount, []beacon.ValidatorPubkey{pubkeys[0]})
+	require.Error(t, err)
+	t.Logf("Signing with an unknown key failed as expected: %v", err)
+}
@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelay_ReorgDropsDeposit(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	keyMgr := sp.GetAvailableKeyManager()
+	lifecycleMgr := sp.GetKeyLifecycleManager()
+	hardhat := testMgr.GetHardhatRpcClient()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+	depositRoot := common.HexToHash("0x01")
+
+	// Use a short confirmation depth so the test chain has settled blocks
+	confirmationDepth := uint64(2)
+	oldDepth := cfg.ScanConfirmationDepth.Value
+	defer func() {
+		cfg.ScanConfirmationDepth.Value = oldDepth
+	}()
+	cfg.ScanConfirmationDepth.Value = confirmationDepth
+	for i := uint64(0); i <= confirmationDepth; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+
+	// Run the initial lookback scan
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	keyMgr.LoadPrivateKeys(logger)
+	goodKeys, _, err := keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, goodKeys, len(pubkeys))
+
+	// Mark the fork point
+	var forkSnapshot string
+	err = hardhat.Call(&forkSnapshot, "evm_snapshot")
+	require.NoError(t, err)
+	forkBlock := currentBlock
+
+	// Deposit key 0 - the deposit isn't settled yet, so the key should be blocked but kept
+	key, err := keygen.GetBlsPrivateKey(0)
+	require.NoError(t, err)
+	_, err = deposit(key, mainNodeOpts)
+	require.NoError(t, err)
+	currentBlock, err = sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	goodKeys, badKeys, err := keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, goodKeys, len(pubkeys)-1)
+	require.Len(t, badKeys, 1)
+	for badKey, reason := range badKeys {
+		require.Equal(t, pubkeys[0], badKey.PublicKey)
+		require.Equal(t, swcommon.IneligibleReason_HasDepositEvent, reason)
+	}
+	require.Contains(t, keyMgr.GetPubkeys(), pubkeys[0])
+	require.LessOrEqual(t, keyMgr.GetNextBlockToScan(), forkBlock+1)
+	lifecycles, _ := lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[0]})
+	require.Equal(t, swapi.KeyLifecycleState_Deposited, lifecycles[0].State)
+	t.Logf("Key 0 was deposited in unsettled block %d and blocked", currentBlock)
+
+	// Fork the chain from before the deposit and build a longer branch without it
+	err = hardhat.Call(nil, "evm_revert", forkSnapshot)
+	require.NoError(t, err)
+	for i := uint64(0); i <= currentBlock-forkBlock; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+	newBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	require.Greater(t, newBlock, currentBlock)
+	t.Logf("Reorged the chain from block %d, new head is %d", forkBlock, newBlock)
+
+	// The deposit is gone, so key 0 should be eligible again
+	goodKeys, badKeys, err = keyMgr.GetAvailableKeys(ctx, logger, depositRoot, newBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck: true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, badKeys)
+	require.Len(t, goodKeys, len(pubkeys))
+	eligible := false
+	for _, goodKey := range goodKeys {
+		if goodKey.PublicKey == pubkeys[0] {
+			eligible = true
+		}
+	}
+	require.True(t, eligible)
+	t.Log("Key 0 was eligible again after the deposit was reorged out")
+
+	// The key's lifecycle should have been rewound too
+	lifecycles, _ = lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[0]})
+	history := lifecycles[0].History
+	require.Equal(t, swapi.KeyLifecycleState_Scanned, lifecycles[0].State)
+	require.Equal(t, swapi.KeyLifecycleState_Deposited, history[len(history)-2].State)
+	require.Equal(t, swapi.KeyLifecycleState_Scanned, history[len(history)-1].State)
+	t.Log("Key 0's lifecycle was rewound from deposited to scanned")
+}
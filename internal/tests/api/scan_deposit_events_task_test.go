@@ -0,0 +1,89 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swtasks "github.com/nodeset-org/hyperdrive-stakewise/tasks"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanDepositEventsTask(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	keyMgr := sp.GetAvailableKeyManager()
+	lifecycleMgr := sp.GetKeyLifecycleManager()
+	ctx := context.Background()
+	task := swtasks.NewScanDepositEventsTask(ctx, sp, sp.GetTasksLogger())
+
+	// Use a short confirmation depth so the test chain has settled blocks
+	confirmationDepth := uint64(2)
+	oldDepth := cfg.ScanConfirmationDepth.Value
+	defer func() {
+		cfg.ScanConfirmationDepth.Value = oldDepth
+	}()
+	cfg.ScanConfirmationDepth.Value = confirmationDepth
+	for i := uint64(0); i <= confirmationDepth; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+
+	// The new keys should need a lookback scan
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	require.True(t, keyMgr.RequiresLookbackScan(currentBlock))
+
+	// Run the task - it should do the lookback scan and move the checkpoint up to the settled block
+	err = task.Run()
+	require.NoError(t, err)
+	require.False(t, keyMgr.RequiresLookbackScan(currentBlock))
+	require.Equal(t, currentBlock-confirmationDepth+1, keyMgr.GetNextBlockToScan())
+	lifecycles, _ := lifecycleMgr.GetLifecycles(pubkeys)
+	for _, lifecycle := range lifecycles {
+		require.Equal(t, swapi.KeyLifecycleState_Scanned, lifecycle.State)
+	}
+	t.Logf("Lookback scan finished in the background, next block to scan is %d", keyMgr.GetNextBlockToScan())
+
+	// New blocks should advance the checkpoint on the next run
+	for i := 0; i < 3; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+	currentBlock, err = sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	err = task.Run()
+	require.NoError(t, err)
+	require.Equal(t, currentBlock-confirmationDepth+1, keyMgr.GetNextBlockToScan())
+	t.Logf("Incremental scan advanced the next block to scan to %d", keyMgr.GetNextBlockToScan())
+
+	// Deposit key 0 and let the deposit settle - the task should remove it from the available keys
+	key, err := keygen.GetBlsPrivateKey(0)
+	require.NoError(t, err)
+	_, err = deposit(key, mainNodeOpts)
+	require.NoError(t, err)
+	for i := uint64(0); i < confirmationDepth; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+	err = task.Run()
+	require.NoError(t, err)
+	require.NotContains(t, keyMgr.GetPubkeys(), pubkeys[0])
+	lifecycles, _ = lifecycleMgr.GetLifecycles([]beacon.ValidatorPubkey{pubkeys[0]})
+	require.Equal(t, swapi.KeyLifecycleState_Deposited, lifecycles[0].State)
+	t.Log("Settled deposit was picked up by the background scan")
+
+	// None of this should have gone through the relay
+	records, err := sp.GetRelayAuditLog().GetRecords(nil, nil, nil, 0)
+	require.NoError(t, err)
+	require.Empty(t, records)
+	t.Log("The relay wasn't used for any of the scans")
+}
@@ -0,0 +1,126 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorExportExits(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	bnMock := testMgr.GetBeaconMockManager()
+	password := "archive_password123"
+	ctx := context.Background()
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+
+	// Make validators 0 and 1 active on Beacon
+	indices := map[beacon.ValidatorPubkey]string{}
+	for _, pubkey := range pubkeys[:2] {
+		bnValidator, err := bnMock.AddValidator(pubkey, common.Hash{})
+		require.NoError(t, err)
+		bnValidator.Status = beacon.ValidatorState_ActiveOngoing
+		status, err := sp.GetBeaconClient().GetValidatorStatus(ctx, pubkey, nil)
+		require.NoError(t, err)
+		indices[pubkey] = status.Index
+	}
+
+	// Export the exits
+	response, err := apiClient.Validator.ExportExits(password)
+	require.NoError(t, err)
+	require.ElementsMatch(t, pubkeys[:2], response.Data.Pubkeys)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkeys[2]}, response.Data.InactivePubkeys)
+	t.Log("Exits were exported for the active validators")
+
+	// Make sure the archive has the right exits in it
+	archive, err := swcommon.DecryptExitArchive(&response.Data.Archive, password)
+	require.NoError(t, err)
+	require.Equal(t, res.DeploymentName, archive.DeploymentName)
+	require.Len(t, archive.Exits, 2)
+	for _, exit := range archive.Exits {
+		require.Equal(t, indices[exit.Pubkey], exit.Index)
+		require.Equal(t, res.CapellaForkEpoch, exit.Epoch)
+		require.NotEqual(t, beacon.ValidatorSignature{}, exit.Signature)
+	}
+	t.Log("Archive decrypted and contained the signed exits")
+
+	// The archive shouldn't open with the wrong password
+	_, err = swcommon.DecryptExitArchive(&response.Data.Archive, "wrong_password123")
+	require.Error(t, err)
+	_, err = apiClient.Validator.BroadcastExits(response.Data.Archive, "wrong_password123", nil)
+	require.Error(t, err)
+	t.Log("Archive couldn't be opened with the wrong password as expected")
+}
+
+func TestValidatorExit_DryRun(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	apiClient := mainNode.GetApiClient()
+	bnMock := testMgr.GetBeaconMockManager()
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err = testMgr.CommitBlock()
+	if err != nil {
+		t.Fatalf("Error committing block: %v", err)
+	}
+
+	// Validator 0 is already exiting, validator 1 was only just activated, and validator 2 isn't on Beacon
+	bnValidator, err := bnMock.AddValidator(pubkeys[0], common.Hash{})
+	require.NoError(t, err)
+	bnValidator.Status = beacon.ValidatorState_ActiveExiting
+	bnValidator, err = bnMock.AddValidator(pubkeys[1], common.Hash{})
+	require.NoError(t, err)
+	bnValidator.Status = beacon.ValidatorState_ActiveOngoing
+
+	// Run the preflight checks
+	response, err := apiClient.Validator.Exit(pubkeys, nil, false, true)
+	require.NoError(t, err)
+	require.True(t, response.Data.DryRun)
+	require.Len(t, response.Data.ExitInfos, 3)
+	expectedErrors := []string{
+		"validator is already exiting",
+		"validator has not been active for the shard committee period",
+		"validator is not on the Beacon chain",
+	}
+	for i, info := range response.Data.ExitInfos {
+		require.Equal(t, pubkeys[i], info.Pubkey)
+		require.Len(t, info.PreflightErrors, 1)
+		require.Contains(t, info.PreflightErrors[0], expectedErrors[i])
+		require.False(t, info.Broadcast)
+		require.Equal(t, beacon.ValidatorSignature{}, info.Signature)
+		t.Logf("Validator %d failed the preflight checks as expected: %s", i, info.PreflightErrors[0])
+	}
+
+	// Broadcasting should skip all of them but still report each one
+	response, err = apiClient.Validator.Exit(pubkeys, nil, false, false)
+	require.NoError(t, err)
+	for _, info := range response.Data.ExitInfos {
+		require.False(t, info.Broadcast)
+		require.NotEmpty(t, info.Error)
+	}
+	t.Log("No exits were broadcast for validators that failed the preflight checks")
+}
@@ -0,0 +1,156 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorPerformance(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	apiClient := mainNode.GetApiClient()
+	store := sp.GetValidatorSnapshotStore()
+
+	// Record a few epochs of history: key 0 misses an attestation in the last epoch, and key 1 gets its excess balance swept in the second
+	start := time.Now().UTC().Add(-3 * time.Hour)
+	balances := [][]uint64{
+		{32e9, 32.05e9},
+		{32e9 + 1e4, 32e9 + 1e4},
+		{32e9 + 5e3, 32e9 + 2e4},
+	}
+	for i, epochBalances := range balances {
+		snapshot := swcommon.ValidatorSnapshot{
+			Epoch: uint64(100 + i),
+			Time:  start.Add(time.Duration(i) * time.Hour),
+		}
+		for j, balance := range epochBalances {
+			snapshot.Validators = append(snapshot.Validators, swcommon.ValidatorSnapshotEntry{
+				Pubkey:           pubkeys[j],
+				Index:            "1",
+				Status:           beacon.ValidatorState_ActiveOngoing,
+				Balance:          balance,
+				EffectiveBalance: 32e9,
+			})
+		}
+		err = store.AddSnapshot(snapshot)
+		require.NoError(t, err)
+	}
+	t.Log("Recorded validator snapshots")
+
+	// Get the performance by snapshot interval
+	response, err := apiClient.Validator.Performance(pubkeys, swapi.PerformancePeriod_Interval, 1)
+	require.NoError(t, err)
+	require.Equal(t, []beacon.ValidatorPubkey{pubkeys[2]}, response.Data.UnknownPubkeys)
+	require.Len(t, response.Data.Validators, 2)
+
+	// Key 0 should have one missed attestation
+	perf := response.Data.Validators[0]
+	require.Equal(t, pubkeys[0], perf.Pubkey)
+	require.Equal(t, 3, perf.Snapshots)
+	require.Equal(t, int64(5e3), perf.BalanceDelta)
+	require.Equal(t, int64(5e3), perf.Rewards)
+	require.Equal(t, uint64(0), perf.Withdrawn)
+	require.Equal(t, 2, perf.ActiveIntervals)
+	require.Equal(t, 1, perf.Decreases)
+	require.Equal(t, uint64(102), perf.LastDecreaseEpoch)
+	require.InDelta(t, 50.0, perf.Effectiveness, 0.001)
+	require.Len(t, perf.PeriodRewards, 2)
+	require.Equal(t, int64(1e4), perf.PeriodRewards[0].Rewards)
+	require.Equal(t, int64(-5e3), perf.PeriodRewards[1].Rewards)
+	require.Equal(t, 1, perf.PeriodRewards[1].Decreases)
+	t.Log("Key 0 had one missed attestation as expected")
+
+	// Key 1's sweep shouldn't count as a penalty
+	perf = response.Data.Validators[1]
+	require.Equal(t, pubkeys[1], perf.Pubkey)
+	require.Equal(t, int64(2e4)-int64(5e7), perf.BalanceDelta)
+	require.Equal(t, uint64(5e7), perf.Withdrawn)
+	require.Equal(t, int64(2e4), perf.Rewards)
+	require.Equal(t, 0, perf.Decreases)
+	require.InDelta(t, 100.0, perf.Effectiveness, 0.001)
+	t.Log("Key 1's withdrawal sweep was counted as a withdrawal instead of a penalty")
+
+	// Daily rewards should add up to the same total
+	response, err = apiClient.Validator.Performance(nil, swapi.PerformancePeriod_Day, 1)
+	require.NoError(t, err)
+	require.Empty(t, response.Data.UnknownPubkeys)
+	require.Len(t, response.Data.Validators, 2)
+	for _, perf := range response.Data.Validators {
+		var total int64
+		for _, period := range perf.PeriodRewards {
+			total += period.Rewards
+		}
+		require.Equal(t, perf.Rewards, total)
+	}
+	t.Log("Daily rewards matched the total rewards")
+}
+
+func TestValidatorPerformance_Compounding(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	store := sp.GetValidatorSnapshotStore()
+
+	// Record a compounding validator above 32 ETH that loses balance, and one above 2048 ETH that gets its excess swept
+	compoundingCreds := swcommon.GetWithdrawalCreds(swconfig.WithdrawalCredentialType_Compounding, res.Vault)
+	start := time.Now().UTC().Add(-2 * time.Hour)
+	balances := [][]uint64{
+		{64e9, 2048.05e9},
+		{64e9 - 5e6, 2048e9 + 1e4},
+	}
+	for i, epochBalances := range balances {
+		snapshot := swcommon.ValidatorSnapshot{
+			Epoch: uint64(100 + i),
+			Time:  start.Add(time.Duration(i) * time.Hour),
+		}
+		for j, balance := range epochBalances {
+			snapshot.Validators = append(snapshot.Validators, swcommon.ValidatorSnapshotEntry{
+				Pubkey:                pubkeys[j],
+				Index:                 "1",
+				Status:                beacon.ValidatorState_ActiveOngoing,
+				Balance:               balance,
+				EffectiveBalance:      64e9,
+				WithdrawalCredentials: compoundingCreds,
+			})
+		}
+		err = store.AddSnapshot(snapshot)
+		require.NoError(t, err)
+	}
+
+	response, err := apiClient.Validator.Performance(pubkeys[:2], swapi.PerformancePeriod_Interval, 1)
+	require.NoError(t, err)
+	require.Len(t, response.Data.Validators, 2)
+
+	// Key 0's drop is below the 2048 ETH max effective balance, so it's a penalty
+	perf := response.Data.Validators[0]
+	require.Equal(t, int64(-5e6), perf.Rewards)
+	require.Equal(t, uint64(0), perf.Withdrawn)
+	require.Equal(t, 1, perf.Decreases)
+	t.Log("Compounding validator's balance drop above 32 ETH was counted as a penalty")
+
+	// Key 1's drop is a sweep of its balance above 2048 ETH
+	perf = response.Data.Validators[1]
+	require.Equal(t, int64(1e4), perf.Rewards)
+	require.Equal(t, uint64(5e7), perf.Withdrawn)
+	require.Equal(t, 0, perf.Decreases)
+	t.Log("Compounding validator's balance above 2048 ETH was counted as a withdrawal sweep")
+}
@@ -0,0 +1,200 @@
+package api_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	batchquery "github.com/rocket-pool/batch-query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelay_ValidatorsRoot(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	auditLog := sp.GetRelayAuditLog()
+
+	// Turn on the verification and load the keys
+	oldVerify := cfg.VerifyDepositsRoot.Value
+	defer func() {
+		cfg.VerifyDepositsRoot.Value = oldVerify
+	}()
+	cfg.VerifyDepositsRoot.Value = true
+	vault.MaxValidatorsPerUser = 1
+	loadRelayKeys(t)
+
+	// Set a root that doesn't match the deposit data
+	err = setMockVaultRoot(t, res.Vault, common.HexToHash("0x5ca1ab1e"), 0)
+	require.NoError(t, err)
+	_, err = op.SubmitValidatorsRequest()
+	require.ErrorContains(t, err, "409")
+	records, err := auditLog.GetRecords(nil, nil, nil, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	record := records[len(records)-1]
+	require.Equal(t, http.StatusConflict, record.StatusCode)
+	require.Empty(t, record.Signature)
+	t.Log("Relay refused to provide validators for a vault root that didn't match, without asking NodeSet for a signature")
+
+	// Set the root of all of the vault's deposit data, the way the operator would after uploading it
+	response, err := apiClient.Wallet.DepositData(&res.Vault, nil)
+	require.NoError(t, err)
+	require.Len(t, response.Data.DepositData, len(pubkeys))
+	err = setMockVaultRoot(t, res.Vault, response.Data.ValidatorsRoot, 0)
+	require.NoError(t, err)
+
+	// The relay should prove the one key it provides is part of that root
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 1)
+	require.Equal(t, pubkeys[0], resp.Validators[0].PublicKey)
+	t.Logf("Relay provided a validator that was proven against vault root %s", response.Data.ValidatorsRoot.Hex())
+}
+
+func TestRelay_ValidatorsRoot_AfterDeposit(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+	qMgr := sp.GetQueryManager()
+	bdc := sp.GetBeaconDepositContract()
+
+	// Turn on the verification and load the keys
+	oldVerify := cfg.VerifyDepositsRoot.Value
+	defer func() {
+		cfg.VerifyDepositsRoot.Value = oldVerify
+	}()
+	cfg.VerifyDepositsRoot.Value = true
+	vault.MaxValidatorsPerUser = 1
+	loadRelayKeys(t)
+
+	// Set the root of all of the vault's deposit data and get the first key
+	response, err := apiClient.Wallet.DepositData(&res.Vault, nil)
+	require.NoError(t, err)
+	require.Len(t, response.Data.DepositData, len(pubkeys))
+	validatorsRoot := response.Data.ValidatorsRoot
+	err = setMockVaultRoot(t, res.Vault, validatorsRoot, 0)
+	require.NoError(t, err)
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 1)
+	require.Equal(t, pubkeys[0], resp.Validators[0].PublicKey)
+	t.Log("Relay provided the first key in the vault's deposit data")
+
+	// Deposit the first key and move the vault on to the next one, the way registering it would
+	key, err := keygen.GetBlsPrivateKey(0)
+	require.NoError(t, err)
+	_, err = deposit(key, mainNodeOpts)
+	require.NoError(t, err)
+	var depositRoot common.Hash
+	err = qMgr.Query(func(mc *batchquery.MultiCaller) error {
+		bdc.GetDepositRoot(mc, &depositRoot)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	nsDB.Eth.SetDepositRoot(depositRoot)
+	err = setMockVaultRoot(t, res.Vault, validatorsRoot, 1)
+	require.NoError(t, err)
+	vault.MaxValidatorsPerUser = 2
+	loadRelayKeys(t)
+
+	// The first key isn't available anymore, but the second one should still be proven against the same root
+	resp, err = op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 1)
+	require.Equal(t, pubkeys[1], resp.Validators[0].PublicKey)
+	t.Logf("Relay provided the second key against vault root %s after the first one was deposited", validatorsRoot.Hex())
+}
+
+func TestRelay_ValidatorsRoot_Zero(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	nsMock := testMgr.GetNodeSetMockServer().GetManager()
+	nsDB := nsMock.GetDatabase()
+	deployment := nsDB.StakeWise.GetDeployment(res.DeploymentName)
+	vault := deployment.GetVault(res.Vault)
+	op := testMgr.GetOperatorMock()
+
+	// Turn on the verification and load the keys
+	oldVerify := cfg.VerifyDepositsRoot.Value
+	defer func() {
+		cfg.VerifyDepositsRoot.Value = oldVerify
+	}()
+	cfg.VerifyDepositsRoot.Value = true
+	vault.MaxValidatorsPerUser = 3
+	loadRelayKeys(t)
+
+	// Vaults that use a validators manager don't have a root, so they only need the NodeSet signature
+	err = setMockVaultRoot(t, res.Vault, common.Hash{}, 0)
+	require.NoError(t, err)
+	resp, err := op.SubmitValidatorsRequest()
+	require.NoError(t, err)
+	require.Len(t, resp.Validators, 3)
+	t.Log("Relay provided validators for a vault without a validators root")
+}
+
+// Commit a fresh block and scan the test keys so they're ready for the relay
+func loadRelayKeys(t *testing.T) {
+	sp := mainNode.GetServiceProvider()
+	keyMgr := sp.GetAvailableKeyManager()
+	logger := testMgr.GetLogger()
+	ctx := context.Background()
+
+	// Commit a block just so the latest block is fresh - otherwise the sync progress check will
+	// error out because the block is too old and it thinks the client just can't find any peers
+	err := testMgr.CommitBlock()
+	require.NoError(t, err)
+	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
+	require.NoError(t, err)
+	keyMgr.LoadPrivateKeys(logger)
+	_, _, err = keyMgr.GetAvailableKeys(ctx, logger, common.HexToHash("0x01"), currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: true,
+	})
+	require.NoError(t, err)
+}
+
+// Replace the vault with a mock contract that has the provided validators root and index of the next validator to register
+func setMockVaultRoot(t *testing.T, vault common.Address, root common.Hash, validatorIndex uint64) error {
+	return testMgr.SetMockContract(vault, map[string][]byte{
+		"validatorsRoot()": packAbiValue(t, "bytes32", [32]byte(root)),
+		"validatorIndex()": packAbiValue(t, "uint256", new(big.Int).SetUint64(validatorIndex)),
+	})
+}
@@ -0,0 +1,117 @@
+package api_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swtasks "github.com/nodeset-org/hyperdrive-stakewise/tasks"
+	"github.com/rocket-pool/node-manager-core/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAnnualizedReturn(t *testing.T) {
+	halfYear := uint64(365 * 24 * 60 * 60 / 2)
+
+	// 1% over half a year is 2% a year
+	apr := swcommon.GetAnnualizedReturn(eth.EthToWei(1), eth.EthToWei(1.01), halfYear)
+	require.InDelta(t, 2.0, apr, 1e-9)
+
+	// No change is 0%
+	apr = swcommon.GetAnnualizedReturn(eth.EthToWei(1.05), eth.EthToWei(1.05), halfYear)
+	require.InDelta(t, 0.0, apr, 1e-9)
+
+	// Losses are negative
+	apr = swcommon.GetAnnualizedReturn(eth.EthToWei(1), eth.EthToWei(0.99), halfYear*2)
+	require.InDelta(t, -1.0, apr, 1e-9)
+}
+
+func TestNetworkVaultReport(t *testing.T) {
+	err := testMgr.RevertSnapshot(initSnapshot)
+	if err != nil {
+		fail("Error reverting to initial snapshot: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	cfg := sp.GetConfig()
+	res := sp.GetResources()
+	apiClient := mainNode.GetApiClient()
+	rateStore := sp.GetVaultRateStore()
+	ctx := context.Background()
+	task := swtasks.NewRecordVaultRatesTask(ctx, sp, sp.GetTasksLogger())
+
+	// Use a short APR window
+	window := uint64(2)
+	oldWindow := cfg.VaultAprWindow.Value
+	defer func() {
+		cfg.VaultAprWindow.Value = oldWindow
+	}()
+	cfg.VaultAprWindow.Value = window
+
+	// Mock the vault with a starting exchange rate
+	startRate := eth.EthToWei(1.01)
+	err = setMockVault(t, startRate)
+	require.NoError(t, err)
+	err = testMgr.CommitBlock()
+	require.NoError(t, err)
+
+	// Record the starting rate
+	err = task.Run()
+	require.NoError(t, err)
+	sampleBlock, exists := rateStore.GetLatestBlock()
+	require.True(t, exists)
+	sample := rateStore.GetSampleAtOrBefore(sampleBlock)
+	require.NotNil(t, sample)
+	require.Equal(t, 0, startRate.Cmp(sample.Rates[res.Vault]))
+	t.Logf("Recorded the starting exchange rate at block %d", sampleBlock)
+
+	// Raise the rate and move past the window
+	endRate := eth.EthToWei(1.02)
+	err = setMockVault(t, endRate)
+	require.NoError(t, err)
+	for i := uint64(0); i <= window; i++ {
+		err = testMgr.CommitBlock()
+		require.NoError(t, err)
+	}
+
+	// Get the report - the APR should come from the recorded sample
+	response, err := apiClient.Network.VaultReport()
+	require.NoError(t, err)
+	data := response.Data
+	require.False(t, data.NotRegisteredWithNodeSet)
+	require.Equal(t, data.Block-window, data.AprWindowStartBlock)
+	require.Len(t, data.Vaults, 1)
+	vault := data.Vaults[0]
+	require.Equal(t, res.Vault, vault.Address)
+	require.Equal(t, 0, endRate.Cmp(vault.ExchangeRate))
+	require.Equal(t, 0, big.NewInt(1000).Cmp(vault.TotalAssets))
+	require.Equal(t, uint16(500), vault.FeePercent)
+	require.True(t, vault.AprAvailable, vault.AprError)
+	require.Equal(t, sampleBlock, vault.AprStartBlock)
+
+	// Check the APR against the block times
+	header, err := sp.GetEthClient().HeaderByNumber(ctx, new(big.Int).SetUint64(data.Block))
+	require.NoError(t, err)
+	expectedApr := swcommon.GetAnnualizedReturn(startRate, endRate, header.Time-uint64(sample.Time.Unix()))
+	require.InDelta(t, expectedApr, vault.Apr, 1e-9)
+	require.Greater(t, vault.Apr, 0.0)
+	t.Logf("Vault report estimated an APR of %.2f%% from the recorded exchange rate", vault.Apr)
+}
+
+// Replace the vault with a mock contract that has the provided exchange rate
+func setMockVault(t *testing.T, rate *big.Int) error {
+	res := mainNode.GetServiceProvider().GetResources()
+	return testMgr.SetMockContract(res.Vault, map[string][]byte{
+		"totalAssets()":            packAbiValue(t, "uint256", big.NewInt(1000)),
+		"totalShares()":            packAbiValue(t, "uint256", big.NewInt(990)),
+		"convertToAssets(uint256)": packAbiValue(t, "uint256", rate),
+		"queuedShares()":           packAbiValue(t, "uint128", big.NewInt(0)),
+		"totalExitingAssets()":     packAbiValue(t, "uint128", big.NewInt(0)),
+		"capacity()":               packAbiValue(t, "uint256", eth.EthToWei(1000)),
+		"feePercent()":             packAbiValue(t, "uint16", uint16(500)),
+		"withdrawableAssets()":     packAbiValue(t, "uint256", big.NewInt(10)),
+	})
+}
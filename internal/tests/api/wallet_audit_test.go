@@ -0,0 +1,177 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/nodeset-org/hyperdrive-daemon/shared"
+	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/stretchr/testify/require"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestWalletAudit(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	wallet := sp.GetWallet()
+	apiClient := mainNode.GetApiClient()
+	validatorPath := filepath.Join(sp.GetModuleDir(), hdconfig.ValidatorsDirectory)
+
+	// Everything should be fine to start with
+	response, err := apiClient.Wallet.Audit(false, false)
+	require.NoError(t, err)
+	require.Zero(t, response.Data.IssueCount)
+	require.Len(t, response.Data.Keys, len(pubkeys))
+	for _, key := range response.Data.Keys {
+		require.True(t, key.InAvailableKeys)
+		require.True(t, key.HasKeystore)
+		require.True(t, key.HasVcKeystore)
+		require.NotEmpty(t, key.DerivationPath)
+	}
+	vcName := response.Data.VcName
+	t.Logf("Audit found no issues with %d keys, VC = %s", len(response.Data.Keys), vcName)
+
+	// Break the checksum of the first key's keystore so it can't be decrypted
+	keystorePath := filepath.Join(validatorPath, swconfig.ModuleName, "keystore-"+pubkeys[0].HexWithPrefix()+".json")
+	keystoreBytes, err := os.ReadFile(keystorePath)
+	require.NoError(t, err)
+	var keystore map[string]any
+	err = json.Unmarshal(keystoreBytes, &keystore)
+	require.NoError(t, err)
+	checksum := keystore["crypto"].(map[string]any)["checksum"].(map[string]any)
+	checksum["message"] = "0000000000000000000000000000000000000000000000000000000000000000"
+	keystoreBytes, err = json.Marshal(keystore)
+	require.NoError(t, err)
+	err = os.WriteFile(keystorePath, keystoreBytes, 0600)
+	require.NoError(t, err)
+
+	// Delete the second key's VC keystores
+	for _, client := range []string{"lighthouse", "lodestar", "nimbus"} {
+		err = os.RemoveAll(filepath.Join(validatorPath, client, "validators", pubkeys[1].HexWithPrefix()))
+		require.NoError(t, err)
+	}
+	err = os.RemoveAll(filepath.Join(validatorPath, "teku", "keys", pubkeys[1].HexWithPrefix()+".json"))
+	require.NoError(t, err)
+
+	// The audit should find both problems
+	response, err = apiClient.Wallet.Audit(false, false)
+	require.NoError(t, err)
+	results := getAuditResults(response.Data.Keys)
+	require.Contains(t, results[pubkeys[0]].Issues, swapi.KeyAuditIssue_Undecryptable)
+	require.NotEmpty(t, results[pubkeys[0]].DerivationPath)
+	if vcName != "prysm" {
+		require.Contains(t, results[pubkeys[1]].Issues, swapi.KeyAuditIssue_MissingVcKeystore)
+		require.False(t, results[pubkeys[1]].HasVcKeystore)
+	}
+	require.Empty(t, results[pubkeys[2]].Issues)
+	t.Log("Audit found the broken keystores")
+
+	// Repair them
+	response, err = apiClient.Wallet.Audit(true, false)
+	require.NoError(t, err)
+	results = getAuditResults(response.Data.Keys)
+	require.True(t, results[pubkeys[0]].Repaired)
+	require.Empty(t, results[pubkeys[0]].RepairError)
+	if vcName != "prysm" {
+		require.True(t, results[pubkeys[1]].Repaired)
+	}
+	require.False(t, results[pubkeys[2]].Repaired)
+	require.False(t, response.Data.VcRestarted)
+	t.Logf("Repaired %d keys", response.Data.RepairedCount)
+
+	// Everything should be fine again
+	response, err = apiClient.Wallet.Audit(false, false)
+	require.NoError(t, err)
+	require.Zero(t, response.Data.IssueCount)
+	key, err := wallet.GetPrivateKeyForPubkey(pubkeys[0])
+	require.NoError(t, err)
+	require.Equal(t, pubkeys[0], beacon.ValidatorPubkey(key.PublicKey().Marshal()))
+	t.Log("Audit found no issues after repairing")
+}
+
+func TestWalletAudit_UntrackedKeys(t *testing.T) {
+	err := testMgr.DependsOn(GenerateTestKeys, &generatedKeysSnapshot, t)
+	if err != nil {
+		fail("Error loading dependent state: %v", err)
+	}
+	defer handle_panics()
+
+	// Get some resources
+	sp := mainNode.GetServiceProvider()
+	wallet := sp.GetWallet()
+	apiClient := mainNode.GetApiClient()
+	hd := sp.GetHyperdriveClient()
+	validatorPath := filepath.Join(sp.GetModuleDir(), hdconfig.ValidatorsDirectory)
+	ctx := context.Background()
+
+	// Save a key that isn't in the available keys or registered with NodeSet, like one the operator moved here from another machine
+	path := fmt.Sprintf(shared.StakeWiseValidatorPath, 100)
+	keyResponse, err := hd.Wallet.GenerateValidatorKey(path)
+	require.NoError(t, err)
+	key, err := eth2types.BLSPrivateKeyFromBytes(keyResponse.Data.PrivateKey)
+	require.NoError(t, err)
+	untracked := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+	err = sp.GetValidatorSigner().ImportKey(ctx, key, path)
+	require.NoError(t, err)
+
+	// Delete its VC keystores
+	for _, client := range []string{"lighthouse", "lodestar", "nimbus"} {
+		err = os.RemoveAll(filepath.Join(validatorPath, client, "validators", untracked.HexWithPrefix()))
+		require.NoError(t, err)
+	}
+	err = os.RemoveAll(filepath.Join(validatorPath, "teku", "keys", untracked.HexWithPrefix()+".json"))
+	require.NoError(t, err)
+	response, err := apiClient.Wallet.Audit(false, false)
+	require.NoError(t, err)
+	vcName := response.Data.VcName
+	if vcName == "prysm" {
+		t.Skip("Prysm keeps every key in one keystore, so the VC keystore can't be removed for a single key")
+	}
+
+	// An orphaned key shouldn't be put into the VC
+	response, err = apiClient.Wallet.Audit(true, false)
+	require.NoError(t, err)
+	require.Zero(t, response.Data.RepairedCount)
+	info := getAuditResults(response.Data.Keys)[untracked]
+	require.Contains(t, info.Issues, swapi.KeyAuditIssue_Orphaned)
+	require.Contains(t, info.Issues, swapi.KeyAuditIssue_MissingVcKeystore)
+	require.False(t, info.Repaired)
+	require.NotEmpty(t, info.RepairError)
+	require.False(t, info.HasVcKeystore)
+	t.Log("Orphaned key wasn't repaired")
+
+	// Without NodeSet's list the key can't be called orphaned, but it still isn't tracked, so it shouldn't be repaired either
+	_, keys, err := wallet.AuditKeys(nil, true)
+	require.NoError(t, err)
+	info = getAuditResults(keys)[untracked]
+	require.NotContains(t, info.Issues, swapi.KeyAuditIssue_Orphaned)
+	require.Contains(t, info.Issues, swapi.KeyAuditIssue_MissingVcKeystore)
+	require.False(t, info.Repaired)
+	require.NotEmpty(t, info.RepairError)
+	_, keys, err = wallet.AuditKeys(nil, false)
+	require.NoError(t, err)
+	require.False(t, getAuditResults(keys)[untracked].HasVcKeystore)
+	t.Log("Untracked key wasn't repaired when NodeSet's list wasn't known")
+}
+
+// Map audit results by pubkey
+func getAuditResults(keys []swapi.KeyAuditInfo) map[beacon.ValidatorPubkey]swapi.KeyAuditInfo {
+	results := map[beacon.ValidatorPubkey]swapi.KeyAuditInfo{}
+	for _, key := range keys {
+		results[key.Pubkey] = key
+	}
+	return results
+}
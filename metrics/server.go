@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	MetricsPath string = "/metrics"
+)
+
+// Serves the daemon's Prometheus metrics over HTTP
+type MetricsServer struct {
+	logger *slog.Logger
+	ip     string
+	port   uint16
+	socket net.Listener
+	server http.Server
+}
+
+// Create a new metrics server
+func NewMetricsServer(sp swcommon.IStakeWiseServiceProvider, ip string, port uint16) *MetricsServer {
+	registry := sp.GetMetricsManager().GetRegistry()
+	logger := sp.GetTasksLogger().Logger
+
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}))
+
+	return &MetricsServer{
+		logger: logger,
+		ip:     ip,
+		port:   port,
+		server: http.Server{
+			Handler: mux,
+		},
+	}
+}
+
+// Starts listening for incoming HTTP requests
+func (s *MetricsServer) Start(wg *sync.WaitGroup) error {
+	// Create the socket
+	socket, err := net.Listen("tcp", fmt.Sprintf("%s:%d", s.ip, s.port))
+	if err != nil {
+		return fmt.Errorf("error creating socket: %w", err)
+	}
+	s.socket = socket
+
+	// Get the port if random
+	if s.port == 0 {
+		s.port = uint16(socket.Addr().(*net.TCPAddr).Port)
+	}
+
+	// Start listening
+	wg.Add(1)
+	go func() {
+		err := s.server.Serve(socket)
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("error while listening for metrics requests", log.Err(err))
+		}
+		wg.Done()
+	}()
+
+	return nil
+}
+
+// Stops the HTTP listener
+func (s *MetricsServer) Stop() error {
+	err := s.server.Shutdown(context.Background())
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error stopping listener: %w", err)
+	}
+	return nil
+}
+
+// Get the port the server is listening on
+func (s *MetricsServer) GetPort() uint16 {
+	return s.port
+}
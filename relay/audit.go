@@ -0,0 +1,80 @@
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Wrapper for a response writer that keeps track of the status code and any error written to it, for the audit log
+type auditRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// Create a new audit recorder
+func newAuditRecorder(w http.ResponseWriter) *auditRecorder {
+	return &auditRecorder{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+}
+
+// Saves the status code before writing it to the underlying response writer
+func (r *auditRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Saves the body of error responses before writing it to the underlying response writer
+func (r *auditRecorder) Write(data []byte) (int, error) {
+	if r.statusCode != http.StatusOK {
+		r.body.Write(data)
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Read the body of a request for the audit log, leaving it in place so it can still be processed normally
+func readAuditRequestBody(r *http.Request) json.RawMessage {
+	if r.Body == nil {
+		return json.RawMessage("null")
+	}
+	bodyBytes, err := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	if err != nil || len(bodyBytes) == 0 {
+		return json.RawMessage("null")
+	}
+
+	// Store bodies that aren't valid JSON as a string so the record can still be serialized
+	if !json.Valid(bodyBytes) {
+		bodyString, _ := json.Marshal(string(bodyBytes))
+		return json.RawMessage(bodyString)
+	}
+	return json.RawMessage(bodyBytes)
+}
+
+// Fill in the response details of an audit record and save it to the audit log
+func (h *baseHandler) saveAuditRecord(record *swapi.RelayAuditRecord, recorder *auditRecorder, start time.Time) {
+	record.StatusCode = recorder.statusCode
+	record.DurationMs = time.Since(start).Milliseconds()
+	if recorder.statusCode != http.StatusOK {
+		var response errorMessage
+		err := json.Unmarshal(recorder.body.Bytes(), &response)
+		if err == nil {
+			record.Error = response.Error
+		} else {
+			record.Error = recorder.body.String()
+		}
+	}
+
+	err := h.sp.GetRelayAuditLog().AddRecord(h.logger, *record)
+	if err != nil {
+		h.logger.Error("Error saving relay audit record", log.Err(err))
+	}
+}
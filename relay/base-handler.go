@@ -21,18 +21,28 @@ type baseHandler struct {
 	logger *slog.Logger
 	ctx    context.Context
 
-	validatorsLock sync.Mutex
-	validatorsBusy bool
+	preparer *validatorsPreparer
+
+	// The validators requests in progress, keyed by the request with its vault filled in
+	jobsLock sync.Mutex
+	jobs     map[ValidatorsRequest]*validatorsJob
+
+	// Held from key selection until the selected keys are marked with the deposit root,
+	// so requests running at the same time can't be given the same keys
+	selectionLock sync.Mutex
 }
 
 // Create a new base handler
 func NewBaseHandler(sp swcommon.IStakeWiseServiceProvider, logger *slog.Logger, ctx context.Context) *baseHandler {
 	return &baseHandler{
-		sp:             sp,
-		logger:         logger,
-		ctx:            ctx,
-		validatorsLock: sync.Mutex{},
-		validatorsBusy: false,
+		sp:       sp,
+		logger:   logger,
+		ctx:      ctx,
+		preparer: newValidatorsPreparer(sp, logger, ctx),
+		jobsLock: sync.Mutex{},
+		jobs:     map[ValidatorsRequest]*validatorsJob{},
+
+		selectionLock: sync.Mutex{},
 	}
 }
 
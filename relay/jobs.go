@@ -0,0 +1,66 @@
+package relay
+
+import (
+	"bytes"
+	"net/http"
+
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+)
+
+// A validators request that's being processed.
+// Identical requests that arrive while it's running wait for it and share its result.
+type validatorsJob struct {
+	// Closed when the job is done
+	done chan struct{}
+
+	// The HTTP status code the job responded with
+	statusCode int
+
+	// The body the job responded with
+	body []byte
+
+	// The audit record of the job
+	record *swapi.RelayAuditRecord
+}
+
+// Create a new validators job
+func newValidatorsJob(record *swapi.RelayAuditRecord) *validatorsJob {
+	return &validatorsJob{
+		done:   make(chan struct{}),
+		record: record,
+	}
+}
+
+// Save the job's response and release anything waiting on it
+func (j *validatorsJob) finish(recorder *jobRecorder) {
+	j.statusCode = recorder.statusCode
+	j.body = recorder.body.Bytes()
+	close(j.done)
+}
+
+// Wrapper for a response writer that keeps a copy of the status code and body written to it, so they can be shared with other requests
+type jobRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// Create a new job recorder
+func newJobRecorder(w http.ResponseWriter) *jobRecorder {
+	return &jobRecorder{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+}
+
+// Saves the status code before writing it to the underlying response writer
+func (r *jobRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Saves the body before writing it to the underlying response writer
+func (r *jobRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
@@ -0,0 +1,242 @@
+package relay
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	batch "github.com/rocket-pool/batch-query"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/log"
+	"github.com/rocket-pool/node-manager-core/utils"
+)
+
+const (
+	// How often to check for a new block to prepare validators for
+	preparePollInterval time.Duration = 4 * time.Second
+
+	// The most validators to cache deposit data for ahead of time, per vault; this is the StakeWise protocol's max batch size
+	maxPreparedValidators int = 10
+)
+
+// Eligible keys for a vault, prepared ahead of a request from the StakeWise Operator
+type preparedValidators struct {
+	// The Beacon deposit root the keys were checked against
+	depositRoot common.Hash
+
+	// The block the keys were checked at
+	block uint64
+
+	// The generation of the available key list when the keys were checked
+	keyGeneration uint64
+
+	// The keys that were eligible for new deposits into the vault
+	keys []*swcommon.AvailableKey
+}
+
+// Loads the private keys when the relay starts and keeps a warm cache of the validators each vault can be given,
+// refreshing it every block so requests can be answered without waiting on the eligibility checks
+type validatorsPreparer struct {
+	sp     swcommon.IStakeWiseServiceProvider
+	logger *slog.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	lock   *sync.Mutex
+
+	// The latest prepared validators for each vault
+	prepared map[common.Address]*preparedValidators
+
+	// The vaults to prepare validators for
+	vaults map[common.Address]bool
+}
+
+// Create a new validators preparer
+func newValidatorsPreparer(sp swcommon.IStakeWiseServiceProvider, logger *slog.Logger, ctx context.Context) *validatorsPreparer {
+	ctx, cancel := context.WithCancel(ctx)
+	return &validatorsPreparer{
+		sp:       sp,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		lock:     &sync.Mutex{},
+		prepared: map[common.Address]*preparedValidators{},
+		vaults: map[common.Address]bool{
+			sp.GetResources().Vault: true,
+		},
+	}
+}
+
+// Start loading the private keys and preparing validators in the background
+func (p *validatorsPreparer) Start(wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		// Wait for the task loop to initialize the StakeWise wallet
+		for {
+			exists, err := p.sp.GetWallet().CheckIfStakewiseWalletExists()
+			if err != nil {
+				p.logger.Debug("Error checking if StakeWise wallet exists", log.Err(err))
+			}
+			if exists {
+				break
+			}
+			if utils.SleepWithCancel(p.ctx, preparePollInterval) {
+				return
+			}
+		}
+
+		// Load the private keys, so the first request doesn't have to wait on them
+		keyMgr := p.sp.GetAvailableKeyManager()
+		if !keyMgr.HasLoadedKeys() {
+			p.logger.Info("Loading private keys...")
+			keyMgr.LoadPrivateKeys(p.logger)
+		}
+
+		p.run()
+	}()
+}
+
+// Stop preparing validators
+func (p *validatorsPreparer) Stop() {
+	p.cancel()
+}
+
+// Add a vault to the list of vaults to prepare validators for
+func (p *validatorsPreparer) AddVault(vault common.Address) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.vaults[vault] = true
+}
+
+// Get the prepared validators for a vault if they're still valid for the provided deposit root and block
+func (p *validatorsPreparer) GetPrepared(vault common.Address, depositRoot common.Hash, currentBlock uint64) *preparedValidators {
+	if !p.sp.GetConfig().RelayPrepareKeys.Value {
+		return nil
+	}
+	p.lock.Lock()
+	prepared, exists := p.prepared[vault]
+	p.lock.Unlock()
+	if !exists {
+		return nil
+	}
+	if prepared.depositRoot != depositRoot || prepared.block != currentBlock {
+		return nil
+	}
+	if prepared.keyGeneration != p.sp.GetAvailableKeyManager().GetGeneration() {
+		return nil
+	}
+	return prepared
+}
+
+// Get the block the validators for a vault were last prepared at, and whether they've been prepared at all
+func (p *validatorsPreparer) GetPreparedBlock(vault common.Address) (uint64, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	prepared, exists := p.prepared[vault]
+	if !exists {
+		return 0, false
+	}
+	return prepared.block, true
+}
+
+// Prepare validators for every tracked vault each time a new block arrives.
+// The setting is checked on every poll so it can be turned on or off without restarting the relay.
+func (p *validatorsPreparer) run() {
+	ec := p.sp.GetEthClient()
+	var lastBlock uint64
+	for {
+		if utils.SleepWithCancel(p.ctx, preparePollInterval) {
+			return
+		}
+		if !p.sp.GetConfig().RelayPrepareKeys.Value {
+			lastBlock = 0
+			continue
+		}
+
+		// Wait for a new block
+		currentBlock, err := ec.BlockNumber(p.ctx)
+		if err != nil {
+			p.logger.Debug("Error getting current block for validator preparation", log.Err(err))
+			continue
+		}
+		if currentBlock == lastBlock {
+			continue
+		}
+
+		// Prepare each vault
+		p.lock.Lock()
+		vaults := make([]common.Address, 0, len(p.vaults))
+		for vault := range p.vaults {
+			vaults = append(vaults, vault)
+		}
+		p.lock.Unlock()
+		succeeded := true
+		for _, vault := range vaults {
+			err = p.prepare(vault, currentBlock)
+			if err != nil {
+				p.logger.Debug("Couldn't prepare validators", "vault", vault.Hex(), "block", currentBlock, log.Err(err))
+				succeeded = false
+			}
+		}
+		if succeeded {
+			lastBlock = currentBlock
+		}
+	}
+}
+
+// Check which keys are eligible for a vault at the provided block and cache their deposit data
+func (p *validatorsPreparer) prepare(vault common.Address, currentBlock uint64) error {
+	sp := p.sp
+	keyMgr := sp.GetAvailableKeyManager()
+	if !keyMgr.HasKeyCandidates() {
+		return nil
+	}
+	start := time.Now()
+
+	// Get the deposit root at the block
+	var depositRoot common.Hash
+	err := sp.GetQueryManager().Query(func(mc *batch.MultiCaller) error {
+		sp.GetBeaconDepositContract().GetDepositRoot(mc, &depositRoot)
+		return nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("error getting latest Beacon deposit root: %w", err)
+	}
+
+	// Get the eligible keys, noting the generation first so any changes made while they're checked invalidate them
+	keyGeneration := keyMgr.GetGeneration()
+	keys, _, err := keyMgr.GetAvailableKeys(p.ctx, p.logger, depositRoot, currentBlock, swcommon.GetAvailableKeyOptions{
+		DoLookbackScan: false,
+		Vault:          &vault,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting available keys: %w", err)
+	}
+
+	// Make sure the deposit data for the keys that could be handed out first is cached
+	count := min(len(keys), maxPreparedValidators)
+	pubkeys := make([]beacon.ValidatorPubkey, count)
+	for i := 0; i < count; i++ {
+		pubkeys[i] = keys[i].PublicKey
+	}
+	_, err = sp.GetDepositDataCache().GetDepositData(p.ctx, p.logger, vault, pubkeys)
+	if err != nil {
+		return fmt.Errorf("error getting deposit data: %w", err)
+	}
+
+	p.lock.Lock()
+	p.prepared[vault] = &preparedValidators{
+		depositRoot:   depositRoot,
+		block:         currentBlock,
+		keyGeneration: keyGeneration,
+		keys:          keys,
+	}
+	p.lock.Unlock()
+	p.logger.Debug("Prepared validators", "vault", vault.Hex(), "block", currentBlock, "available", len(keys), "elapsed", time.Since(start))
+	return nil
+}
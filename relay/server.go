@@ -8,7 +8,9 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
 	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
@@ -60,6 +62,7 @@ func NewRelayServer(sp swcommon.IStakeWiseServiceProvider, ip string, port uint1
 
 	// Register base routes
 	server.baseHandler.RegisterRoutes(router)
+	router.Use(server.recordMetrics)
 
 	return server, nil
 }
@@ -78,6 +81,9 @@ func (s *RelayServer) Start(wg *sync.WaitGroup) error {
 		s.port = uint16(socket.Addr().(*net.TCPAddr).Port)
 	}
 
+	// Start loading keys and preparing validators in the background
+	s.baseHandler.preparer.Start(wg)
+
 	// Start listening
 	wg.Add(1)
 	go func() {
@@ -93,6 +99,7 @@ func (s *RelayServer) Start(wg *sync.WaitGroup) error {
 
 // Stops the HTTP listener
 func (s *RelayServer) Stop() error {
+	s.baseHandler.preparer.Stop()
 	err := s.server.Shutdown(context.Background())
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("error stopping listener: %w", err)
@@ -109,3 +116,40 @@ func (s *RelayServer) GetPort() uint16 {
 func (s *RelayServer) GetLogPath() string {
 	return s.logPath
 }
+
+// Get the block the relay last prepared validators for a vault at, and whether it has prepared any for it yet
+func (s *RelayServer) GetPreparedBlock(vault common.Address) (uint64, bool) {
+	return s.baseHandler.preparer.GetPreparedBlock(vault)
+}
+
+// Middleware that records the response code and duration of each request in the daemon's metrics
+func (s *RelayServer) recordMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+		next.ServeHTTP(recorder, r)
+
+		path := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				path = template
+			}
+		}
+		s.sp.GetMetricsManager().RecordRelayRequest(path, recorder.statusCode, time.Since(start))
+	})
+}
+
+// Wrapper for a response writer that keeps track of the status code written to it
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+// Saves the status code before writing it to the underlying response writer
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
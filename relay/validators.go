@@ -1,8 +1,12 @@
 package relay
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math/big"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,10 +14,11 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
 	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
 	nscommon "github.com/nodeset-org/nodeset-client-go/common"
 	batch "github.com/rocket-pool/batch-query"
 	"github.com/rocket-pool/node-manager-core/beacon"
-	"github.com/rocket-pool/node-manager-core/node/validator"
 	eth2types "github.com/wealdtech/go-eth2-types/v2"
 )
 
@@ -63,23 +68,84 @@ type ValidatorsResponse struct {
 
 // Handle a request to get validators from the StakeWise Operator
 func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
-	// Check if the request is already in progress
-	h.validatorsLock.Lock()
-	if h.validatorsBusy {
-		h.validatorsLock.Unlock()
-		HandleError(w, h.logger, http.StatusTooManyRequests, fmt.Errorf("validators request already in progress"))
+	// Record the request in the audit log once it's done
+	requestStart := time.Now()
+	record := &swapi.RelayAuditRecord{
+		Time:    requestStart.UTC(),
+		Request: readAuditRequestBody(r),
+		Keys:    []beacon.ValidatorPubkey{},
+		Timings: map[string]int64{},
+	}
+	recorder := newAuditRecorder(w)
+	w = recorder
+	defer h.saveAuditRecord(record, recorder, requestStart)
+
+	// Parse the body
+	logger := h.logger
+	res := h.sp.GetResources()
+	start := time.Now()
+	var request ValidatorsRequest
+	pathArgs, queryArgs := ProcessApiRequest(logger, w, r, &request)
+	if pathArgs == nil && queryArgs == nil {
 		return
 	}
-
-	// Mark the handler as busy to prevent requests until it's done
-	h.validatorsBusy = true
-	h.validatorsLock.Unlock()
+	logger.Debug("Parsed request", "elapsed", time.Since(start))
+	record.Timings["parse"] = time.Since(start).Milliseconds()
+
+	// Make sure the requested vault can be used, defaulting to the primary vault if one isn't provided
+	vault := request.Vault
+	if vault == (common.Address{}) {
+		vault = res.Vault
+	}
+	record.Vault = vault
+
+	// Wait for an identical request already in progress if there is one, otherwise start a new one.
+	// Only identical requests can share a result, since the exits are signed for the requested validator indices.
+	request.Vault = vault
+	h.jobsLock.Lock()
+	job, running := h.jobs[request]
+	if !running {
+		job = newValidatorsJob(record)
+		h.jobs[request] = job
+	}
+	h.jobsLock.Unlock()
+	if running {
+		h.waitForJob(w, r, job, record)
+		return
+	}
+	jobWriter := newJobRecorder(w)
 	defer func() {
-		h.validatorsLock.Lock()
-		h.validatorsBusy = false
-		h.validatorsLock.Unlock()
+		h.jobsLock.Lock()
+		delete(h.jobs, request)
+		h.jobsLock.Unlock()
+		job.finish(jobWriter)
 	}()
+	h.processValidatorsRequest(jobWriter, request, vault, record)
+}
+
+// Wait for the identical request already in progress and respond with its result
+func (h *baseHandler) waitForJob(w http.ResponseWriter, r *http.Request, job *validatorsJob, record *swapi.RelayAuditRecord) {
+	logger := h.logger
+	start := time.Now()
+	logger.Info("An identical validators request is already in progress, waiting for its result", "vault", record.Vault.Hex())
+	select {
+	case <-job.done:
+	case <-r.Context().Done():
+		HandleError(w, logger, http.StatusServiceUnavailable, fmt.Errorf("request was cancelled while waiting for the validators request already in progress"))
+		return
+	}
+	logger.Debug("Request in progress finished", "elapsed", time.Since(start))
+	record.Timings["sharedWait"] = time.Since(start).Milliseconds()
 
+	// Share the result
+	record.DepositRoot = job.record.DepositRoot
+	record.Keys = job.record.Keys
+	record.Signature = job.record.Signature
+	writeResponse(w, logger, job.statusCode, job.body)
+}
+
+// Get validators for a vault and respond with them
+func (h *baseHandler) processValidatorsRequest(w http.ResponseWriter, request ValidatorsRequest, vault common.Address, record *swapi.RelayAuditRecord) {
 	// Get the services
 	logger := h.logger
 	ctx := h.ctx
@@ -90,21 +156,19 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 	keyMgr := sp.GetAvailableKeyManager()
 	bn := sp.GetBeaconClient()
 
-	// Parse the body
 	start := time.Now()
-	var request ValidatorsRequest
-	pathArgs, queryArgs := ProcessApiRequest(logger, w, r, &request)
-	if pathArgs == nil && queryArgs == nil {
+	code, err := h.checkVault(vault)
+	if err != nil {
+		HandleError(w, logger, code, err)
 		return
 	}
-	logger.Debug("Parsed request", "elapsed", time.Since(start))
+	logger.Debug("Verified vault", "elapsed", time.Since(start), "vault", vault.Hex())
+	record.Timings["vault"] = time.Since(start).Milliseconds()
+	h.preparer.AddVault(vault)
 
-	// Short-circuit if the private keys haven't been loaded yet
+	// Private keys are loaded when the relay starts, so this only waits if that hasn't finished yet
 	if !keyMgr.HasLoadedKeys() {
-		logger.Debug("Private keys need to be loaded, loading now")
-		HandleError(w, logger, http.StatusServiceUnavailable, fmt.Errorf("still loading private keys"))
-		keyMgr.LoadPrivateKeys(logger)
-		return
+		logger.Debug("Private keys are still loading, they will be loaded before the keys are checked")
 	}
 
 	// Short-circuit if there aren't any validators to get
@@ -124,14 +188,18 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	logger.Debug("Verified wallet status", "elapsed", time.Since(start))
+	record.Timings["walletStatus"] = time.Since(start).Milliseconds()
 	walletStatus := walletResponse.Data.WalletStatus
 	start = time.Now()
 	err = sp.RequireStakewiseWalletReady(ctx, walletStatus)
 	if err != nil {
-		HandleError(w, logger, http.StatusUnprocessableEntity, fmt.Errorf("error checking wallet status: %w", err))
+		err = fmt.Errorf("error checking wallet status: %w", err)
+		h.alertRelayFailure("wallet_not_ready", err)
+		HandleError(w, logger, http.StatusUnprocessableEntity, err)
 		return
 	}
 	logger.Debug("StakeWise wallet ready", "elapsed", time.Since(start))
+	record.Timings["walletReady"] = time.Since(start).Milliseconds()
 
 	// Get the current Beacon deposit root
 	err = sp.RequireEthClientSynced(ctx)
@@ -154,6 +222,8 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	logger.Debug("Got deposit root", "elapsed", time.Since(start), "root", depositRoot.Hex())
+	record.DepositRoot = &depositRoot
+	record.Timings["depositRoot"] = time.Since(start).Milliseconds()
 
 	// Get the current block number
 	currentBlock, err := sp.GetEthClient().BlockNumber(ctx)
@@ -162,62 +232,41 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Short-circuit if lookback scanning is required because it will take too long
-	if keyMgr.RequiresLookbackScan(currentBlock) {
-		logger.Info("Lookback scan required for new keys or old data, starting scan")
-		if err := sp.RequireBeaconClientSynced(ctx); err != nil {
-			HandleError(w, logger, http.StatusUnprocessableEntity, fmt.Errorf("lookback scan is required but the Beacon Node is not synced: %w", err))
-			return
-		}
+	// Wait for any other request to finish handing out its keys, since they don't leave the pool until they're marked with the deposit root
+	start = time.Now()
+	h.selectionLock.Lock()
+	defer h.selectionLock.Unlock()
+	logger.Debug("Acquired key selection lock", "elapsed", time.Since(start))
+	record.Timings["selectionWait"] = time.Since(start).Milliseconds()
 
-		// Beacon is synced but the lookback scan is required, so start it and return while it runs
-		HandleError(w, logger, http.StatusServiceUnavailable, fmt.Errorf("lookback scan required for new keys or old data, try again later"))
+	// Get the available keys, using the ones prepared in the background if they're still current
+	var availableKeys []*swcommon.AvailableKey
+	start = time.Now()
+	prepared := h.preparer.GetPrepared(vault, depositRoot, currentBlock)
+	if prepared != nil {
+		availableKeys = prepared.keys
+		logger.Debug("Got prepared keys", "elapsed", time.Since(start), "block", prepared.block)
+		record.Timings["preparedKeys"] = time.Since(start).Milliseconds()
+	} else {
+		// Keys that still need a lookback scan are left to the background scanner and skipped here
+		if keyMgr.RequiresLookbackScan(currentBlock) {
+			logger.Info("Some keys are waiting on a lookback scan, they will be skipped until the scan is done")
+		}
 
-		// Do the lookback scan
-		start = time.Now()
-		_, _, err := keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, swcommon.GetAvailableKeyOptions{
+		scanOpts := swcommon.GetAvailableKeyOptions{
 			SkipSyncCheck:  true,
-			DoLookbackScan: true,
-		})
-		logger.Debug("Lookback scan complete", "elapsed", time.Since(start))
-		if err != nil {
-			logger.Error("Error during lookback scan", "error", err)
+			DoLookbackScan: false,
+			Vault:          &vault,
 		}
-		return
-	}
-
-	// Get the available keys, clamping to the number of validators requested
-	scanOpts := swcommon.GetAvailableKeyOptions{
-		SkipSyncCheck:  true,
-		DoLookbackScan: false,
-	}
-	start = time.Now()
-	availableKeys, ineligibleKeys, err := keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, scanOpts)
-	if err != nil {
-		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error getting available keys: %w", err))
-		return
-	}
-	logger.Debug("Got available keys", "elapsed", time.Since(start))
-
-	// Log the ineligible keys
-	if len(ineligibleKeys) > 0 {
-		logger.Info("Ineligible keys found", "count", len(ineligibleKeys))
-		for key, reason := range ineligibleKeys {
-			switch reason {
-			case swcommon.IneligibleReason_NoPrivateKey:
-				logger.Info("No private key found", "key", key.PublicKey.HexWithPrefix())
-			case swcommon.IneligibleReason_LookbackScanRequired:
-				logger.Info("Key requires lookback scan still?", "key", key.PublicKey.HexWithPrefix())
-			case swcommon.IneligibleReason_OnBeacon:
-				logger.Info("Key already seen on Beacon", "key", key.PublicKey.HexWithPrefix())
-			case swcommon.IneligibleReason_HasDepositEvent:
-				logger.Info("Key has a deposit event already", "key", key.PublicKey.HexWithPrefix())
-			case swcommon.IneligibleReason_AlreadyUsedDepositRoot:
-				logger.Info("Key has already used this deposit root", "key", key.PublicKey.HexWithPrefix())
-			default:
-				logger.Info("Key is ineligible for unknown reason", "key", key.PublicKey.HexWithPrefix(), "reason", reason)
-			}
+		var ineligibleKeys map[*swcommon.AvailableKey]swcommon.IneligibleReason
+		availableKeys, ineligibleKeys, err = keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, scanOpts)
+		if err != nil {
+			HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error getting available keys: %w", err))
+			return
 		}
+		logger.Debug("Got available keys", "elapsed", time.Since(start))
+		record.Timings["availableKeys"] = time.Since(start).Milliseconds()
+		logIneligibleKeys(logger, ineligibleKeys)
 	}
 
 	// Check if there are any available keys
@@ -236,7 +285,7 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 
 	// Check if NodeSet can support more validators
 	start = time.Now()
-	validatorsInfo, err := hd.NodeSet_StakeWise.GetValidatorsInfo(res.DeploymentName, res.Vault)
+	validatorsInfo, err := hd.NodeSet_StakeWise.GetValidatorsInfo(res.DeploymentName, vault)
 	if err != nil {
 		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error getting meta info from nodeset: %w", err))
 		return
@@ -247,6 +296,7 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 	}
 	availableForNodeSet := validatorsInfo.Data.AvailableValidators
 	logger.Debug("Got meta info from NodeSet", "elapsed", time.Since(start), "available", availableForNodeSet)
+	record.Timings["nodeSetInfo"] = time.Since(start).Milliseconds()
 	if availableForNodeSet == 0 {
 		// Return an empty response
 		HandleSuccess(w, logger, ValidatorsResponse{
@@ -261,32 +311,55 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 	debugEntries := []any{}
 	for _, key := range availableKeys {
 		debugEntries = append(debugEntries, "key", key.PublicKey.HexWithPrefix())
+		record.Keys = append(record.Keys, key.PublicKey)
 	}
 	logger.Info("Got available keys", debugEntries...)
 
-	// Create the deposit data
+	// Get the deposit data
 	start = time.Now()
-	privateKeys := make([]*eth2types.BLSPrivateKey, len(availableKeys))
+	pubkeys := make([]beacon.ValidatorPubkey, len(availableKeys))
 	for i, key := range availableKeys {
-		privateKeys[i] = key.PrivateKey
+		pubkeys[i] = key.PublicKey
 	}
-	depositDatas, err := swcommon.GenerateDepositData(logger, res, privateKeys)
+	depositDatas, err := sp.GetDepositDataCache().GetDepositData(ctx, logger, vault, pubkeys)
 	if err != nil {
-		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error generating deposit data: %w", err))
+		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error getting deposit data: %w", err))
 		return
 	}
-	logger.Debug("Generated deposit data", "elapsed", time.Since(start))
+	logger.Debug("Got deposit data", "elapsed", time.Since(start))
+	record.Timings["depositData"] = time.Since(start).Milliseconds()
+
+	// Make sure the vault has verified the deposit data before asking NodeSet to approve it
+	if sp.GetConfig().VerifyDepositsRoot.Value {
+		start = time.Now()
+		code, err := h.verifyValidatorsRoot(vault, depositDatas)
+		if err != nil {
+			h.alertRelayFailure("validators_root", err)
+			HandleError(w, logger, code, err)
+			return
+		}
+		logger.Debug("Verified vault validators root", "elapsed", time.Since(start))
+		record.Timings["validatorsRoot"] = time.Since(start).Milliseconds()
+	} else {
+		logger.Warn("Validators root verification is disabled, skipping the vault check")
+	}
 
 	// Create signed exits
 	start = time.Now()
 	signatureDomain, err := bn.GetDomainData(ctx, eth2types.DomainVoluntaryExit[:], res.CapellaForkEpoch, false)
 	if err != nil {
 		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error getting voluntary exit domain data: %w", err))
+		return
+	}
+	forkInfo, err := swcommon.GetExitForkInfo(ctx, sp)
+	if err != nil {
+		HandleError(w, logger, http.StatusInternalServerError, err)
+		return
 	}
 	exitMessages := make([]nscommon.ExitMessage, len(availableKeys))
 	currentIndex := uint64(request.ValidatorsStartIndex)
-	for i, key := range privateKeys {
-		exitMessage, err := createSignedExitMessage(key, currentIndex, res.CapellaForkEpoch, signatureDomain)
+	for i, pubkey := range pubkeys {
+		exitMessage, err := createSignedExitMessage(ctx, sp.GetValidatorSigner(), forkInfo, pubkey, currentIndex, res.CapellaForkEpoch, signatureDomain)
 		if err != nil {
 			HandleError(w, logger, http.StatusInternalServerError, err)
 			return
@@ -295,6 +368,7 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 		currentIndex++
 	}
 	logger.Debug("Generated exit messages", "elapsed", time.Since(start))
+	record.Timings["exitMessages"] = time.Since(start).Milliseconds()
 
 	// Encrypt the exits
 	start = time.Now()
@@ -310,41 +384,61 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 		encryptedExits[i] = encryptedMessage
 	}
 	logger.Debug("Encrypted exit messages", "elapsed", time.Since(start))
+	record.Timings["encryptExits"] = time.Since(start).Milliseconds()
 
 	// Get a signature from NodeSet
 	start = time.Now()
-	signatureResponse, err := hd.NodeSet_StakeWise.GetValidatorManagerSignature(res.DeploymentName, res.Vault, depositRoot, depositDatas, encryptedExits)
+	metrics := sp.GetMetricsManager()
+	signatureResponse, err := hd.NodeSet_StakeWise.GetValidatorManagerSignature(res.DeploymentName, vault, depositRoot, depositDatas, encryptedExits)
 	if err != nil {
-		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error getting validators signature from nodeset: %w", err))
+		metrics.RecordSignatureFailure("error")
+		err = fmt.Errorf("error getting validators signature from nodeset: %w", err)
+		h.alertRelayFailure("error", err)
+		HandleError(w, logger, http.StatusInternalServerError, err)
 		return
 	}
 	if signatureResponse.Data.DepositRootAlreadyUsed {
-		HandleError(w, logger, http.StatusConflict, fmt.Errorf("deposit root %s has already been used by another node operator", depositRoot.Hex()))
+		metrics.RecordSignatureFailure("deposit_root_already_used")
+		err = fmt.Errorf("deposit root %s has already been used by another node operator", depositRoot.Hex())
+		h.alertRelayFailure("deposit_root_already_used", err)
+		HandleError(w, logger, http.StatusConflict, err)
 		return
 	}
 	if signatureResponse.Data.NotRegistered {
-		HandleError(w, logger, http.StatusUnprocessableEntity, fmt.Errorf("node is not registered with nodeset"))
+		metrics.RecordSignatureFailure("not_registered")
+		err = fmt.Errorf("node is not registered with nodeset")
+		h.alertRelayFailure("not_registered", err)
+		HandleError(w, logger, http.StatusUnprocessableEntity, err)
 		return
 	}
 	if signatureResponse.Data.InvalidPermissions {
-		HandleError(w, logger, http.StatusUnauthorized, fmt.Errorf("node does not have permission to register validators with this deployment"))
+		metrics.RecordSignatureFailure("invalid_permissions")
+		err = fmt.Errorf("node does not have permission to register validators with this deployment")
+		h.alertRelayFailure("invalid_permissions", err)
+		HandleError(w, logger, http.StatusUnauthorized, err)
 		return
 	}
 	if signatureResponse.Data.VaultNotFound {
-		HandleError(w, logger, http.StatusUnprocessableEntity, fmt.Errorf("nodeset cannot find vault [%s] on deployment [%s]", res.Vault.Hex(), res.DeploymentName))
+		metrics.RecordSignatureFailure("vault_not_found")
+		err = fmt.Errorf("nodeset cannot find vault [%s] on deployment [%s]", vault.Hex(), res.DeploymentName)
+		h.alertRelayFailure("vault_not_found", err)
+		HandleError(w, logger, http.StatusUnprocessableEntity, err)
 		return
 	}
 	signature := signatureResponse.Data.Signature
+	record.Signature = signature
 	logger.Debug("Got validators signature from NodeSet", "elapsed", time.Since(start), "signature", signature)
+	record.Timings["signature"] = time.Since(start).Milliseconds()
 
 	// Set the last deposit root for those keys
 	start = time.Now()
-	err = keyMgr.SetLastDepositRoot(availableKeys, depositRoot)
+	err = keyMgr.SetLastDepositRoot(availableKeys, depositRoot, vault)
 	if err != nil {
 		HandleError(w, logger, http.StatusInternalServerError, fmt.Errorf("error setting last deposit root: %w", err))
 		return
 	}
 	logger.Debug("Updated available keys with last deposit root", "elapsed", time.Since(start))
+	record.Timings["lastDepositRoot"] = time.Since(start).Milliseconds()
 
 	// Return the validators to SW
 	start = time.Now()
@@ -362,11 +456,167 @@ func (h *baseHandler) getValidators(w http.ResponseWriter, r *http.Request) {
 	}
 	HandleSuccess(w, logger, response)
 	logger.Debug("Relay processing complete", "elapsed", time.Since(start))
+	record.Timings["response"] = time.Since(start).Milliseconds()
+}
+
+// Log the reasons keys weren't eligible for new deposits
+func logIneligibleKeys(logger *slog.Logger, ineligibleKeys map[*swcommon.AvailableKey]swcommon.IneligibleReason) {
+	if len(ineligibleKeys) == 0 {
+		return
+	}
+	logger.Info("Ineligible keys found", "count", len(ineligibleKeys))
+	for key, reason := range ineligibleKeys {
+		switch reason {
+		case swcommon.IneligibleReason_NoPrivateKey:
+			logger.Info("No private key found", "key", key.PublicKey.HexWithPrefix())
+		case swcommon.IneligibleReason_LookbackScanRequired:
+			logger.Info("Key is waiting on a lookback scan", "key", key.PublicKey.HexWithPrefix())
+		case swcommon.IneligibleReason_OnBeacon:
+			logger.Info("Key already seen on Beacon", "key", key.PublicKey.HexWithPrefix())
+		case swcommon.IneligibleReason_HasDepositEvent:
+			logger.Info("Key has a deposit event already", "key", key.PublicKey.HexWithPrefix())
+		case swcommon.IneligibleReason_AlreadyUsedDepositRoot:
+			logger.Info("Key has already used this deposit root", "key", key.PublicKey.HexWithPrefix())
+		case swcommon.IneligibleReason_ReservedForOtherVault:
+			logger.Debug("Key is reserved for another vault", "key", key.PublicKey.HexWithPrefix(), "vault", key.Vault.Hex())
+		default:
+			logger.Info("Key is ineligible for unknown reason", "key", key.PublicKey.HexWithPrefix(), "reason", reason)
+		}
+	}
+}
+
+// Make sure the vault requested by the StakeWise Operator is one the node can provide validators for.
+// If it isn't, this returns the HTTP status code to respond with and an error describing the problem.
+func (h *baseHandler) checkVault(vault common.Address) (int, error) {
+	sp := h.sp
+	res := sp.GetResources()
+	hd := sp.GetHyperdriveClient()
+
+	// Check if the vault has been disabled locally
+	vaultCfg := res.GetVault(vault)
+	if vaultCfg != nil && !vaultCfg.Enabled {
+		return http.StatusUnprocessableEntity, fmt.Errorf("vault [%s] is disabled on this node", vault.Hex())
+	}
+
+	// Check if NodeSet lets the node use the vault
+	response, err := hd.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error getting vaults from nodeset: %w", err)
+	}
+	if response.Data.NotRegistered {
+		return http.StatusUnprocessableEntity, fmt.Errorf("node is not registered with nodeset")
+	}
+	if response.Data.InvalidPermissions {
+		return http.StatusUnauthorized, fmt.Errorf("node does not have permission to use the vaults on deployment [%s]", res.DeploymentName)
+	}
+	for _, vaultInfo := range response.Data.Vaults {
+		if vaultInfo.Address == vault {
+			return http.StatusOK, nil
+		}
+	}
+	return http.StatusUnprocessableEntity, fmt.Errorf("vault [%s] is not a known vault on deployment [%s]", vault.Hex(), res.DeploymentName)
+}
+
+// Make sure the vault has verified the deposit data for the provided keys before it's sent to NodeSet for approval.
+// Vaults without a validators root register validators with the NodeSet signature alone, so they're skipped.
+// Otherwise the root has to be one the wallet deposit-data route exported, and since the vault registers the validators in that list in order
+// starting from its validator index, the keys have to be the next ones in it with the same deposit data.
+// If they aren't, this returns the HTTP status code to respond with and an error describing the problem.
+func (h *baseHandler) verifyValidatorsRoot(vault common.Address, depositDatas []beacon.ExtendedDepositData) (int, error) {
+	sp := h.sp
+	logger := h.logger
+
+	// Get the root stored in the vault and the index of the next validator it will register
+	vaultContract, err := swcontracts.NewStakewiseVault(vault, sp.GetEthClient(), sp.GetTransactionManager())
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error creating binding for vault [%s]: %w", vault.Hex(), err)
+	}
+	var vaultRoot common.Hash
+	var validatorIndex *big.Int
+	err = sp.GetQueryManager().Query(func(mc *batch.MultiCaller) error {
+		vaultContract.GetValidatorsRoot(mc, &vaultRoot)
+		vaultContract.GetValidatorIndex(mc, &validatorIndex)
+		return nil
+	}, nil)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error getting validators root of vault [%s]: %w", vault.Hex(), err)
+	}
+	if vaultRoot == (common.Hash{}) {
+		logger.Debug("Vault doesn't have a validators root, it only needs the NodeSet signature", "vault", vault.Hex())
+		return http.StatusOK, nil
+	}
+
+	// Get the deposit data list the root was built from
+	exported, exists := sp.GetDepositDataCache().GetExport(vault, vaultRoot)
+	if !exists {
+		logger.Error("Vault validators root doesn't match any deposit data exported by this node", "vault", vault.Hex(), "vaultRoot", vaultRoot.Hex())
+		return http.StatusConflict, fmt.Errorf("validators root of vault [%s] is %s, which doesn't match any deposit data exported by this node; refusing to provide validators the vault hasn't verified", vault.Hex(), vaultRoot.Hex())
+	}
+
+	// Make sure the keys are the next ones the vault will register
+	if !validatorIndex.IsUint64() {
+		return http.StatusInternalServerError, fmt.Errorf("validator index of vault [%s] is too large: %s", vault.Hex(), validatorIndex.String())
+	}
+	nextIndex := validatorIndex.Uint64()
+	pubkeys := make([]beacon.ValidatorPubkey, len(depositDatas))
+	for i, depositData := range depositDatas {
+		pubkeys[i] = beacon.ValidatorPubkey(depositData.PublicKey)
+		listIndex := nextIndex + uint64(i)
+		if listIndex >= uint64(len(exported)) {
+			return http.StatusConflict, fmt.Errorf("vault [%s] has registered %d of the %d validators in its deposit data, so it can't register key %s; export and upload new deposit data for it", vault.Hex(), nextIndex, len(exported), pubkeys[i].HexWithPrefix())
+		}
+		expected := exported[listIndex]
+		if !bytes.Equal(expected.PublicKey, depositData.PublicKey) {
+			return http.StatusConflict, fmt.Errorf("vault [%s] will register key %s at index %d of its deposit data, but key %s is being provided", vault.Hex(), beacon.ValidatorPubkey(expected.PublicKey).HexWithPrefix(), listIndex, pubkeys[i].HexWithPrefix())
+		}
+		if !bytes.Equal(expected.Signature, depositData.Signature) || !bytes.Equal(expected.DepositDataRoot, depositData.DepositDataRoot) {
+			return http.StatusConflict, fmt.Errorf("deposit data for key %s doesn't match the deposit data that was exported for vault [%s]", pubkeys[i].HexWithPrefix(), vault.Hex())
+		}
+	}
+
+	// Prove the keys' deposit data against the root
+	proof, err := swcommon.GetValidatorsMultiProof(exported, pubkeys)
+	if err != nil {
+		return http.StatusConflict, fmt.Errorf("error building validators proof for vault [%s]: %w", vault.Hex(), err)
+	}
+	expectedRoot, err := proof.GetRoot()
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error computing validators root from the proof: %w", err)
+	}
+	if vaultRoot != expectedRoot {
+		logger.Error("Vault validators root doesn't match the deposit data being provided",
+			"vault", vault.Hex(),
+			"vaultRoot", vaultRoot.Hex(),
+			"depositDataRoot", expectedRoot.Hex(),
+		)
+		return http.StatusConflict, fmt.Errorf("validators root of vault [%s] is %s but the deposit data being provided has a root of %s; refusing to provide validators the vault hasn't verified", vault.Hex(), vaultRoot.Hex(), expectedRoot.Hex())
+	}
+	return http.StatusOK, nil
+}
+
+// Send an alert about a validators request that couldn't be completed.
+// The reason is used to track repeats of the same failure separately from other failures.
+func (h *baseHandler) alertRelayFailure(reason string, err error) {
+	h.sp.GetAlertManager().Send(h.logger, swcommon.Alert{
+		Type:     swcommon.AlertType_RelayFailure,
+		Severity: swcommon.AlertSeverity_Warning,
+		Subject:  reason,
+		Title:    "Relay couldn't provide validators to the StakeWise Operator",
+		Message:  err.Error(),
+	})
 }
 
 // Create a signed exit message for a validator
 // TODO: This really needs to be baseline in NMC, not just the signature generator
-func createSignedExitMessage(validatorKey *eth2types.BLSPrivateKey, validatorIndex uint64, epoch uint64, signatureDomain []byte) (nscommon.ExitMessage, error) {
+func createSignedExitMessage(
+	ctx context.Context,
+	signer swcommon.IValidatorSigner,
+	forkInfo *swcommon.ForkInfo,
+	pubkey beacon.ValidatorPubkey,
+	validatorIndex uint64,
+	epoch uint64,
+	signatureDomain []byte,
+) (nscommon.ExitMessage, error) {
 	indexString := strconv.FormatUint(validatorIndex, 10)
 	exitMessage := nscommon.ExitMessage{
 		Message: nscommon.ExitMessageDetails{
@@ -374,9 +624,8 @@ func createSignedExitMessage(validatorKey *eth2types.BLSPrivateKey, validatorInd
 			ValidatorIndex: indexString,
 		},
 	}
-	exitMessageSignature, err := validator.GetSignedExitMessage(validatorKey, indexString, epoch, signatureDomain)
+	exitMessageSignature, err := swcommon.SignExitMessage(ctx, signer, forkInfo, pubkey, indexString, epoch, signatureDomain)
 	if err != nil {
-		pubkey := beacon.ValidatorPubkey(validatorKey.PublicKey().Marshal())
 		return nscommon.ExitMessage{}, fmt.Errorf("error getting signed exit message for validator [%s]: %w", pubkey.HexWithPrefix(), err)
 	}
 	exitMessage.Signature = exitMessageSignature.HexWithPrefix()
@@ -24,6 +24,8 @@ func NewNetworkHandler(logger *log.Logger, ctx context.Context, serviceProvider
 	}
 	h.factories = []server.IContextFactory{
 		&networkStatusContextFactory{h},
+		&networkOracleConfigContextFactory{h},
+		&networkVaultReportContextFactory{h},
 	}
 	return h
 }
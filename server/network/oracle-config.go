@@ -0,0 +1,61 @@
+package swnetwork
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type networkOracleConfigContextFactory struct {
+	handler *NetworkHandler
+}
+
+func (f *networkOracleConfigContextFactory) Create(args url.Values) (*networkOracleConfigContext, error) {
+	c := &networkOracleConfigContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *networkOracleConfigContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*networkOracleConfigContext, api.NetworkOracleConfigData](
+		router, "oracle-config", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type networkOracleConfigContext struct {
+	handler *NetworkHandler
+}
+
+func (c *networkOracleConfigContext) PrepareData(data *api.NetworkOracleConfigData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	res := sp.GetResources()
+	oracleMgr := sp.GetOracleConfigManager()
+
+	// Requirements
+	if res.Keeper == (common.Address{}) {
+		return types.ResponseStatus_Error, fmt.Errorf("the StakeWise Keeper address is not set for this network")
+	}
+
+	// Get the latest config the task loop has saved
+	data.NotScanned = !oracleMgr.IsScanned()
+	data.Config, data.Changes = oracleMgr.GetOracleConfig()
+	return types.ResponseStatus_Success, nil
+}
@@ -0,0 +1,67 @@
+package swnetwork
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type networkVaultReportContextFactory struct {
+	handler *NetworkHandler
+}
+
+func (f *networkVaultReportContextFactory) Create(args url.Values) (*networkVaultReportContext, error) {
+	c := &networkVaultReportContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *networkVaultReportContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*networkVaultReportContext, api.NetworkVaultReportData](
+		router, "vault-report", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type networkVaultReportContext struct {
+	handler *NetworkHandler
+}
+
+func (c *networkVaultReportContext) PrepareData(data *api.NetworkVaultReportData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	ctx := c.handler.ctx
+
+	// Requirements
+	err := sp.RequireRegisteredWithNodeSet(ctx)
+	if err != nil {
+		data.NotRegisteredWithNodeSet = true
+		return types.ResponseStatus_Success, err
+	}
+	err = sp.RequireEthClientSynced(ctx)
+	if err != nil {
+		return types.ResponseStatus_ClientsNotSynced, err
+	}
+
+	// Get the vault report
+	err = swcommon.GetVaultReport(ctx, sp, data)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+	return types.ResponseStatus_Success, nil
+}
@@ -25,6 +25,7 @@ func NewServiceHandler(logger *log.Logger, ctx context.Context, serviceProvider
 	h.factories = []server.IContextFactory{
 		&serviceGetNetworkSettingsContextFactory{h},
 		&serviceGetResourcesContextFactory{h},
+		&serviceRelayHistoryContextFactory{h},
 		&serviceVersionContextFactory{h},
 	}
 	return h
@@ -0,0 +1,97 @@
+package swservice
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+const (
+	// The number of records to return if the request doesn't set a limit
+	defaultRelayHistoryLimit uint64 = 1000
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type serviceRelayHistoryContextFactory struct {
+	handler *ServiceHandler
+}
+
+func (f *serviceRelayHistoryContextFactory) Create(args url.Values) (*serviceRelayHistoryContext, error) {
+	c := &serviceRelayHistoryContext{
+		handler: f.handler,
+		limit:   defaultRelayHistoryLimit,
+	}
+	inputErrs := []error{
+		server.ValidateOptionalArg("start", args, input.ValidateTime, &c.start, &c.hasStart),
+		server.ValidateOptionalArg("end", args, input.ValidateTime, &c.end, &c.hasEnd),
+		server.ValidateOptionalArg("vault", args, input.ValidateAddress, &c.vault, &c.hasVault),
+		server.ValidateOptionalArg("limit", args, input.ValidateUint, &c.limit, nil),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *serviceRelayHistoryContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*serviceRelayHistoryContext, swapi.ServiceRelayHistoryData](
+		router, "relay-history", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type serviceRelayHistoryContext struct {
+	handler  *ServiceHandler
+	start    time.Time
+	hasStart bool
+	end      time.Time
+	hasEnd   bool
+	vault    common.Address
+	hasVault bool
+	limit    uint64
+}
+
+func (c *serviceRelayHistoryContext) PrepareData(data *swapi.ServiceRelayHistoryData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+
+	var start *time.Time
+	if c.hasStart {
+		start = &c.start
+	}
+	var end *time.Time
+	if c.hasEnd {
+		end = &c.end
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return types.ResponseStatus_InvalidArguments, fmt.Errorf("start time %s must be before end time %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	var vault *common.Address
+	if c.hasVault {
+		vault = &c.vault
+	}
+
+	if c.limit == 0 {
+		return types.ResponseStatus_InvalidArguments, fmt.Errorf("limit must be at least 1")
+	}
+
+	records, err := sp.GetRelayAuditLog().GetRecords(start, end, vault, int(c.limit))
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting relay history: %w", err)
+	}
+	data.Records = records
+	return types.ResponseStatus_Success, nil
+}
@@ -0,0 +1,110 @@
+package swvalidator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type validatorBroadcastExitsContextFactory struct {
+	handler *ValidatorHandler
+}
+
+func (f *validatorBroadcastExitsContextFactory) Create(body api.ValidatorBroadcastExitsBody) (*validatorBroadcastExitsContext, error) {
+	c := &validatorBroadcastExitsContext{
+		handler: f.handler,
+		body:    body,
+	}
+	inputErrs := []error{}
+	if len(body.Pubkeys) > pubkeyLimit {
+		inputErrs = append(inputErrs, fmt.Errorf("too many pubkeys (provided %d, max = %d)", len(body.Pubkeys), pubkeyLimit))
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *validatorBroadcastExitsContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessPost[*validatorBroadcastExitsContext, api.ValidatorBroadcastExitsBody, api.ValidatorBroadcastExitsData](
+		router, "broadcast-exits", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type validatorBroadcastExitsContext struct {
+	handler *ValidatorHandler
+	body    api.ValidatorBroadcastExitsBody
+}
+
+func (c *validatorBroadcastExitsContext) PrepareData(data *api.ValidatorBroadcastExitsData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	bc := sp.GetBeaconClient()
+	res := sp.GetResources()
+	ctx := c.handler.ctx
+
+	// Requirements - the validator keys aren't needed since the exits are already signed
+	err := sp.RequireBeaconClientSynced(ctx)
+	if err != nil {
+		if errors.Is(err, services.ErrBeaconNodeNotSynced) {
+			return types.ResponseStatus_ClientsNotSynced, err
+		}
+		return types.ResponseStatus_Error, err
+	}
+
+	// Open the archive
+	archive, err := swcommon.DecryptExitArchive(&c.body.Archive, c.body.Password)
+	if err != nil {
+		return types.ResponseStatus_InvalidArguments, err
+	}
+	if archive.DeploymentName != res.DeploymentName {
+		return types.ResponseStatus_InvalidArguments, fmt.Errorf("exit archive was made for deployment [%s] but this node is using [%s]", archive.DeploymentName, res.DeploymentName)
+	}
+
+	// Pick the exits to broadcast - all of them if no pubkeys were provided
+	exits := archive.Exits
+	data.MissingPubkeys = []beacon.ValidatorPubkey{}
+	if len(c.body.Pubkeys) > 0 {
+		exitMap := make(map[beacon.ValidatorPubkey]api.PresignedExit, len(archive.Exits))
+		for _, exit := range archive.Exits {
+			exitMap[exit.Pubkey] = exit
+		}
+		exits = make([]api.PresignedExit, 0, len(c.body.Pubkeys))
+		for _, pubkey := range c.body.Pubkeys {
+			exit, exists := exitMap[pubkey]
+			if !exists {
+				data.MissingPubkeys = append(data.MissingPubkeys, pubkey)
+				continue
+			}
+			exits = append(exits, exit)
+		}
+	}
+
+	// Submit each one, carrying on past failures so one bad exit doesn't block the rest
+	data.Results = make([]api.ValidatorBroadcastExitResult, len(exits))
+	for i, exit := range exits {
+		result := api.ValidatorBroadcastExitResult{
+			Pubkey: exit.Pubkey,
+			Index:  exit.Index,
+		}
+		err = bc.ExitValidator(ctx, exit.Index, exit.Epoch, exit.Signature)
+		if err != nil {
+			result.Error = fmt.Sprintf("error exiting validator %s: %s", exit.Pubkey.Hex(), err.Error())
+		}
+		data.Results[i] = result
+	}
+	return types.ResponseStatus_Success, nil
+}
@@ -10,11 +10,11 @@ import (
 	"github.com/gorilla/mux"
 	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
 	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
 	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
 	"github.com/rocket-pool/node-manager-core/api/server"
 	"github.com/rocket-pool/node-manager-core/api/types"
 	"github.com/rocket-pool/node-manager-core/beacon"
-	"github.com/rocket-pool/node-manager-core/node/validator"
 	"github.com/rocket-pool/node-manager-core/utils/input"
 	"github.com/rocket-pool/node-manager-core/wallet"
 	eth2types "github.com/wealdtech/go-eth2-types/v2"
@@ -40,6 +40,7 @@ func (f *validatorExitContextFactory) Create(args url.Values) (*validatorExitCon
 		server.ValidateOptionalArg("epoch", args, input.ValidateUint, &c.epoch, &c.isEpochSet),
 		server.ValidateArgBatch("pubkeys", args, pubkeyLimit, input.ValidatePubkey, &c.pubkeys),
 		server.ValidateArg("no-broadcast", args, input.ValidateBool, &c.noBroadcast),
+		server.ValidateOptionalArg("dry-run", args, input.ValidateBool, &c.dryRun, nil),
 	}
 	return c, errors.Join(inputErrs...)
 }
@@ -60,12 +61,13 @@ type validatorExitContext struct {
 	isEpochSet  bool
 	pubkeys     []beacon.ValidatorPubkey
 	noBroadcast bool
+	dryRun      bool
 }
 
 func (c *validatorExitContext) PrepareData(data *api.ValidatorExitData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
 	sp := c.handler.serviceProvider
 	bc := sp.GetBeaconClient()
-	w := sp.GetWallet()
+	signer := sp.GetValidatorSigner()
 	ctx := c.handler.ctx
 
 	if len(c.pubkeys) == 0 {
@@ -85,62 +87,117 @@ func (c *validatorExitContext) PrepareData(data *api.ValidatorExitData, walletSt
 		return types.ResponseStatus_Error, err
 	}
 
-	// Load the keys
-	keys := make([]*eth2types.BLSPrivateKey, len(c.pubkeys))
-	for i, pubkey := range c.pubkeys {
-		key, err := w.GetPrivateKeyForPubkey(pubkey)
-		if err != nil {
-			return types.ResponseStatus_Error, err
-		}
-		keys[i] = key
+	// Get the chain head and the epoch to exit on
+	head, err := bc.GetBeaconHead(ctx)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting beacon head: %w", err)
 	}
-
-	// Get the epoch of the chain head if needed
 	if !c.isEpochSet {
-		head, err := bc.GetBeaconHead(ctx)
-		if err != nil {
-			return types.ResponseStatus_Error, fmt.Errorf("error getting beacon head: %w", err)
-		}
 		c.epoch = head.Epoch
 	}
+	data.Epoch = c.epoch
+	data.DryRun = c.dryRun
 
-	// Get the voluntary exit signature domain
-	signatureDomain, err := bc.GetDomainData(ctx, eth2types.DomainVoluntaryExit[:], c.epoch, false)
+	// Get the Beacon config for the shard committee period
+	eth2Config, err := bc.GetEth2Config(ctx)
 	if err != nil {
-		return types.ResponseStatus_Error, fmt.Errorf("error getting voluntary exit domain data: %w", err)
+		return types.ResponseStatus_Error, fmt.Errorf("error getting Beacon config: %w", err)
 	}
 
 	// Get the statuses (indices) of each validator
 	statuses, err := bc.GetValidatorStatuses(ctx, c.pubkeys, nil)
 	if err != nil {
-		return types.ResponseStatus_Error, fmt.Errorf("error getting validator indices: %w", err)
+		return types.ResponseStatus_Error, fmt.Errorf("error getting validator statuses: %w", err)
 	}
 
-	// Get the signatures
-	data.ExitInfos = make([]api.ValidatorExitInfo, len(keys))
-	for i, key := range keys {
-		// Get signed voluntary exit message
-		pubkey := c.pubkeys[i]
-		index := statuses[pubkey].Index
+	// Run the preflight checks
+	data.ExitInfos = make([]api.ValidatorExitInfo, len(c.pubkeys))
+	for i, pubkey := range c.pubkeys {
+		status, exists := statuses[pubkey]
+		exists = exists && status.Exists
+		info := api.ValidatorExitInfo{
+			Pubkey:          pubkey,
+			Status:          status.Status,
+			PreflightErrors: getExitPreflightErrors(status, exists, c.epoch, head.Epoch, eth2Config.ShardCommitteePeriod),
+		}
+		if exists {
+			info.Index, _ = strconv.ParseUint(status.Index, 10, 64)
+		}
+		data.ExitInfos[i] = info
+	}
+	if c.dryRun {
+		return types.ResponseStatus_Success, nil
+	}
 
-		signature, err := validator.GetSignedExitMessage(key, index, c.epoch, signatureDomain)
-		if err != nil {
-			return types.ResponseStatus_Error, fmt.Errorf("error getting exit message signature for validator %s: %w", pubkey.Hex(), err)
+	// Get the voluntary exit signature domain
+	signatureDomain, err := bc.GetDomainData(ctx, eth2types.DomainVoluntaryExit[:], c.epoch, false)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting voluntary exit domain data: %w", err)
+	}
+	forkInfo, err := swcommon.GetExitForkInfo(ctx, sp)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+
+	// Sign and broadcast each exit, carrying on past failures so one bad validator doesn't block the rest
+	for i := range data.ExitInfos {
+		info := &data.ExitInfos[i]
+		status, exists := statuses[info.Pubkey]
+		if !exists || !status.Exists {
+			info.Error = fmt.Sprintf("validator %s does not have an index on Beacon", info.Pubkey.Hex())
+			continue
+		}
+		if !c.noBroadcast && len(info.PreflightErrors) > 0 {
+			info.Error = fmt.Sprintf("validator %s failed the exit preflight checks", info.Pubkey.Hex())
+			continue
 		}
-		indexUint, _ := strconv.ParseUint(index, 10, 64)
 
-		data.ExitInfos[i] = api.ValidatorExitInfo{
-			Pubkey:    pubkey,
-			Index:     indexUint,
-			Signature: signature,
+		// Get signed voluntary exit message
+		signature, err := swcommon.SignExitMessage(ctx, signer, forkInfo, info.Pubkey, status.Index, c.epoch, signatureDomain)
+		if err != nil {
+			info.Error = fmt.Sprintf("error getting exit message signature for validator %s: %s", info.Pubkey.Hex(), err.Error())
+			continue
 		}
+		info.Signature = signature
 		if !c.noBroadcast {
-			err = bc.ExitValidator(ctx, index, c.epoch, signature)
+			err = bc.ExitValidator(ctx, status.Index, c.epoch, signature)
 			if err != nil {
-				return types.ResponseStatus_Error, fmt.Errorf("error exiting validator %s: %w", pubkey.Hex(), err)
+				info.Error = fmt.Sprintf("error exiting validator %s: %s", info.Pubkey.Hex(), err.Error())
+				continue
 			}
+			info.Broadcast = true
 		}
 	}
 
 	return types.ResponseStatus_Success, nil
 }
+
+// Check if a validator can be exited on the given epoch, returning the reasons it can't if not
+func getExitPreflightErrors(status beacon.ValidatorStatus, exists bool, exitEpoch uint64, currentEpoch uint64, shardCommitteePeriod uint64) []string {
+	if !exists {
+		return []string{"validator is not on the Beacon chain"}
+	}
+
+	errs := []string{}
+	if status.Slashed {
+		errs = append(errs, "validator has been slashed")
+	}
+	switch status.Status {
+	case beacon.ValidatorState_PendingInitialized, beacon.ValidatorState_PendingQueued:
+		errs = append(errs, "validator is not active yet")
+	case beacon.ValidatorState_ActiveOngoing:
+		if status.ActivationEpoch > currentEpoch || currentEpoch-status.ActivationEpoch < shardCommitteePeriod {
+			errs = append(errs, fmt.Sprintf("validator has not been active for the shard committee period of %d epochs yet (activated on epoch %d)", shardCommitteePeriod, status.ActivationEpoch))
+		}
+	case beacon.ValidatorState_ActiveSlashed:
+		if !status.Slashed {
+			errs = append(errs, "validator has been slashed")
+		}
+	default:
+		errs = append(errs, "validator is already exiting")
+	}
+	if exitEpoch > currentEpoch {
+		errs = append(errs, fmt.Sprintf("exit epoch %d is after the current epoch %d", exitEpoch, currentEpoch))
+	}
+	return errs
+}
@@ -0,0 +1,134 @@
+package swvalidator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type validatorExportExitsContextFactory struct {
+	handler *ValidatorHandler
+}
+
+func (f *validatorExportExitsContextFactory) Create(body api.ValidatorExportExitsBody) (*validatorExportExitsContext, error) {
+	c := &validatorExportExitsContext{
+		handler: f.handler,
+		body:    body,
+	}
+	inputErrs := []error{}
+	if len(body.Password) < input.MinPasswordLength {
+		inputErrs = append(inputErrs, fmt.Errorf("archive password must be at least %d characters long", input.MinPasswordLength))
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *validatorExportExitsContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessPost[*validatorExportExitsContext, api.ValidatorExportExitsBody, api.ValidatorExportExitsData](
+		router, "export-exits", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type validatorExportExitsContext struct {
+	handler *ValidatorHandler
+	body    api.ValidatorExportExitsBody
+}
+
+func (c *validatorExportExitsContext) PrepareData(data *api.ValidatorExportExitsData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	bc := sp.GetBeaconClient()
+	signer := sp.GetValidatorSigner()
+	res := sp.GetResources()
+	ctx := c.handler.ctx
+
+	// Requirements
+	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+	err = sp.RequireBeaconClientSynced(ctx)
+	if err != nil {
+		if errors.Is(err, services.ErrBeaconNodeNotSynced) {
+			return types.ResponseStatus_ClientsNotSynced, err
+		}
+		return types.ResponseStatus_Error, err
+	}
+
+	// Load the keys
+	pubkeys, err := signer.GetPubkeys(ctx)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting validator keys from the %s signer: %w", signer.GetName(), err)
+	}
+
+	// Get the statuses (indices) of each validator
+	statuses, err := bc.GetValidatorStatuses(ctx, pubkeys, nil)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting validator statuses: %w", err)
+	}
+
+	// Exits signed with the Capella domain stay valid across future forks
+	signatureDomain, err := bc.GetDomainData(ctx, eth2types.DomainVoluntaryExit[:], res.CapellaForkEpoch, false)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting voluntary exit domain data: %w", err)
+	}
+	forkInfo, err := swcommon.GetExitForkInfo(ctx, sp)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+
+	// Sign an exit for each active validator
+	archive := api.ExitArchive{
+		Version:        swcommon.ExitArchiveVersion,
+		DeploymentName: res.DeploymentName,
+		Created:        time.Now().UTC(),
+		Exits:          []api.PresignedExit{},
+	}
+	data.Pubkeys = []beacon.ValidatorPubkey{}
+	data.InactivePubkeys = []beacon.ValidatorPubkey{}
+	for _, pubkey := range pubkeys {
+		status, exists := statuses[pubkey]
+		if !exists || status.Status != beacon.ValidatorState_ActiveOngoing {
+			data.InactivePubkeys = append(data.InactivePubkeys, pubkey)
+			continue
+		}
+
+		signature, err := swcommon.SignExitMessage(ctx, signer, forkInfo, pubkey, status.Index, res.CapellaForkEpoch, signatureDomain)
+		if err != nil {
+			return types.ResponseStatus_Error, fmt.Errorf("error getting exit message signature for validator %s: %w", pubkey.Hex(), err)
+		}
+		archive.Exits = append(archive.Exits, api.PresignedExit{
+			Pubkey:    pubkey,
+			Index:     status.Index,
+			Epoch:     res.CapellaForkEpoch,
+			Signature: signature,
+		})
+		data.Pubkeys = append(data.Pubkeys, pubkey)
+	}
+
+	// Encrypt the archive
+	encryptedArchive, err := swcommon.EncryptExitArchive(&archive, c.body.Password)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+	data.Archive = *encryptedArchive
+	return types.ResponseStatus_Success, nil
+}
@@ -23,8 +23,12 @@ func NewValidatorHandler(logger *log.Logger, ctx context.Context, serviceProvide
 		serviceProvider: serviceProvider,
 	}
 	h.factories = []server.IContextFactory{
+		&validatorBroadcastExitsContextFactory{h},
 		&validatorExitContextFactory{h},
+		&validatorExportExitsContextFactory{h},
+		&validatorPerformanceContextFactory{h},
 		&validatorStatusContextFactory{h},
+		&validatorTopUpContextFactory{h},
 	}
 	return h
 }
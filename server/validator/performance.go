@@ -0,0 +1,88 @@
+package swvalidator
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+const (
+	// The default number of days of history to report on
+	defaultPerformanceDays uint64 = 7
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type validatorPerformanceContextFactory struct {
+	handler *ValidatorHandler
+}
+
+func (f *validatorPerformanceContextFactory) Create(args url.Values) (*validatorPerformanceContext, error) {
+	c := &validatorPerformanceContext{
+		handler: f.handler,
+		period:  api.PerformancePeriod_Day,
+		days:    defaultPerformanceDays,
+	}
+	inputErrs := []error{
+		server.ValidateOptionalArgBatch("pubkeys", args, pubkeyLimit, input.ValidatePubkey, &c.pubkeys, nil),
+		server.ValidateOptionalArg("period", args, validatePerformancePeriod, &c.period, nil),
+		server.ValidateOptionalArg("days", args, input.ValidatePositiveUint, &c.days, nil),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *validatorPerformanceContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*validatorPerformanceContext, api.ValidatorPerformanceData](
+		router, "performance", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type validatorPerformanceContext struct {
+	handler *ValidatorHandler
+	pubkeys []beacon.ValidatorPubkey
+	period  api.PerformancePeriod
+	days    uint64
+}
+
+func (c *validatorPerformanceContext) PrepareData(data *api.ValidatorPerformanceData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	store := sp.GetValidatorSnapshotStore()
+
+	// Get the snapshots in the window
+	data.Period = c.period
+	data.Since = time.Now().UTC().Add(-time.Duration(c.days) * 24 * time.Hour)
+	snapshots := store.GetSnapshotsSince(data.Since)
+
+	// Estimate the performance from them
+	data.Validators, data.UnknownPubkeys = swcommon.GetValidatorPerformance(snapshots, c.pubkeys, c.period)
+	return types.ResponseStatus_Success, nil
+}
+
+// Make sure the period is one of the supported ones
+func validatePerformancePeriod(name string, value string) (api.PerformancePeriod, error) {
+	period := api.PerformancePeriod(value)
+	switch period {
+	case api.PerformancePeriod_Interval, api.PerformancePeriod_Day:
+		return period, nil
+	default:
+		return "", fmt.Errorf("invalid %s '%s': must be '%s' or '%s'", name, value, api.PerformancePeriod_Interval, api.PerformancePeriod_Day)
+	}
+}
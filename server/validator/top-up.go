@@ -0,0 +1,143 @@
+package swvalidator
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type validatorTopUpContextFactory struct {
+	handler *ValidatorHandler
+}
+
+func (f *validatorTopUpContextFactory) Create(args url.Values) (*validatorTopUpContext, error) {
+	c := &validatorTopUpContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{
+		server.ValidateArgBatch("pubkeys", args, pubkeyLimit, input.ValidatePubkey, &c.pubkeys),
+		server.ValidateArg("amount", args, input.ValidateUint, &c.amount),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *validatorTopUpContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*validatorTopUpContext, api.ValidatorTopUpData](
+		router, "top-up", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type validatorTopUpContext struct {
+	handler *ValidatorHandler
+	pubkeys []beacon.ValidatorPubkey
+	amount  uint64
+}
+
+func (c *validatorTopUpContext) PrepareData(data *api.ValidatorTopUpData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	bc := sp.GetBeaconClient()
+	signer := sp.GetValidatorSigner()
+	res := sp.GetResources()
+	ctx := c.handler.ctx
+	logger := c.handler.logger.Logger
+
+	data.Amount = c.amount
+	data.TopUps = []api.ValidatorTopUpInfo{}
+	if len(c.pubkeys) == 0 {
+		return types.ResponseStatus_Success, nil
+	}
+	if c.amount < swcommon.MinDepositAmount {
+		return types.ResponseStatus_InvalidArguments, fmt.Errorf("top-up amount of %d gwei is less than the minimum deposit of %d gwei", c.amount, swcommon.MinDepositAmount)
+	}
+
+	// Requirements
+	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+	err = sp.RequireBeaconClientSynced(ctx)
+	if err != nil {
+		if errors.Is(err, services.ErrBeaconNodeNotSynced) {
+			return types.ResponseStatus_ClientsNotSynced, err
+		}
+		return types.ResponseStatus_Error, err
+	}
+
+	// Get the statuses of each validator
+	statuses, err := bc.GetValidatorStatuses(ctx, c.pubkeys, nil)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting validator statuses: %w", err)
+	}
+
+	// Generate the deposit data for each validator that can be topped up, carrying on past failures so one bad validator doesn't block the rest
+	data.TopUps = make([]api.ValidatorTopUpInfo, len(c.pubkeys))
+	for i, pubkey := range c.pubkeys {
+		info := &data.TopUps[i]
+		info.Pubkey = pubkey
+		status, exists := statuses[pubkey]
+		if !exists || !status.Exists {
+			info.Error = fmt.Sprintf("validator %s is not on the Beacon chain", pubkey.Hex())
+			continue
+		}
+		info.Index = status.Index
+		info.Status = status.Status
+		info.Balance = status.Balance
+		info.Vault = common.BytesToAddress(status.WithdrawalCredentials[12:])
+
+		// Make sure the validator can take a top-up
+		errMsg := getTopUpError(status, c.amount)
+		if errMsg != "" {
+			info.Error = fmt.Sprintf("validator %s %s", pubkey.Hex(), errMsg)
+			continue
+		}
+
+		// Generate the deposit data
+		depositDatas, err := swcommon.GenerateTopUpDepositData(ctx, logger, signer, res, status.WithdrawalCredentials, c.amount, []beacon.ValidatorPubkey{pubkey})
+		if err != nil {
+			info.Error = fmt.Sprintf("error generating deposit data for validator %s: %s", pubkey.Hex(), err.Error())
+			continue
+		}
+		info.DepositData = &depositDatas[0]
+	}
+	return types.ResponseStatus_Success, nil
+}
+
+// Check if a validator can be topped up with the provided amount, returning the reason it can't if not
+func getTopUpError(status beacon.ValidatorStatus, amount uint64) string {
+	if !swcommon.IsCompoundingWithdrawalCreds(status.WithdrawalCredentials) {
+		return "does not have compounding (0x02) withdrawal credentials"
+	}
+	if status.Slashed {
+		return "has been slashed"
+	}
+	switch status.Status {
+	case beacon.ValidatorState_PendingInitialized, beacon.ValidatorState_PendingQueued, beacon.ValidatorState_ActiveOngoing:
+	default:
+		return "is exiting or has already exited"
+	}
+	if status.Balance+amount > swcommon.MaxCompoundingBalance {
+		return fmt.Sprintf("would have a balance of %d gwei, more than the max effective balance of %d gwei", status.Balance+amount, swcommon.MaxCompoundingBalance)
+	}
+	return ""
+}
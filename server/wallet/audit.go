@@ -0,0 +1,101 @@
+package swwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletAuditContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletAuditContextFactory) Create(body api.WalletAuditBody) (*walletAuditContext, error) {
+	c := &walletAuditContext{
+		handler: f.handler,
+		body:    body,
+	}
+	inputErrs := []error{}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletAuditContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessPost[*walletAuditContext, api.WalletAuditBody, api.WalletAuditData](
+		router, "audit", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletAuditContext struct {
+	handler *WalletHandler
+	body    api.WalletAuditBody
+}
+
+func (c *walletAuditContext) PrepareData(data *api.WalletAuditData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	client := sp.GetHyperdriveClient()
+	w := sp.GetWallet()
+	keyMgr := sp.GetAvailableKeyManager()
+	ctx := c.handler.ctx
+	logger := c.handler.logger.Logger
+
+	// Requirements
+	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+
+	// Get the keys registered with NodeSet
+	registeredPubkeys, err := swcommon.GetRegisteredPubkeys(sp)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+	data.NotRegisteredWithNodeSet = (registeredPubkeys == nil)
+
+	// Check the keys
+	vcName, keys, err := w.AuditKeys(registeredPubkeys, c.body.Repair)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error auditing keys: %w", err)
+	}
+	data.VcName = vcName
+	data.Keys = keys
+	for _, key := range keys {
+		data.IssueCount += len(key.Issues)
+		if key.Repaired {
+			data.RepairedCount++
+		}
+	}
+	logger.Info("Audited validator keys", "keys", len(keys), "issues", data.IssueCount, "repaired", data.RepairedCount)
+
+	if data.RepairedCount == 0 {
+		return types.ResponseStatus_Success, nil
+	}
+
+	// Reload the private keys for any keys that were repaired
+	keyMgr.LoadPrivateKeys(logger)
+
+	// Restart the VC so it picks up the repaired keystores
+	if c.body.RestartVc {
+		_, err = client.Service.RestartContainer(string(swconfig.ContainerID_StakewiseValidator))
+		if err != nil {
+			return types.ResponseStatus_Error, err
+		}
+		data.VcRestarted = true
+	}
+	return types.ResponseStatus_Success, nil
+}
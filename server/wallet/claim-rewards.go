@@ -0,0 +1,140 @@
+package swwallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	"github.com/nodeset-org/hyperdrive-daemon/module-utils/services"
+	swcontracts "github.com/nodeset-org/hyperdrive-stakewise/common/contracts"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	batch "github.com/rocket-pool/batch-query"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/eth/contracts"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletClaimRewardsContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletClaimRewardsContextFactory) Create(args url.Values) (*walletClaimRewardsContext, error) {
+	c := &walletClaimRewardsContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletClaimRewardsContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*walletClaimRewardsContext, api.WalletClaimRewardsData](
+		router, "claim-rewards", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletClaimRewardsContext struct {
+	handler *WalletHandler
+}
+
+func (c *walletClaimRewardsContext) PrepareData(data *api.WalletClaimRewardsData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	ec := sp.GetEthClient()
+	qMgr := sp.GetQueryManager()
+	txMgr := sp.GetTransactionManager()
+	res := sp.GetResources()
+	ctx := c.handler.ctx
+
+	// Requirements
+	err := sp.RequireWalletReady(walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+	err = sp.RequireEthClientSynced(ctx)
+	if err != nil {
+		if errors.Is(err, services.ErrExecutionClientNotSynced) {
+			return types.ResponseStatus_ClientsNotSynced, err
+		}
+		return types.ResponseStatus_Error, err
+	}
+	if res.SplitWarehouse == (common.Address{}) {
+		return types.ResponseStatus_Error, fmt.Errorf("no SplitWarehouse address is set for this network")
+	}
+	nodeAddress := walletStatus.Wallet.WalletAddress
+
+	// Create the bindings
+	splitWarehouse, err := swcontracts.NewSplitWarehouse(res.SplitWarehouse, ec, txMgr)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error creating SplitWarehouse binding: %w", err)
+	}
+	vaults := res.GetEnabledVaults()
+	data.Vaults = make([]api.WalletClaimRewardsVaultInfo, len(vaults))
+	for i, vault := range vaults {
+		vaultToken, err := contracts.NewErc20Contract(vault, ec, qMgr, txMgr, nil)
+		if err != nil {
+			return types.ResponseStatus_Error, fmt.Errorf("error creating token binding for vault [%s]: %w", vault.Hex(), err)
+		}
+		data.Vaults[i] = api.WalletClaimRewardsVaultInfo{
+			Vault:       vault,
+			TokenName:   vaultToken.Name(),
+			TokenSymbol: vaultToken.Symbol(),
+		}
+	}
+
+	// Get the native token and the claimable balances
+	err = qMgr.Query(func(mc *batch.MultiCaller) error {
+		splitWarehouse.NativeToken(mc, &data.NativeToken)
+		return nil
+	}, nil)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting SplitWarehouse native token: %w", err)
+	}
+	err = qMgr.Query(func(mc *batch.MultiCaller) error {
+		for i := range data.Vaults {
+			vault := &data.Vaults[i]
+			splitWarehouse.BalanceOf(mc, &vault.WithdrawableToken, nodeAddress, vault.Vault)
+		}
+		splitWarehouse.BalanceOf(mc, &data.WithdrawableNativeToken, nodeAddress, data.NativeToken)
+		return nil
+	}, nil)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting SplitWarehouse balances: %w", err)
+	}
+
+	// Only withdraw the tokens that actually have a balance
+	tokens := []common.Address{}
+	amounts := []*big.Int{}
+	for _, vault := range data.Vaults {
+		if vault.WithdrawableToken.Sign() > 0 {
+			tokens = append(tokens, vault.Vault)
+			amounts = append(amounts, vault.WithdrawableToken)
+		}
+	}
+	if data.WithdrawableNativeToken.Sign() > 0 {
+		tokens = append(tokens, data.NativeToken)
+		amounts = append(amounts, data.WithdrawableNativeToken)
+	}
+	data.CanClaim = len(tokens) > 0
+	if !data.CanClaim {
+		return types.ResponseStatus_Success, nil
+	}
+
+	// Build and simulate the withdrawal
+	data.TxInfo, err = splitWarehouse.Withdraw(nodeAddress, tokens, amounts, opts)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting TX info for Withdraw: %w", err)
+	}
+	return types.ResponseStatus_Success, nil
+}
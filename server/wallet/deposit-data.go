@@ -0,0 +1,135 @@
+package swwallet
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletDepositDataContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletDepositDataContextFactory) Create(args url.Values) (*walletDepositDataContext, error) {
+	c := &walletDepositDataContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{
+		server.ValidateOptionalArg("vault", args, input.ValidateAddress, &c.vault, &c.hasVault),
+		server.ValidateOptionalArgBatch("pubkeys", args, pubkeyLimit, input.ValidatePubkey, &c.pubkeys, &c.hasPubkeys),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletDepositDataContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*walletDepositDataContext, api.WalletDepositDataData](
+		router, "deposit-data", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletDepositDataContext struct {
+	handler    *WalletHandler
+	vault      common.Address
+	hasVault   bool
+	pubkeys    []beacon.ValidatorPubkey
+	hasPubkeys bool
+}
+
+func (c *walletDepositDataContext) PrepareData(data *api.WalletDepositDataData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	keyMgr := sp.GetAvailableKeyManager()
+	ctx := c.handler.ctx
+	logger := c.handler.logger.Logger
+
+	// Requirements
+	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+
+	// Get the keys for the vault
+	vault := sp.GetResources().Vault
+	if c.hasVault {
+		vault = c.vault
+	}
+	data.Vault = vault
+	vaultKeys, otherVaultKeys := keyMgr.GetKeysForVault(logger, vault)
+
+	// Sort them by whether they can be used
+	keyMap := map[beacon.ValidatorPubkey]*swcommon.AvailableKey{}
+	for _, key := range vaultKeys {
+		keyMap[key.PublicKey] = key
+	}
+	otherVaultKeyMap := map[beacon.ValidatorPubkey]bool{}
+	for _, key := range otherVaultKeys {
+		otherVaultKeyMap[key.PublicKey] = true
+	}
+	requestedKeys := vaultKeys
+	data.KeysReservedForOtherVault = []beacon.ValidatorPubkey{}
+	data.UnknownPubkeys = []beacon.ValidatorPubkey{}
+	if c.hasPubkeys {
+		requestedKeys = []*swcommon.AvailableKey{}
+		for _, pubkey := range c.pubkeys {
+			key, exists := keyMap[pubkey]
+			if exists {
+				requestedKeys = append(requestedKeys, key)
+			} else if otherVaultKeyMap[pubkey] {
+				data.KeysReservedForOtherVault = append(data.KeysReservedForOtherVault, pubkey)
+			} else {
+				data.UnknownPubkeys = append(data.UnknownPubkeys, pubkey)
+			}
+		}
+	} else {
+		for _, key := range otherVaultKeys {
+			data.KeysReservedForOtherVault = append(data.KeysReservedForOtherVault, key.PublicKey)
+		}
+	}
+	pubkeys := []beacon.ValidatorPubkey{}
+	data.KeysMissingPrivateKey = []beacon.ValidatorPubkey{}
+	for _, key := range requestedKeys {
+		if !key.HasSigningKey {
+			data.KeysMissingPrivateKey = append(data.KeysMissingPrivateKey, key.PublicKey)
+			continue
+		}
+		pubkeys = append(pubkeys, key.PublicKey)
+	}
+
+	// Get the deposit data from the cache
+	data.DepositData, err = sp.GetDepositDataCache().GetDepositData(ctx, logger, vault, pubkeys)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting deposit data: %w", err)
+	}
+	if len(data.DepositData) > 0 {
+		data.ValidatorsRoot, err = swcommon.ComputeValidatorsRoot(data.DepositData)
+		if err != nil {
+			return types.ResponseStatus_Error, fmt.Errorf("error computing validators root: %w", err)
+		}
+
+		// Keep the list as it was exported, since the relay needs it to prove keys against the root once the vault has it
+		err = sp.GetDepositDataCache().SaveExport(vault, data.ValidatorsRoot, data.DepositData)
+		if err != nil {
+			return types.ResponseStatus_Error, fmt.Errorf("error saving exported deposit data: %w", err)
+		}
+	}
+	return types.ResponseStatus_Success, nil
+}
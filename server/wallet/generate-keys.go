@@ -6,6 +6,7 @@ import (
 	"net/url"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
 	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
@@ -32,6 +33,7 @@ func (f *walletGenerateKeysContextFactory) Create(args url.Values) (*walletGener
 	inputErrs := []error{
 		server.ValidateArg("count", args, input.ValidateUint, &c.count),
 		server.ValidateArg("restart-vc", args, input.ValidateBool, &c.restartVc),
+		server.ValidateOptionalArg("vault", args, input.ValidateAddress, &c.vault, nil),
 	}
 	return c, errors.Join(inputErrs...)
 }
@@ -50,12 +52,14 @@ type walletGenerateKeysContext struct {
 	handler   *WalletHandler
 	count     uint64
 	restartVc bool
+	vault     common.Address
 }
 
 func (c *walletGenerateKeysContext) PrepareData(data *api.WalletGenerateKeysData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
 	sp := c.handler.serviceProvider
 	client := sp.GetHyperdriveClient()
 	wallet := sp.GetWallet()
+	keyMgr := sp.GetAvailableKeyManager()
 	ctx := c.handler.ctx
 
 	// Requirements
@@ -67,7 +71,7 @@ func (c *walletGenerateKeysContext) PrepareData(data *api.WalletGenerateKeysData
 	// Generate and save the keys
 	pubkeys := make([]beacon.ValidatorPubkey, c.count)
 	for i := 0; i < int(c.count); i++ {
-		key, err := wallet.GenerateNewValidatorKey()
+		key, err := wallet.GenerateNewValidatorKey(c.vault)
 		if err != nil {
 			return types.ResponseStatus_Error, fmt.Errorf("error generating validator key: %w", err)
 		}
@@ -75,6 +79,7 @@ func (c *walletGenerateKeysContext) PrepareData(data *api.WalletGenerateKeysData
 		pubkeys[i] = pubkey
 	}
 	data.Pubkeys = pubkeys
+	data.Pools = getKeyPools(keyMgr.GetPoolCounts())
 
 	// Restart the VC
 	if c.restartVc {
@@ -1,10 +1,12 @@
 package swwallet
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -21,10 +23,6 @@ import (
 	"github.com/rocket-pool/node-manager-core/wallet"
 )
 
-const (
-	validatorDepositCost float64 = 0.01
-)
-
 // ===============
 // === Factory ===
 // ===============
@@ -39,6 +37,7 @@ func (f *walletGetAvailableKeysContextFactory) Create(args url.Values) (*walletG
 	}
 	inputErrs := []error{
 		server.ValidateOptionalArg("lookback", args, input.ValidateBool, &c.doLookback, nil),
+		server.ValidateOptionalArg("vault", args, input.ValidateAddress, &c.vault, &c.hasVault),
 	}
 	return c, errors.Join(inputErrs...)
 }
@@ -56,6 +55,8 @@ func (f *walletGetAvailableKeysContextFactory) RegisterRoute(router *mux.Router)
 type walletGetAvailableKeysContext struct {
 	handler    *WalletHandler
 	doLookback bool
+	vault      common.Address
+	hasVault   bool
 }
 
 func (c *walletGetAvailableKeysContext) PrepareData(data *api.WalletGetAvailableKeysData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
@@ -111,13 +112,20 @@ func (c *walletGetAvailableKeysContext) PrepareData(data *api.WalletGetAvailable
 	if keyMgr.RequiresLookbackScan(currentBlock) || c.doLookback {
 		scanOpts.DoLookbackScan = true
 	}
+	if c.hasVault {
+		scanOpts.Vault = &c.vault
+	}
 	goodKeys, badKeys, err := keyMgr.GetAvailableKeys(ctx, logger, depositRoot, currentBlock, scanOpts)
 	if err != nil {
 		return types.ResponseStatus_Error, fmt.Errorf("error getting available keys: %w", err)
 	}
+	availableCounts := map[common.Address]int{}
 	for _, key := range goodKeys {
 		data.AvailablePubkeys = append(data.AvailablePubkeys, key.PublicKey)
+		availableCounts[key.Vault]++
 	}
+	data.Pools = getKeyPools(keyMgr.GetPoolCounts())
+	data.AvailablePools = getKeyPools(availableCounts)
 	for key, reason := range badKeys {
 		switch reason {
 		case swcommon.IneligibleReason_NoPrivateKey:
@@ -130,6 +138,8 @@ func (c *walletGetAvailableKeysContext) PrepareData(data *api.WalletGetAvailable
 			data.KeysWithDepositEvents = append(data.KeysWithDepositEvents, key.PublicKey)
 		case swcommon.IneligibleReason_AlreadyUsedDepositRoot:
 			data.KeysUsedWithDepositRoot = append(data.KeysUsedWithDepositRoot, key.PublicKey)
+		case swcommon.IneligibleReason_ReservedForOtherVault:
+			data.KeysReservedForOtherVault = append(data.KeysReservedForOtherVault, key.PublicKey)
 		default:
 			logger.Warn(
 				"Key is not available for an unknown reason",
@@ -147,8 +157,8 @@ func (c *walletGetAvailableKeysContext) PrepareData(data *api.WalletGetAvailable
 	data.Balance = eth.WeiToEth(balance)
 
 	// Subtract the cost of the pending keys
-	data.EthPerKey = validatorDepositCost
-	costPerKeyBig := eth.EthToWei(validatorDepositCost)
+	data.EthPerKey = swcommon.ValidatorDepositCost
+	costPerKeyBig := eth.EthToWei(swcommon.ValidatorDepositCost)
 	pendingCountBig := big.NewInt(int64(len(goodKeys)))
 	pendingCost := new(big.Int).Mul(costPerKeyBig, pendingCountBig)
 	remainingBalance := new(big.Int).Sub(balance, pendingCost)
@@ -161,3 +171,19 @@ func (c *walletGetAvailableKeysContext) PrepareData(data *api.WalletGetAvailable
 
 	return types.ResponseStatus_Success, nil
 }
+
+// Convert a map of vault key counts into a list of key pools, sorted by vault address.
+// The pool of keys that aren't reserved for any vault is included under the zero address.
+func getKeyPools(counts map[common.Address]int) []api.WalletKeyPoolInfo {
+	pools := make([]api.WalletKeyPoolInfo, 0, len(counts))
+	for vault, count := range counts {
+		pools = append(pools, api.WalletKeyPoolInfo{
+			Vault:    vault,
+			KeyCount: count,
+		})
+	}
+	sort.Slice(pools, func(i int, j int) bool {
+		return bytes.Compare(pools[i].Vault[:], pools[j].Vault[:]) < 0
+	})
+	return pools
+}
@@ -23,10 +23,18 @@ func NewWalletHandler(logger *log.Logger, ctx context.Context, serviceProvider s
 		serviceProvider: serviceProvider,
 	}
 	h.factories = []server.IContextFactory{
+		&walletAuditContextFactory{h},
+		&walletClaimRewardsContextFactory{h},
+		&walletDepositDataContextFactory{h},
 		&walletGenerateKeysContextFactory{h},
 		&walletInitializeContextFactory{h},
 		&walletGetAvailableKeysContextFactory{h},
+		&walletKeyLifecycleContextFactory{h},
+		&walletMoveKeysContextFactory{h},
+		&walletRecoverAllContextFactory{h},
 		&walletRecoverKeysContextFactory{h},
+		&walletRecoverKeysStatusContextFactory{h},
+		&walletRotateKeystorePasswordContextFactory{h},
 	}
 	return h
 }
@@ -0,0 +1,64 @@
+package swwallet
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletKeyLifecycleContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletKeyLifecycleContextFactory) Create(args url.Values) (*walletKeyLifecycleContext, error) {
+	c := &walletKeyLifecycleContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{
+		server.ValidateOptionalArgBatch("pubkeys", args, pubkeyLimit, input.ValidatePubkey, &c.pubkeys, &c.hasPubkeys),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletKeyLifecycleContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*walletKeyLifecycleContext, api.WalletKeyLifecycleData](
+		router, "key-lifecycle", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletKeyLifecycleContext struct {
+	handler    *WalletHandler
+	pubkeys    []beacon.ValidatorPubkey
+	hasPubkeys bool
+}
+
+func (c *walletKeyLifecycleContext) PrepareData(data *api.WalletKeyLifecycleData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	lifecycleMgr := sp.GetKeyLifecycleManager()
+
+	// Get all of the keys if none were requested
+	if !c.hasPubkeys {
+		data.Keys = lifecycleMgr.GetAllLifecycles()
+		data.UnknownPubkeys = []beacon.ValidatorPubkey{}
+		return types.ResponseStatus_Success, nil
+	}
+	data.Keys, data.UnknownPubkeys = lifecycleMgr.GetLifecycles(c.pubkeys)
+	return types.ResponseStatus_Success, nil
+}
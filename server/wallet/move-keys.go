@@ -0,0 +1,109 @@
+package swwallet
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/server"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/utils/input"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+const (
+	pubkeyLimit int = 100000 // Basically no limit
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletMoveKeysContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletMoveKeysContextFactory) Create(args url.Values) (*walletMoveKeysContext, error) {
+	c := &walletMoveKeysContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{
+		server.ValidateArgBatch("pubkeys", args, pubkeyLimit, input.ValidatePubkey, &c.pubkeys),
+		server.ValidateOptionalArg("vault", args, input.ValidateAddress, &c.vault, nil),
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletMoveKeysContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*walletMoveKeysContext, api.WalletMoveKeysData](
+		router, "move-keys", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletMoveKeysContext struct {
+	handler *WalletHandler
+	pubkeys []beacon.ValidatorPubkey
+	vault   common.Address
+}
+
+func (c *walletMoveKeysContext) PrepareData(data *api.WalletMoveKeysData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	keyMgr := sp.GetAvailableKeyManager()
+
+	// Make sure the keys can be used by the vault if they're being reserved for one
+	if c.vault != (common.Address{}) {
+		status, err := c.checkVault()
+		if err != nil {
+			return status, err
+		}
+	}
+
+	// Move the keys
+	err := keyMgr.MoveKeys(c.pubkeys, c.vault)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error moving keys: %w", err)
+	}
+	data.Pools = getKeyPools(keyMgr.GetPoolCounts())
+	return types.ResponseStatus_Success, nil
+}
+
+// Make sure the vault is one the node can provide validators for: it can't be disabled locally, and NodeSet has to know about it
+func (c *walletMoveKeysContext) checkVault() (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	res := sp.GetResources()
+	hd := sp.GetHyperdriveClient()
+
+	// Check if the vault has been disabled locally
+	vaultCfg := res.GetVault(c.vault)
+	if vaultCfg != nil && !vaultCfg.Enabled {
+		return types.ResponseStatus_InvalidArguments, fmt.Errorf("vault [%s] is disabled on this node", c.vault.Hex())
+	}
+
+	// Check if NodeSet lets the node use the vault
+	response, err := hd.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting vaults from nodeset: %w", err)
+	}
+	if response.Data.NotRegistered {
+		return types.ResponseStatus_Error, fmt.Errorf("node is not registered with nodeset")
+	}
+	if response.Data.InvalidPermissions {
+		return types.ResponseStatus_Error, fmt.Errorf("node does not have permission to use the vaults on deployment [%s]", res.DeploymentName)
+	}
+	for _, vaultInfo := range response.Data.Vaults {
+		if vaultInfo.Address == c.vault {
+			return types.ResponseStatus_Success, nil
+		}
+	}
+	return types.ResponseStatus_InvalidArguments, fmt.Errorf("vault [%s] is not a known vault on deployment [%s]", c.vault.Hex(), res.DeploymentName)
+}
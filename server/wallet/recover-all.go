@@ -0,0 +1,111 @@
+package swwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletRecoverAllContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletRecoverAllContextFactory) Create(body api.WalletRecoverAllBody) (*walletRecoverAllContext, error) {
+	c := &walletRecoverAllContext{
+		handler: f.handler,
+		body:    body,
+	}
+	inputErrs := []error{}
+	if body.Concurrency > swcommon.MaxKeyRecoveryConcurrency {
+		inputErrs = append(inputErrs, fmt.Errorf("concurrency can't be more than %d", swcommon.MaxKeyRecoveryConcurrency))
+	}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletRecoverAllContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessPost[*walletRecoverAllContext, api.WalletRecoverAllBody, api.WalletRecoverAllData](
+		router, "recover-all", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletRecoverAllContext struct {
+	handler *WalletHandler
+	body    api.WalletRecoverAllBody
+}
+
+func (c *walletRecoverAllContext) PrepareData(data *api.WalletRecoverAllData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	recoveryMgr := sp.GetKeyRecoveryManager()
+	signer := sp.GetValidatorSigner()
+	ctx := c.handler.ctx
+	logger := c.handler.logger.Logger
+
+	// Requirements
+	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+	err = sp.RequireRegisteredWithNodeSet(ctx)
+	if err != nil {
+		data.NotRegisteredWithNodeSet = true
+		return types.ResponseStatus_Success, err
+	}
+
+	// Get the keys registered with NodeSet
+	registeredPubkeys, err := swcommon.GetRegisteredPubkeys(sp)
+	if err != nil {
+		return types.ResponseStatus_Error, err
+	}
+	if registeredPubkeys == nil {
+		data.NotRegisteredWithNodeSet = true
+		return types.ResponseStatus_Success, nil
+	}
+	data.RegisteredPubkeys = registeredPubkeys
+
+	// Find the ones that don't have a key stored locally
+	storedPubkeys, err := signer.GetPubkeys(ctx)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error getting stored validator keys: %w", err)
+	}
+	stored := make(map[beacon.ValidatorPubkey]bool, len(storedPubkeys))
+	for _, pubkey := range storedPubkeys {
+		stored[pubkey] = true
+	}
+	data.MissingPubkeys = []beacon.ValidatorPubkey{}
+	for _, pubkey := range registeredPubkeys {
+		if !stored[pubkey] {
+			data.MissingPubkeys = append(data.MissingPubkeys, pubkey)
+		}
+	}
+	if len(data.MissingPubkeys) == 0 {
+		data.Progress = recoveryMgr.GetProgress()
+		return types.ResponseStatus_Success, nil
+	}
+	logger.Info("Found registered keys without a local keystore", "registered", len(registeredPubkeys), "missing", len(data.MissingPubkeys))
+
+	// Search for them in the background; the progress can be polled with the recover-keys status route
+	data.Progress, err = recoveryMgr.StartGapSearch(logger, data.MissingPubkeys, c.body.StartIndex, c.body.GapLimit, c.body.SearchLimit, c.body.Concurrency, c.body.RestartVc)
+	if errors.Is(err, swcommon.ErrKeyRecoveryRunning) {
+		return types.ResponseStatus_ResourceConflict, err
+	}
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error starting key recovery: %w", err)
+	}
+	return types.ResponseStatus_Success, nil
+}
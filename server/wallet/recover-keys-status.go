@@ -0,0 +1,49 @@
+package swwallet
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletRecoverKeysStatusContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletRecoverKeysStatusContextFactory) Create(args url.Values) (*walletRecoverKeysStatusContext, error) {
+	c := &walletRecoverKeysStatusContext{
+		handler: f.handler,
+	}
+	inputErrs := []error{}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletRecoverKeysStatusContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessGet[*walletRecoverKeysStatusContext, api.WalletRecoverKeysStatusData](
+		router, "recover-keys-status", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletRecoverKeysStatusContext struct {
+	handler *WalletHandler
+}
+
+func (c *walletRecoverKeysStatusContext) PrepareData(data *api.WalletRecoverKeysStatusData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	data.Progress = sp.GetKeyRecoveryManager().GetProgress()
+	return types.ResponseStatus_Success, nil
+}
@@ -7,8 +7,8 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/gorilla/mux"
 	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
 	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
-	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
 	"github.com/rocket-pool/node-manager-core/api/types"
 	"github.com/rocket-pool/node-manager-core/wallet"
 )
@@ -27,6 +27,17 @@ func (f *walletRecoverKeysContextFactory) Create(body api.WalletRecoverKeysBody)
 		body:    body,
 	}
 	inputErrs := []error{}
+	if !body.Resume {
+		if len(body.Pubkeys) == 0 {
+			inputErrs = append(inputErrs, fmt.Errorf("no keys to search for"))
+		}
+		if body.Count == 0 {
+			inputErrs = append(inputErrs, fmt.Errorf("count must be greater than 0"))
+		}
+		if body.Concurrency > swcommon.MaxKeyRecoveryConcurrency {
+			inputErrs = append(inputErrs, fmt.Errorf("concurrency can't be more than %d", swcommon.MaxKeyRecoveryConcurrency))
+		}
+	}
 	return c, errors.Join(inputErrs...)
 }
 
@@ -47,9 +58,9 @@ type walletRecoverKeysContext struct {
 
 func (c *walletRecoverKeysContext) PrepareData(data *api.WalletRecoverKeysData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
 	sp := c.handler.serviceProvider
-	client := sp.GetHyperdriveClient()
-	wallet := sp.GetWallet()
+	recoveryMgr := sp.GetKeyRecoveryManager()
 	ctx := c.handler.ctx
+	logger := c.handler.logger.Logger
 
 	// Requirements
 	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
@@ -62,20 +73,22 @@ func (c *walletRecoverKeysContext) PrepareData(data *api.WalletRecoverKeysData,
 		return types.ResponseStatus_Success, err
 	}
 
-	// Recover the keys
-	keys, lastIndexSearched, err := wallet.RecoverValidatorKeys(c.body.Pubkeys, c.body.StartIndex, c.body.Count, c.body.SearchLimit)
-	if err != nil {
-		return types.ResponseStatus_Error, fmt.Errorf("error recovering validator keys: %w", err)
+	// Start the recovery in the background; its progress can be polled with the status route
+	var progress api.KeyRecoveryProgress
+	if c.body.Resume {
+		progress, err = recoveryMgr.Resume(logger)
+	} else {
+		progress, err = recoveryMgr.Start(logger, c.body.Pubkeys, c.body.StartIndex, c.body.Count, c.body.SearchLimit, c.body.Concurrency, c.body.RestartVc)
 	}
-	data.Keys = keys
-	data.SearchEnd = lastIndexSearched
-
-	// Restart the VC
-	if c.body.RestartVc {
-		_, err = client.Service.RestartContainer(string(swconfig.ContainerID_StakewiseValidator))
-		if err != nil {
-			return types.ResponseStatus_Error, err
-		}
+	data.Progress = progress
+	if errors.Is(err, swcommon.ErrKeyRecoveryRunning) {
+		return types.ResponseStatus_ResourceConflict, err
+	}
+	if errors.Is(err, swcommon.ErrNoKeyRecoveryCheckpoint) {
+		return types.ResponseStatus_ResourceNotFound, err
+	}
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error starting key recovery: %w", err)
 	}
 	return types.ResponseStatus_Success, nil
 }
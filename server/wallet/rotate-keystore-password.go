@@ -0,0 +1,79 @@
+package swwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/gorilla/mux"
+	duserver "github.com/nodeset-org/hyperdrive-daemon/module-utils/server"
+	api "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/api/types"
+	"github.com/rocket-pool/node-manager-core/wallet"
+)
+
+// ===============
+// === Factory ===
+// ===============
+
+type walletRotateKeystorePasswordContextFactory struct {
+	handler *WalletHandler
+}
+
+func (f *walletRotateKeystorePasswordContextFactory) Create(body api.WalletRotateKeystorePasswordBody) (*walletRotateKeystorePasswordContext, error) {
+	c := &walletRotateKeystorePasswordContext{
+		handler: f.handler,
+		body:    body,
+	}
+	inputErrs := []error{}
+	return c, errors.Join(inputErrs...)
+}
+
+func (f *walletRotateKeystorePasswordContextFactory) RegisterRoute(router *mux.Router) {
+	duserver.RegisterQuerylessPost[*walletRotateKeystorePasswordContext, api.WalletRotateKeystorePasswordBody, api.WalletRotateKeystorePasswordData](
+		router, "rotate-keystore-password", f, f.handler.logger.Logger, f.handler.serviceProvider,
+	)
+}
+
+// ===============
+// === Context ===
+// ===============
+
+type walletRotateKeystorePasswordContext struct {
+	handler *WalletHandler
+	body    api.WalletRotateKeystorePasswordBody
+}
+
+func (c *walletRotateKeystorePasswordContext) PrepareData(data *api.WalletRotateKeystorePasswordData, walletStatus wallet.WalletStatus, opts *bind.TransactOpts) (types.ResponseStatus, error) {
+	sp := c.handler.serviceProvider
+	client := sp.GetHyperdriveClient()
+	w := sp.GetWallet()
+	ctx := c.handler.ctx
+	logger := c.handler.logger.Logger
+
+	// Requirements
+	err := sp.RequireStakewiseWalletReady(ctx, walletStatus)
+	if err != nil {
+		return types.ResponseStatus_WalletNotReady, err
+	}
+
+	// Re-encrypt the keystores
+	result, err := w.RotateKeystorePassword(c.body.RotateVc)
+	if err != nil {
+		return types.ResponseStatus_Error, fmt.Errorf("error rotating keystore password: %w", err)
+	}
+	data.Pubkeys = result.Pubkeys
+	data.VcKeystoreCount = result.VcKeystoreCount
+	logger.Info("Rotated keystore password", "keystores", len(result.Pubkeys), "vcKeystores", result.VcKeystoreCount)
+
+	// Restart the VC so it reads the new passwords
+	if c.body.RestartVc {
+		_, err = client.Service.RestartContainer(string(swconfig.ContainerID_StakewiseValidator))
+		if err != nil {
+			return types.ResponseStatus_Error, err
+		}
+		data.VcRestarted = true
+	}
+	return types.ResponseStatus_Success, nil
+}
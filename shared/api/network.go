@@ -2,6 +2,7 @@ package swapi
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -20,3 +21,76 @@ type NetworkStatusData struct {
 	InvalidPermissions       bool                `json:"invalidPermissions"`
 	Vaults                   []*NetworkVaultInfo `json:"vaults"`
 }
+
+// The kind of change made to the StakeWise Keeper's oracle config
+type OracleConfigChangeType string
+
+const (
+	// A new config was published to IPFS
+	OracleConfigChangeType_ConfigUpdated OracleConfigChangeType = "config_updated"
+
+	// An oracle was added to the oracle set
+	OracleConfigChangeType_OracleAdded OracleConfigChangeType = "oracle_added"
+
+	// An oracle was removed from the oracle set
+	OracleConfigChangeType_OracleRemoved OracleConfigChangeType = "oracle_removed"
+
+	// The number of oracle signatures required for rewards or validator approvals changed
+	OracleConfigChangeType_ThresholdsUpdated OracleConfigChangeType = "thresholds_updated"
+)
+
+// The StakeWise Keeper's oracle config as of the last settled block the daemon scanned
+type OracleConfig struct {
+	Keeper               common.Address   `json:"keeper"`
+	ConfigIpfsHash       string           `json:"configIpfsHash"`
+	ConfigBlock          uint64           `json:"configBlock"`
+	Oracles              []common.Address `json:"oracles"`
+	TotalOracles         uint64           `json:"totalOracles"`
+	RewardsMinOracles    uint64           `json:"rewardsMinOracles"`
+	ValidatorsMinOracles uint64           `json:"validatorsMinOracles"`
+	ScannedBlock         uint64           `json:"scannedBlock"`
+}
+
+// A single change to the StakeWise Keeper's oracle config
+type OracleConfigChange struct {
+	Type                 OracleConfigChangeType `json:"type"`
+	Time                 time.Time              `json:"time"`
+	BlockNumber          uint64                 `json:"blockNumber"`
+	TxHash               common.Hash            `json:"txHash"`
+	ConfigIpfsHash       string                 `json:"configIpfsHash,omitempty"`
+	Oracle               *common.Address        `json:"oracle,omitempty"`
+	RewardsMinOracles    uint64                 `json:"rewardsMinOracles,omitempty"`
+	ValidatorsMinOracles uint64                 `json:"validatorsMinOracles,omitempty"`
+}
+
+type NetworkOracleConfigData struct {
+	NotScanned bool                 `json:"notScanned"`
+	Config     OracleConfig         `json:"config"`
+	Changes    []OracleConfigChange `json:"changes"`
+}
+
+// The on-chain health of a vault, along with an APR estimate over the configured block window
+type NetworkVaultReport struct {
+	Name               string         `json:"name"`
+	Address            common.Address `json:"address"`
+	TotalAssets        *big.Int       `json:"totalAssets"`
+	TotalShares        *big.Int       `json:"totalShares"`
+	ExchangeRate       *big.Int       `json:"exchangeRate"`
+	QueuedShares       *big.Int       `json:"queuedShares"`
+	TotalExitingAssets *big.Int       `json:"totalExitingAssets"`
+	Capacity           *big.Int       `json:"capacity"`
+	FeePercent         uint16         `json:"feePercent"`
+	WithdrawableAssets *big.Int       `json:"withdrawableAssets"`
+	AprAvailable       bool           `json:"aprAvailable"`
+	AprStartBlock      uint64         `json:"aprStartBlock,omitempty"`
+	Apr                float64        `json:"apr"`
+	AprError           string         `json:"aprError,omitempty"`
+}
+
+type NetworkVaultReportData struct {
+	NotRegisteredWithNodeSet bool                  `json:"notRegisteredWithNodeSet"`
+	InvalidPermissions       bool                  `json:"invalidPermissions"`
+	Block                    uint64                `json:"block"`
+	AprWindowStartBlock      uint64                `json:"aprWindowStartBlock"`
+	Vaults                   []*NetworkVaultReport `json:"vaults"`
+}
@@ -1,6 +1,13 @@
 package swapi
 
-import swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+)
 
 type ServiceGetResourcesData struct {
 	Resources *swconfig.MergedResources `json:"resources"`
@@ -17,3 +24,40 @@ type ServiceGetConfigData struct {
 type ServiceVersionData struct {
 	Version string `json:"version"`
 }
+
+// A record of a single validators request from the StakeWise Operator to the relay
+type RelayAuditRecord struct {
+	// When the request was received
+	Time time.Time `json:"time"`
+
+	// The vault the validators were requested for, after defaulting to the primary vault
+	Vault common.Address `json:"vault"`
+
+	// The raw body of the request
+	Request json.RawMessage `json:"request"`
+
+	// The Beacon deposit root the keys were checked against, if the request got that far
+	DepositRoot *common.Hash `json:"depositRoot,omitempty"`
+
+	// The keys that were chosen for the request
+	Keys []beacon.ValidatorPubkey `json:"keys"`
+
+	// The validators manager signature from NodeSet, if one was provided
+	Signature string `json:"signature,omitempty"`
+
+	// The error that stopped the request, if there was one
+	Error string `json:"error,omitempty"`
+
+	// The HTTP status code the relay responded with
+	StatusCode int `json:"statusCode"`
+
+	// How long the whole request took, in milliseconds
+	DurationMs int64 `json:"durationMs"`
+
+	// How long each stage of the request took, in milliseconds
+	Timings map[string]int64 `json:"timings"`
+}
+
+type ServiceRelayHistoryData struct {
+	Records []RelayAuditRecord `json:"records"`
+}
@@ -1,21 +1,43 @@
 package swapi
 
 import (
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/rocket-pool/node-manager-core/beacon"
 )
 
 type ValidatorExitInfo struct {
-	Pubkey    beacon.ValidatorPubkey    `json:"pubkey"`
-	Index     uint64                    `json:"index"`
-	Signature beacon.ValidatorSignature `json:"signature"`
+	Pubkey          beacon.ValidatorPubkey    `json:"pubkey"`
+	Index           uint64                    `json:"index"`
+	Status          beacon.ValidatorState     `json:"status"`
+	PreflightErrors []string                  `json:"preflightErrors"`
+	Signature       beacon.ValidatorSignature `json:"signature"`
+	Broadcast       bool                      `json:"broadcast"`
+	Error           string                    `json:"error"`
 }
 
 type ValidatorExitData struct {
 	Epoch     uint64              `json:"epoch"`
+	DryRun    bool                `json:"dryRun"`
 	ExitInfos []ValidatorExitInfo `json:"exitInfos"`
 }
 
+type ValidatorTopUpInfo struct {
+	Pubkey      beacon.ValidatorPubkey      `json:"pubkey"`
+	Index       string                      `json:"index"`
+	Status      beacon.ValidatorState       `json:"status"`
+	Balance     uint64                      `json:"balance"`
+	Vault       common.Address              `json:"vault"`
+	DepositData *beacon.ExtendedDepositData `json:"depositData"`
+	Error       string                      `json:"error"`
+}
+
+type ValidatorTopUpData struct {
+	Amount uint64               `json:"amount"`
+	TopUps []ValidatorTopUpInfo `json:"topUps"`
+}
+
 type ValidatorInfo struct {
 	Pubkey         beacon.ValidatorPubkey `json:"pubkey"`
 	HasBeaconIndex bool                   `json:"hasBeaconIndex"`
@@ -36,3 +58,100 @@ type ValidatorStatusData struct {
 	InvalidPermissions       bool         `json:"invalidPermissions"`
 	Vaults                   []*VaultInfo `json:"vaults"`
 }
+
+// A voluntary exit that has been signed ahead of time so it can be broadcast later without the validator key
+type PresignedExit struct {
+	Pubkey    beacon.ValidatorPubkey    `json:"pubkey"`
+	Index     string                    `json:"index"`
+	Epoch     uint64                    `json:"epoch"`
+	Signature beacon.ValidatorSignature `json:"signature"`
+}
+
+// The decrypted contents of an exit archive
+type ExitArchive struct {
+	Version        int             `json:"version"`
+	DeploymentName string          `json:"deploymentName"`
+	Created        time.Time       `json:"created"`
+	Exits          []PresignedExit `json:"exits"`
+}
+
+// An exit archive that has been encrypted with a password, in the same format as an EIP-2335 keystore
+type EncryptedExitArchive struct {
+	Version int            `json:"version"`
+	Crypto  map[string]any `json:"crypto"`
+}
+
+type ValidatorExportExitsBody struct {
+	Password string `json:"password"`
+}
+
+type ValidatorExportExitsData struct {
+	Archive         EncryptedExitArchive     `json:"archive"`
+	Pubkeys         []beacon.ValidatorPubkey `json:"pubkeys"`
+	InactivePubkeys []beacon.ValidatorPubkey `json:"inactivePubkeys"`
+}
+
+type ValidatorBroadcastExitsBody struct {
+	Password string                   `json:"password"`
+	Archive  EncryptedExitArchive     `json:"archive"`
+	Pubkeys  []beacon.ValidatorPubkey `json:"pubkeys"`
+}
+
+type ValidatorBroadcastExitResult struct {
+	Pubkey beacon.ValidatorPubkey `json:"pubkey"`
+	Index  string                 `json:"index"`
+	Error  string                 `json:"error"`
+}
+
+type ValidatorBroadcastExitsData struct {
+	Results        []ValidatorBroadcastExitResult `json:"results"`
+	MissingPubkeys []beacon.ValidatorPubkey       `json:"missingPubkeys"`
+}
+
+// The length of the periods that validator rewards are grouped into
+type PerformancePeriod string
+
+const (
+	// Group rewards by the interval between each pair of snapshots.
+	// Snapshots are taken by the task loop rather than on every epoch, so an interval can span more than one epoch.
+	PerformancePeriod_Interval PerformancePeriod = "interval"
+
+	// Group rewards by UTC day
+	PerformancePeriod_Day PerformancePeriod = "day"
+)
+
+// The rewards a validator earned over one period, in gwei
+type ValidatorPeriodRewards struct {
+	Start      time.Time `json:"start"`
+	StartEpoch uint64    `json:"startEpoch"`
+	EndEpoch   uint64    `json:"endEpoch"`
+	Rewards    int64     `json:"rewards"`
+	Decreases  int       `json:"decreases"`
+}
+
+// A validator's performance over the requested window, estimated from the daemon's balance snapshots.
+// Balances, deltas, and rewards are in gwei.
+type ValidatorPerformance struct {
+	Pubkey            beacon.ValidatorPubkey   `json:"pubkey"`
+	Index             string                   `json:"index"`
+	State             beacon.ValidatorState    `json:"state"`
+	Balance           uint64                   `json:"balance"`
+	Snapshots         int                      `json:"snapshots"`
+	FirstEpoch        uint64                   `json:"firstEpoch"`
+	LastEpoch         uint64                   `json:"lastEpoch"`
+	BalanceDelta      int64                    `json:"balanceDelta"`
+	Withdrawn         uint64                   `json:"withdrawn"`
+	Rewards           int64                    `json:"rewards"`
+	ActiveIntervals   int                      `json:"activeIntervals"`
+	Decreases         int                      `json:"decreases"`
+	LastDecreaseEpoch uint64                   `json:"lastDecreaseEpoch,omitempty"`
+	Effectiveness     float64                  `json:"effectiveness"`
+	PeriodRewards     []ValidatorPeriodRewards `json:"periodRewards"`
+}
+
+type ValidatorPerformanceData struct {
+	Period         PerformancePeriod        `json:"period"`
+	Since          time.Time                `json:"since"`
+	Validators     []ValidatorPerformance   `json:"validators"`
+	UnknownPubkeys []beacon.ValidatorPubkey `json:"unknownPubkeys"`
+}
@@ -2,6 +2,7 @@ package swapi
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/rocket-pool/node-manager-core/beacon"
@@ -12,19 +13,33 @@ type WalletInitializeData struct {
 	AccountAddress common.Address `json:"accountAddress"`
 }
 
+type WalletKeyPoolInfo struct {
+	Vault    common.Address `json:"vault"`
+	KeyCount int            `json:"keyCount"`
+}
+
 type WalletGenerateKeysData struct {
 	Pubkeys []beacon.ValidatorPubkey `json:"pubkeys"`
+	Pools   []WalletKeyPoolInfo      `json:"pools"`
+}
+
+type WalletMoveKeysData struct {
+	Pools []WalletKeyPoolInfo `json:"pools"`
+}
+
+type WalletClaimRewardsVaultInfo struct {
+	Vault             common.Address `json:"vault"`
+	TokenName         string         `json:"tokenName"`
+	TokenSymbol       string         `json:"tokenSymbol"`
+	WithdrawableToken *big.Int       `json:"withdrawableToken"`
 }
 
 type WalletClaimRewardsData struct {
-	NativeToken             common.Address       `json:"nativeToken"`
-	TokenName               string               `json:"tokenName"`
-	TokenSymbol             string               `json:"tokenSymbol"`
-	DistributableToken      *big.Int             `json:"distributableToken"`
-	DistributableEth        *big.Int             `json:"distributableEth"`
-	WithdrawableToken       *big.Int             `json:"withdrawableToken"`
-	WithdrawableNativeToken *big.Int             `json:"withdrawableNativeToken"`
-	TxInfo                  *eth.TransactionInfo `json:"txInfo"`
+	CanClaim                bool                          `json:"canClaim"`
+	NativeToken             common.Address                `json:"nativeToken"`
+	Vaults                  []WalletClaimRewardsVaultInfo `json:"vaults"`
+	WithdrawableNativeToken *big.Int                      `json:"withdrawableNativeToken"`
+	TxInfo                  *eth.TransactionInfo          `json:"txInfo"`
 }
 
 type WalletGetAvailableKeysData struct {
@@ -38,6 +53,18 @@ type WalletGetAvailableKeysData struct {
 	KeysAlreadyOnBeacon       []beacon.ValidatorPubkey `json:"keysAlreadyOnBeacon"`
 	KeysWithDepositEvents     []beacon.ValidatorPubkey `json:"keysWithDepositEvents"`
 	KeysUsedWithDepositRoot   []beacon.ValidatorPubkey `json:"keysUsedWithDepositRoot"`
+	KeysReservedForOtherVault []beacon.ValidatorPubkey `json:"keysReservedForOtherVault"`
+	Pools                     []WalletKeyPoolInfo      `json:"pools"`
+	AvailablePools            []WalletKeyPoolInfo      `json:"availablePools"`
+}
+
+type WalletDepositDataData struct {
+	Vault                     common.Address               `json:"vault"`
+	DepositData               []beacon.ExtendedDepositData `json:"depositData"`
+	ValidatorsRoot            common.Hash                  `json:"validatorsRoot"`
+	KeysMissingPrivateKey     []beacon.ValidatorPubkey     `json:"keysMissingPrivateKey"`
+	KeysReservedForOtherVault []beacon.ValidatorPubkey     `json:"keysReservedForOtherVault"`
+	UnknownPubkeys            []beacon.ValidatorPubkey     `json:"unknownPubkeys"`
 }
 
 type WalletRecoverKeysBody struct {
@@ -45,7 +72,9 @@ type WalletRecoverKeysBody struct {
 	StartIndex  uint64                   `json:"startIndex"`
 	Count       uint64                   `json:"count"`
 	SearchLimit uint64                   `json:"searchLimit"`
+	Concurrency uint64                   `json:"concurrency"`
 	RestartVc   bool                     `json:"restartVc"`
+	Resume      bool                     `json:"resume"`
 }
 
 type RecoveredKey struct {
@@ -53,8 +82,175 @@ type RecoveredKey struct {
 	Index  uint64                 `json:"index"`
 }
 
+// The state of a key recovery job
+type KeyRecoveryState string
+
+const (
+	// No key recovery has been run since the daemon started
+	KeyRecoveryState_Idle KeyRecoveryState = "idle"
+
+	// The key recovery is running in the background
+	KeyRecoveryState_Running KeyRecoveryState = "running"
+
+	// The key recovery stopped before it finished and can be resumed from its checkpoint
+	KeyRecoveryState_Interrupted KeyRecoveryState = "interrupted"
+
+	// The key recovery failed and can be resumed from its checkpoint
+	KeyRecoveryState_Failed KeyRecoveryState = "failed"
+
+	// The key recovery finished
+	KeyRecoveryState_Complete KeyRecoveryState = "complete"
+)
+
+// The progress of a key recovery job
+type KeyRecoveryProgress struct {
+	State       KeyRecoveryState `json:"state"`
+	StartIndex  uint64           `json:"startIndex"`
+	NextIndex   uint64           `json:"nextIndex"`
+	SearchEnd   uint64           `json:"searchEnd"`
+	GapLimit    uint64           `json:"gapLimit"`
+	GapEnd      uint64           `json:"gapEnd"`
+	Count       uint64           `json:"count"`
+	Concurrency uint64           `json:"concurrency"`
+	KeysToFind  int              `json:"keysToFind"`
+	Keys        []RecoveredKey   `json:"keys"`
+	Error       string           `json:"error"`
+	VcRestarted bool             `json:"vcRestarted"`
+	StartTime   time.Time        `json:"startTime"`
+	UpdateTime  time.Time        `json:"updateTime"`
+}
+
 type WalletRecoverKeysData struct {
+	NotRegisteredWithNodeSet bool                `json:"notRegisteredWithNodeSet"`
+	Progress                 KeyRecoveryProgress `json:"progress"`
+}
+
+type WalletRecoverAllBody struct {
+	StartIndex  uint64 `json:"startIndex"`
+	GapLimit    uint64 `json:"gapLimit"`
+	SearchLimit uint64 `json:"searchLimit"`
+	Concurrency uint64 `json:"concurrency"`
+	RestartVc   bool   `json:"restartVc"`
+}
+
+type WalletRecoverAllData struct {
+	NotRegisteredWithNodeSet bool                     `json:"notRegisteredWithNodeSet"`
+	RegisteredPubkeys        []beacon.ValidatorPubkey `json:"registeredPubkeys"`
+	MissingPubkeys           []beacon.ValidatorPubkey `json:"missingPubkeys"`
+	Progress                 KeyRecoveryProgress      `json:"progress"`
+}
+
+type WalletRecoverKeysStatusData struct {
+	Progress KeyRecoveryProgress `json:"progress"`
+}
+
+type WalletRotateKeystorePasswordBody struct {
+	RotateVc  bool `json:"rotateVc"`
+	RestartVc bool `json:"restartVc"`
+}
+
+type WalletRotateKeystorePasswordData struct {
+	Pubkeys         []beacon.ValidatorPubkey `json:"pubkeys"`
+	VcKeystoreCount int                      `json:"vcKeystoreCount"`
+	VcRestarted     bool                     `json:"vcRestarted"`
+}
+
+// A problem found with a validator key by a wallet audit
+type KeyAuditIssue string
+
+const (
+	// The key is in the available keys or registered with NodeSet, but there's no keystore for it in the StakeWise folder
+	KeyAuditIssue_MissingKeystore KeyAuditIssue = "missing-keystore"
+
+	// The key has a keystore in the StakeWise folder or is tracked, but the VC doesn't have a keystore for it
+	KeyAuditIssue_MissingVcKeystore KeyAuditIssue = "missing-vc-keystore"
+
+	// There's a keystore for the key, but it isn't in the available keys and isn't registered with NodeSet
+	KeyAuditIssue_Orphaned KeyAuditIssue = "orphaned"
+
+	// The key's keystore in the StakeWise folder can't be read or decrypted
+	KeyAuditIssue_Undecryptable KeyAuditIssue = "undecryptable"
+
+	// The key's VC keystore can't be read or decrypted
+	KeyAuditIssue_VcUndecryptable KeyAuditIssue = "vc-undecryptable"
+
+	// A keystore saved for the key decrypts to a different key
+	KeyAuditIssue_PubkeyMismatch KeyAuditIssue = "pubkey-mismatch"
+)
+
+// The results of auditing a single validator key
+type KeyAuditInfo struct {
+	Pubkey                beacon.ValidatorPubkey `json:"pubkey"`
+	InAvailableKeys       bool                   `json:"inAvailableKeys"`
+	HasKeystore           bool                   `json:"hasKeystore"`
+	HasVcKeystore         bool                   `json:"hasVcKeystore"`
+	RegisteredWithNodeSet bool                   `json:"registeredWithNodeSet"`
+	DerivationPath        string                 `json:"derivationPath"`
+	Issues                []KeyAuditIssue        `json:"issues"`
+	Errors                []string               `json:"errors"`
+	Repaired              bool                   `json:"repaired"`
+	RepairError           string                 `json:"repairError"`
+}
+
+type WalletAuditBody struct {
+	Repair    bool `json:"repair"`
+	RestartVc bool `json:"restartVc"`
+}
+
+type WalletAuditData struct {
 	NotRegisteredWithNodeSet bool           `json:"notRegisteredWithNodeSet"`
-	Keys                     []RecoveredKey `json:"keys"`
-	SearchEnd                uint64         `json:"searchEnd"`
+	VcName                   string         `json:"vcName"`
+	Keys                     []KeyAuditInfo `json:"keys"`
+	IssueCount               int            `json:"issueCount"`
+	RepairedCount            int            `json:"repairedCount"`
+	VcRestarted              bool           `json:"vcRestarted"`
+}
+
+// The stage of its lifecycle a validator key is in
+type KeyLifecycleState string
+
+const (
+	// The key was generated or recovered and added to the available key pool
+	KeyLifecycleState_Generated KeyLifecycleState = "generated"
+
+	// The key's deposit history was checked and it was cleared for use
+	KeyLifecycleState_Scanned KeyLifecycleState = "scanned"
+
+	// The key was offered to the StakeWise operator for a deposit
+	KeyLifecycleState_Offered KeyLifecycleState = "offered"
+
+	// The key was used in a deposit to the Beacon deposit contract
+	KeyLifecycleState_Deposited KeyLifecycleState = "deposited"
+
+	// The key's deposit is on Beacon but the validator isn't active yet
+	KeyLifecycleState_Pending KeyLifecycleState = "pending"
+
+	// The validator is active on Beacon
+	KeyLifecycleState_Active KeyLifecycleState = "active"
+
+	// The validator has exited Beacon
+	KeyLifecycleState_Exited KeyLifecycleState = "exited"
+)
+
+// A single transition in a key's lifecycle
+type KeyLifecycleEvent struct {
+	State          KeyLifecycleState `json:"state"`
+	Time           time.Time         `json:"time"`
+	Vault          common.Address    `json:"vault"`
+	DepositRoot    common.Hash       `json:"depositRoot"`
+	TxHash         common.Hash       `json:"txHash"`
+	BlockNumber    uint64            `json:"blockNumber"`
+	ValidatorIndex string            `json:"validatorIndex"`
+}
+
+// The lifecycle history of a validator key
+type KeyLifecycle struct {
+	Pubkey  beacon.ValidatorPubkey `json:"pubkey"`
+	State   KeyLifecycleState      `json:"state"`
+	History []KeyLifecycleEvent    `json:"history"`
+}
+
+type WalletKeyLifecycleData struct {
+	Keys           []KeyLifecycle           `json:"keys"`
+	UnknownPubkeys []beacon.ValidatorPubkey `json:"unknownPubkeys"`
 }
@@ -9,6 +9,26 @@ const (
 	OperatorContainerTagID string = "operatorContainerTag"
 	AdditionalOpFlagsID    string = "additionalOpFlags"
 	VerifyDepositRootsID   string = "verifyDepositRoots"
+	RelayPrepareKeysID     string = "relayPrepareKeys"
+	AutoGenerateKeysID     string = "autoGenerateKeys"
+	KeyLowWaterMarkID      string = "keyLowWaterMark"
+	KeyTargetCountID       string = "keyTargetCount"
+	AutoGenRestartVcID     string = "autoGenerateKeysRestartVc"
+	EnableMetricsID        string = "enableMetrics"
+	MetricsPortID          string = "metricsPort"
+	ScanConfirmationsID    string = "scanConfirmationDepth"
+	ScanUseFinalizedID     string = "scanUseFinalizedBlock"
+	VaultAprWindowID       string = "vaultAprWindow"
+	PerfRetentionDaysID    string = "performanceRetentionDays"
+	AlertWebhookUrlID      string = "alertWebhookUrl"
+	AlertSmtpServerID      string = "alertSmtpServer"
+	AlertSmtpUsernameID    string = "alertSmtpUsername"
+	AlertSmtpPasswordID    string = "alertSmtpPassword"
+	AlertSmtpFromID        string = "alertSmtpFrom"
+	AlertSmtpToID          string = "alertSmtpTo"
+	EnableAlertFileID      string = "enableAlertFile"
+	AlertSyncThresholdID   string = "alertClientSyncThreshold"
+	RemoteSignerUrlID      string = "remoteSignerUrl"
 
 	// Subconfig IDs
 	VcCommonID   string = "common"
@@ -15,6 +15,11 @@ import (
 )
 
 var (
+	// The address of the Splits v2 SplitWarehouse contract, which is deployed to the same address on every network.
+	// Used when a network's settings don't provide one.
+	// See https://docs.splits.org/core/warehouse
+	DefaultSplitWarehouse common.Address = common.HexToAddress("0x8fb66F38cF86A3d5e8768f8F1754A24A6c661Fb8")
+
 	// Mainnet resources for reference in testing
 	MainnetResourcesReference *StakeWiseResources = &StakeWiseResources{
 		Vault:        common.HexToAddress("0xE2AEECC76839692AEa35a8D119181b14ebf411c9"),
@@ -23,6 +28,11 @@ var (
 		// https://github.com/stakewise/sw-utils/blob/2d79588a64858d657f7b8a9a520b149727df0359/sw_utils/networks.py
 		Keeper:             common.HexToAddress("0x6B5815467da09DaA7DC83Db21c9239d98Bb487b5"),
 		KeeperGenesisBlock: big.NewInt(18470089),
+
+		// Block the Beacon deposit contract was deployed in
+		DepositContractGenesisBlock: big.NewInt(11052984),
+
+		SplitWarehouse: DefaultSplitWarehouse,
 	}
 
 	// Hoodi devnet resources for reference in testing
@@ -33,6 +43,11 @@ var (
 		// https://github.com/stakewise/sw-utils/blob/4a20b479bc84f1340fb34c02a89401e489537f53/sw_utils/networks.py
 		Keeper:             common.HexToAddress("0xA7D1Ac9D6F32B404C75626874BA56f7654c1dC0f"),
 		KeeperGenesisBlock: big.NewInt(94074),
+
+		// The deposit contract is part of Hoodi's genesis state
+		DepositContractGenesisBlock: big.NewInt(0),
+
+		SplitWarehouse: DefaultSplitWarehouse,
 	}
 
 	// Hoodi resources for reference in testing
@@ -43,9 +58,25 @@ var (
 		// https://github.com/stakewise/sw-utils/blob/4a20b479bc84f1340fb34c02a89401e489537f53/sw_utils/networks.py
 		Keeper:             common.HexToAddress("0xA7D1Ac9D6F32B404C75626874BA56f7654c1dC0f"),
 		KeeperGenesisBlock: big.NewInt(94074),
+
+		// The deposit contract is part of Hoodi's genesis state
+		DepositContractGenesisBlock: big.NewInt(0),
+
+		SplitWarehouse: DefaultSplitWarehouse,
 	}
 )
 
+// The kind of withdrawal credentials a vault's validators use
+type WithdrawalCredentialType string
+
+const (
+	// 0x01 credentials; balances above 32 ETH are swept to the vault
+	WithdrawalCredentialType_Eth1 WithdrawalCredentialType = "0x01"
+
+	// 0x02 credentials for compounding validators, which can hold up to 2048 ETH and accept top-ups
+	WithdrawalCredentialType_Compounding WithdrawalCredentialType = "0x02"
+)
+
 // Details for a StakeWise vault
 type StakeWiseVault struct {
 	// Whether or not the vault is enabled on the node
@@ -56,6 +87,13 @@ type StakeWiseVault struct {
 
 	// The fee recipient to use for the vault
 	FeeRecipient common.Address `yaml:"feeRecipient" json:"feeRecipient"`
+
+	// The kind of withdrawal credentials new validators for the vault use. Defaults to 0x01 if not set.
+	CredentialType WithdrawalCredentialType `yaml:"credentialType,omitempty" json:"credentialType,omitempty"`
+
+	// The amount to deposit for each new validator, in gwei. Defaults to 32 ETH if not set.
+	// 0x01 validators need exactly 32 ETH, and 0x02 validators need between 32 and 2048 ETH.
+	DepositAmount uint64 `yaml:"depositAmount,omitempty" json:"depositAmount,omitempty"`
 }
 
 // Network settings with a field for StakeWise-specific settings
@@ -90,6 +128,43 @@ type StakeWiseResources struct {
 
 	// The block on the network that StakeWise's keeper contract was deployed
 	KeeperGenesisBlock *big.Int `yaml:"keeperGenesisBlock" json:"keeperGenesisBlock"`
+
+	// The block on the network that the Beacon deposit contract was deployed.
+	// The deposit event index starts from here; if it isn't set, the index starts from block 0.
+	DepositContractGenesisBlock *big.Int `yaml:"depositContractGenesisBlock,omitempty" json:"depositContractGenesisBlock,omitempty"`
+
+	// The address of the SplitWarehouse contract that holds the node's share of the vault rewards until they're withdrawn.
+	// If this isn't set, DefaultSplitWarehouse is used.
+	SplitWarehouse common.Address `yaml:"splitWarehouse,omitempty" json:"splitWarehouse,omitempty"`
+
+	// Additional vaults the node can provide validators for, beyond the primary vault.
+	// Vaults in this list can be disabled to prevent the relay from providing validators for them.
+	// The primary vault can be included here to set its credential type and deposit amount.
+	Vaults []*StakeWiseVault `yaml:"vaults,omitempty" json:"vaults,omitempty"`
+}
+
+// Get the locally configured vault with the provided address, or nil if it isn't in the vault list
+func (r *StakeWiseResources) GetVault(address common.Address) *StakeWiseVault {
+	for _, vault := range r.Vaults {
+		if vault.Address == address {
+			return vault
+		}
+	}
+	return nil
+}
+
+// Get the addresses of the vaults the node is enabled for: the primary vault, unless it's disabled in the vault list, followed by every enabled vault in the list
+func (r *StakeWiseResources) GetEnabledVaults() []common.Address {
+	vaults := []common.Address{}
+	if primary := r.GetVault(r.Vault); primary == nil || primary.Enabled {
+		vaults = append(vaults, r.Vault)
+	}
+	for _, vault := range r.Vaults {
+		if vault.Enabled && vault.Address != r.Vault {
+			vaults = append(vaults, vault.Address)
+		}
+	}
+	return vaults
 }
 
 // A merged set of general resources and StakeWise-specific resources for the selected network
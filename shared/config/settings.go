@@ -1,20 +1,31 @@
 package swconfig
 
 const (
-	ModuleName           string = "stakewise"
-	ShortModuleName      string = "sw"
-	DaemonBaseRoute      string = ModuleName
-	ApiVersion           string = "1"
-	ApiClientRoute       string = DaemonBaseRoute + "/api/v" + ApiVersion
-	WalletFilename       string = "wallet.json"
-	PasswordFilename     string = "password.txt"
-	KeystorePasswordFile string = "secret.txt"
-	DepositDataFile      string = "deposit-data.json"
-	AvailableKeysFile    string = "available-keys.json"
-	OracleManagerFile    string = "oracle-data.json"
-	DefaultApiPort       uint16 = 8180
-	RelayLogName         string = "relay.log"
-	DefaultRelayPort     uint16 = 18180
+	ModuleName              string = "stakewise"
+	ShortModuleName         string = "sw"
+	DaemonBaseRoute         string = ModuleName
+	ApiVersion              string = "1"
+	ApiClientRoute          string = DaemonBaseRoute + "/api/v" + ApiVersion
+	WalletFilename          string = "wallet.json"
+	PasswordFilename        string = "password.txt"
+	KeystorePasswordFile    string = "secret.txt"
+	DepositDataFile         string = "deposit-data.json"
+	AvailableKeysFile       string = "available-keys.json"
+	DepositDataCacheFile    string = "deposit-data-cache.json"
+	OracleManagerFile       string = "oracle-data.json"
+	DepositEventIndexFile   string = "deposit-event-index.json"
+	DepositEventRecordsFile string = "deposit-events.bin"
+	KeyLifecycleFile        string = "key-lifecycle.json"
+	ValidatorSnapshotsFile  string = "validator-snapshots.jsonl"
+	AlertsFile              string = "alerts.jsonl"
+	RelayAuditFile          string = "relay-audit.jsonl"
+	KeystoreRotationFile    string = "keystore-rotation.json"
+	KeyRecoveryFile         string = "key-recovery.json"
+	VaultRatesFile          string = "vault-rates.jsonl"
+	DefaultApiPort          uint16 = 8180
+	RelayLogName            string = "relay.log"
+	DefaultRelayPort        uint16 = 18180
+	DefaultMetricsPort      uint16 = 9182
 
 	// Volumes
 	DataVolume string = "swdata"
@@ -30,6 +30,66 @@ type StakeWiseConfig struct {
 	// Toggle for verifying deposit data Merkle roots before saving
 	VerifyDepositsRoot config.Parameter[bool]
 
+	// Toggle for preparing eligible keys and deposit data for the relay in the background
+	RelayPrepareKeys config.Parameter[bool]
+
+	// Toggle for automatically generating new validator keys when the number of available keys runs low
+	AutoGenerateKeys config.Parameter[bool]
+
+	// The number of available keys that triggers automatic key generation when the pool drops below it
+	KeyLowWaterMark config.Parameter[uint64]
+
+	// The number of available keys that automatic key generation will try to maintain
+	KeyTargetCount config.Parameter[uint64]
+
+	// Toggle for restarting the VC after automatically generating new keys
+	AutoGenerateKeysRestartVc config.Parameter[bool]
+
+	// Toggle for serving Prometheus metrics from the daemon
+	EnableMetrics config.Parameter[bool]
+
+	// Port to serve the daemon's Prometheus metrics on
+	MetricsPort config.Parameter[uint16]
+
+	// The number of blocks behind the chain head that deposit event and Keeper config scans consider settled
+	ScanConfirmationDepth config.Parameter[uint64]
+
+	// Toggle for using the finalized block as the settled block for deposit event scans instead of the confirmation depth
+	ScanUseFinalizedBlock config.Parameter[bool]
+
+	// The number of blocks to look back over when estimating vault APRs
+	VaultAprWindow config.Parameter[uint64]
+
+	// The number of days of validator balance snapshots to keep for performance tracking
+	PerformanceRetentionDays config.Parameter[uint64]
+
+	// URL to POST alerts to as JSON; blank disables webhook alerts
+	AlertWebhookUrl config.Parameter[string]
+
+	// SMTP server (host:port) to email alerts through; blank disables email alerts
+	AlertSmtpServer config.Parameter[string]
+
+	// Username for the SMTP server, if it requires authentication
+	AlertSmtpUsername config.Parameter[string]
+
+	// Password for the SMTP server, if it requires authentication
+	AlertSmtpPassword config.Parameter[string]
+
+	// Address to send alert emails from
+	AlertSmtpFrom config.Parameter[string]
+
+	// Comma-separated addresses to send alert emails to
+	AlertSmtpTo config.Parameter[string]
+
+	// Toggle for writing alerts to a file in the module's data directory
+	EnableAlertFile config.Parameter[bool]
+
+	// The number of minutes a client can be out of sync before an alert is sent
+	AlertClientSyncThreshold config.Parameter[uint64]
+
+	// URL of a Web3Signer-compatible remote signer to hold the validator keys; blank keeps them in local keystores
+	RemoteSignerUrl config.Parameter[string]
+
 	// The Docker Hub tag for the Stakewise daemon
 	DaemonContainerTag config.Parameter[string]
 
@@ -105,7 +165,7 @@ func NewStakeWiseConfig(hdCfg *hdconfig.HyperdriveConfig, networks []*StakeWiseS
 			ParameterCommon: &config.ParameterCommon{
 				ID:                 ids.VerifyDepositRootsID,
 				Name:               "Verify Deposits Root",
-				Description:        "Enable this to verify that the Merkle root of aggregated deposit data returned by the NodeSet server matches the Merkle root stored in the NodeSet vault contract. This is a safety mechanism to ensure the Stakewise Operator container won't try to submit deposits for validators that the NodeSet vault hasn't verified yet.\n\n[orange]Don't disable this unless you know what you're doing.",
+				Description:        "Enable this to verify that the Merkle root of aggregated deposit data returned by the NodeSet server matches the Merkle root stored in the NodeSet vault contract. Vaults that don't store a Merkle root only need the NodeSet signature, so they aren't checked. This is a safety mechanism to ensure the Stakewise Operator container won't try to submit deposits for validators that the NodeSet vault hasn't verified yet.\n\n[orange]Don't disable this unless you know what you're doing.",
 				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
 				CanBeBlank:         false,
 				OverwriteOnUpgrade: false,
@@ -115,6 +175,286 @@ func NewStakeWiseConfig(hdCfg *hdconfig.HyperdriveConfig, networks []*StakeWiseS
 			},
 		},
 
+		RelayPrepareKeys: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.RelayPrepareKeysID,
+				Name:               "Prepare Relay Keys",
+				Description:        "Enable this to have the relay check which of your keys are eligible for new deposits and build their deposit data in the background every block, so requests from the StakeWise Operator can be answered right away instead of waiting on the checks.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: true,
+			},
+		},
+
+		AutoGenerateKeys: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AutoGenerateKeysID,
+				Name:               "Auto-Generate Keys",
+				Description:        "Enable this to have the StakeWise daemon automatically generate new validator keys in the background whenever the number of keys available for new deposits drops below the Key Low-Water Mark. It will generate enough keys to get back up to the Key Target Count, but never more than NodeSet will let your node register.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
+		KeyLowWaterMark: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyLowWaterMarkID,
+				Name:               "Key Low-Water Mark",
+				Description:        "When Auto-Generate Keys is enabled, new keys will be generated once the number of keys available for new deposits drops below this number.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 2,
+			},
+		},
+
+		KeyTargetCount: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.KeyTargetCountID,
+				Name:               "Key Target Count",
+				Description:        "When Auto-Generate Keys is enabled, this is the number of keys available for new deposits that the daemon will try to maintain. It must be at least as large as the Key Low-Water Mark.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 5,
+			},
+		},
+
+		AutoGenerateKeysRestartVc: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AutoGenRestartVcID,
+				Name:               "Restart VC After Auto-Generating Keys",
+				Description:        "Enable this to restart the StakeWise Validator Client after each batch of automatically generated keys, so it loads the new keys right away.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: true,
+			},
+		},
+
+		EnableMetrics: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.EnableMetricsID,
+				Name:               "Enable Metrics",
+				Description:        "Enable this to have the StakeWise daemon serve Prometheus metrics about the relay, the available keys, and your vaults on the Metrics Port.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
+		MetricsPort: config.Parameter[uint16]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.MetricsPortID,
+				Name:               "Metrics Port",
+				Description:        "The port that the StakeWise daemon should serve its Prometheus metrics on, at the `/metrics` path.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint16{
+				config.Network_All: DefaultMetricsPort,
+			},
+		},
+
+		ScanConfirmationDepth: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.ScanConfirmationsID,
+				Name:               "Deposit Scan Confirmation Depth",
+				Description:        "The number of blocks behind the head of the chain that the StakeWise daemon treats as settled when scanning for deposit events. Events in newer blocks still block their keys from being used, but those blocks are scanned again next time in case a chain reorg removes them. StakeWise Keeper oracle config updates are only recorded once they're this deep as well.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 64,
+			},
+		},
+
+		ScanUseFinalizedBlock: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.ScanUseFinalizedID,
+				Name:               "Use Finalized Block for Deposit Scans",
+				Description:        "Enable this to treat only finalized blocks as settled when scanning for deposit events, instead of using the Deposit Scan Confirmation Depth.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
+		VaultAprWindow: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.VaultAprWindowID,
+				Name:               "Vault APR Window",
+				Description:        "The number of blocks to look back over when estimating the APR of each vault in the vault report. The estimate compares the vault's exchange rate now with its rate at the start of the window. The daemon records each vault's rate about once an hour, so the APR is available once it has been running for the length of the window; until then, it's only available if your Execution Client still has the state for the start of the window (which usually needs an archive node).",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 50400,
+			},
+		},
+
+		PerformanceRetentionDays: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.PerfRetentionDaysID,
+				Name:               "Performance History Days",
+				Description:        "The number of days of validator balance and status snapshots the StakeWise daemon keeps for tracking validator performance. Older snapshots are deleted automatically.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 30,
+			},
+		},
+
+		RemoteSignerUrl: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.RemoteSignerUrlID,
+				Name:               "Remote Signer URL",
+				Description:        "The URL of a Web3Signer-compatible remote signer to hold your validator keys. New keys will be imported into it instead of being saved as local keystores, your Validator Client will sign with it, and deposit and exit signatures will be requested from it. Leave this blank to keep your keys in local keystores.\n\n[orange]NOTE: Keys that were already saved locally are not moved to the remote signer automatically.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon, ContainerID_StakewiseValidator},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AlertWebhookUrl: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertWebhookUrlID,
+				Name:               "Alert Webhook URL",
+				Description:        "The URL the StakeWise daemon should POST alerts to as JSON, such as relay failures or slashed validators. Leave this blank to disable webhook alerts.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AlertSmtpServer: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertSmtpServerID,
+				Name:               "Alert SMTP Server",
+				Description:        "The SMTP server to send alert emails through, in host:port format. Leave this blank to disable email alerts.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AlertSmtpUsername: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertSmtpUsernameID,
+				Name:               "Alert SMTP Username",
+				Description:        "The username to log into the SMTP server with. Leave this blank if the server doesn't require authentication.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AlertSmtpPassword: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertSmtpPasswordID,
+				Name:               "Alert SMTP Password",
+				Description:        "The password to log into the SMTP server with.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AlertSmtpFrom: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertSmtpFromID,
+				Name:               "Alert Email Sender",
+				Description:        "The address alert emails should be sent from.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		AlertSmtpTo: config.Parameter[string]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertSmtpToID,
+				Name:               "Alert Email Recipients",
+				Description:        "The addresses alert emails should be sent to, separated by commas.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         true,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]string{
+				config.Network_All: "",
+			},
+		},
+
+		EnableAlertFile: config.Parameter[bool]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.EnableAlertFileID,
+				Name:               "Enable Alert File",
+				Description:        "Enable this to append every alert to a JSON lines file in the StakeWise daemon's data directory.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]bool{
+				config.Network_All: false,
+			},
+		},
+
+		AlertClientSyncThreshold: config.Parameter[uint64]{
+			ParameterCommon: &config.ParameterCommon{
+				ID:                 ids.AlertSyncThresholdID,
+				Name:               "Client Sync Alert Threshold",
+				Description:        "The number of minutes your Execution Client or Beacon Node can be out of sync before the StakeWise daemon sends an alert.",
+				AffectsContainers:  []config.ContainerID{ContainerID_StakeWiseDaemon},
+				CanBeBlank:         false,
+				OverwriteOnUpgrade: false,
+			},
+			Default: map[config.Network]uint64{
+				config.Network_All: 30,
+			},
+		},
+
 		DaemonContainerTag: config.Parameter[string]{
 			ParameterCommon: &config.ParameterCommon{
 				ID:                 ids.DaemonContainerTagID,
@@ -190,6 +530,26 @@ func (cfg *StakeWiseConfig) GetParameters() []config.IParameter {
 		&cfg.ApiPort,
 		&cfg.RelayPort,
 		&cfg.VerifyDepositsRoot,
+		&cfg.RelayPrepareKeys,
+		&cfg.AutoGenerateKeys,
+		&cfg.KeyLowWaterMark,
+		&cfg.KeyTargetCount,
+		&cfg.AutoGenerateKeysRestartVc,
+		&cfg.EnableMetrics,
+		&cfg.MetricsPort,
+		&cfg.ScanConfirmationDepth,
+		&cfg.ScanUseFinalizedBlock,
+		&cfg.VaultAprWindow,
+		&cfg.PerformanceRetentionDays,
+		&cfg.AlertWebhookUrl,
+		&cfg.AlertSmtpServer,
+		&cfg.AlertSmtpUsername,
+		&cfg.AlertSmtpPassword,
+		&cfg.AlertSmtpFrom,
+		&cfg.AlertSmtpTo,
+		&cfg.EnableAlertFile,
+		&cfg.AlertClientSyncThreshold,
+		&cfg.RemoteSignerUrl,
 		&cfg.DaemonContainerTag,
 		&cfg.OperatorContainerTag,
 		&cfg.AdditionalOpFlags,
@@ -230,6 +590,15 @@ func (cfg *StakeWiseConfig) UpdateDefaults(network config.Network) {
 // Checks to see if the current configuration is valid; if not, returns a list of errors
 func (cfg *StakeWiseConfig) Validate() []string {
 	errors := []string{}
+	if cfg.AutoGenerateKeys.Value && cfg.KeyTargetCount.Value < cfg.KeyLowWaterMark.Value {
+		errors = append(errors, fmt.Sprintf("the %s (%d) must be at least as large as the %s (%d)", cfg.KeyTargetCount.Name, cfg.KeyTargetCount.Value, cfg.KeyLowWaterMark.Name, cfg.KeyLowWaterMark.Value))
+	}
+	if cfg.EnableMetrics.Value && (cfg.MetricsPort.Value == cfg.ApiPort.Value || cfg.MetricsPort.Value == cfg.RelayPort.Value) {
+		errors = append(errors, fmt.Sprintf("the %s (%d) can't be the same as the %s or the %s", cfg.MetricsPort.Name, cfg.MetricsPort.Value, cfg.ApiPort.Name, cfg.RelayPort.Name))
+	}
+	if cfg.AlertSmtpServer.Value != "" && (cfg.AlertSmtpFrom.Value == "" || cfg.AlertSmtpTo.Value == "") {
+		errors = append(errors, fmt.Sprintf("the %s and %s must be set to send alert emails", cfg.AlertSmtpFrom.Name, cfg.AlertSmtpTo.Name))
+	}
 	return errors
 }
 
@@ -2,6 +2,7 @@ package swconfig
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rocket-pool/node-manager-core/config"
 )
@@ -62,20 +63,56 @@ func (cfg *StakeWiseConfig) GetVcContainerTag() string {
 	}
 }
 
-// Gets the additional flags of the selected VC
+// Gets the additional flags of the selected VC, including the flags for the remote signer if one is set
 func (cfg *StakeWiseConfig) GetVcAdditionalFlags() string {
+	var flags string
 	bn := cfg.hdCfg.GetSelectedBeaconNode()
 	switch bn {
 	case config.BeaconNode_Lighthouse:
-		return cfg.Lighthouse.AdditionalFlags.Value
+		flags = cfg.Lighthouse.AdditionalFlags.Value
 	case config.BeaconNode_Lodestar:
-		return cfg.Lodestar.AdditionalFlags.Value
+		flags = cfg.Lodestar.AdditionalFlags.Value
 	case config.BeaconNode_Nimbus:
-		return cfg.Nimbus.AdditionalFlags.Value
+		flags = cfg.Nimbus.AdditionalFlags.Value
 	case config.BeaconNode_Prysm:
-		return cfg.Prysm.AdditionalFlags.Value
+		flags = cfg.Prysm.AdditionalFlags.Value
 	case config.BeaconNode_Teku:
-		return cfg.Teku.AdditionalFlags.Value
+		flags = cfg.Teku.AdditionalFlags.Value
+	default:
+		panic(fmt.Sprintf("Unknown Beacon Node %s", bn))
+	}
+
+	signerFlags := cfg.GetVcRemoteSignerFlags()
+	if signerFlags == "" {
+		return flags
+	}
+	return strings.TrimSpace(flags + " " + signerFlags)
+}
+
+// Check if the validator keys are held by a remote signer instead of local keystores
+func (cfg *StakeWiseConfig) IsRemoteSignerEnabled() bool {
+	return cfg.RemoteSignerUrl.Value != ""
+}
+
+// Gets the flags that point the selected VC at the remote signer, or a blank string if there isn't one.
+// Lighthouse doesn't need any since the daemon adds each key to its validator definitions file.
+func (cfg *StakeWiseConfig) GetVcRemoteSignerFlags() string {
+	if !cfg.IsRemoteSignerEnabled() {
+		return ""
+	}
+	url := strings.TrimSuffix(cfg.RemoteSignerUrl.Value, "/")
+	bn := cfg.hdCfg.GetSelectedBeaconNode()
+	switch bn {
+	case config.BeaconNode_Lighthouse:
+		return ""
+	case config.BeaconNode_Lodestar:
+		return fmt.Sprintf("--externalSigner.url=%s --externalSigner.fetch", url)
+	case config.BeaconNode_Nimbus:
+		return fmt.Sprintf("--web3-signer-url=%s", url)
+	case config.BeaconNode_Prysm:
+		return fmt.Sprintf("--validators-external-signer-url=%s --validators-external-signer-public-keys=%s/api/v1/eth2/publicKeys", url, url)
+	case config.BeaconNode_Teku:
+		return fmt.Sprintf("--validators-external-signer-url=%s --validators-external-signer-public-keys=external-signer", url)
 	default:
 		panic(fmt.Sprintf("Unknown Beacon Node %s", bn))
 	}
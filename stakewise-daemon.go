@@ -16,6 +16,7 @@ import (
 	"github.com/nodeset-org/hyperdrive-daemon/shared/auth"
 	hdconfig "github.com/nodeset-org/hyperdrive-daemon/shared/config"
 	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/nodeset-org/hyperdrive-stakewise/metrics"
 	"github.com/nodeset-org/hyperdrive-stakewise/relay"
 	"github.com/nodeset-org/hyperdrive-stakewise/server"
 	swshared "github.com/nodeset-org/hyperdrive-stakewise/shared"
@@ -194,6 +195,18 @@ func main() {
 		}
 		fmt.Printf("Relay server started on %s:%d\n", ip, relayPort)
 
+		// Start the metrics server if it's enabled
+		var metricsServer *metrics.MetricsServer
+		swCfg := stakewiseSp.GetConfig()
+		if swCfg.EnableMetrics.Value {
+			metricsServer = metrics.NewMetricsServer(stakewiseSp, ip, swCfg.MetricsPort.Value)
+			err = metricsServer.Start(stopWg)
+			if err != nil {
+				return fmt.Errorf("error starting metrics server: %w", err)
+			}
+			fmt.Printf("Metrics server started on %s:%d\n", ip, metricsServer.GetPort())
+		}
+
 		// Handle process closures
 		termListener := make(chan os.Signal, 1)
 		signal.Notify(termListener, os.Interrupt, syscall.SIGTERM)
@@ -206,6 +219,12 @@ func main() {
 			if err != nil {
 				fmt.Printf("WARNING: relay server didn't shutdown cleanly: %s\n", err.Error())
 			}
+			if metricsServer != nil {
+				err = metricsServer.Stop()
+				if err != nil {
+					fmt.Printf("WARNING: metrics server didn't shutdown cleanly: %s\n", err.Error())
+				}
+			}
 		}()
 
 		// Run the daemon until closed
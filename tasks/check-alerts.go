@@ -0,0 +1,178 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/rocket-pool/node-manager-core/eth"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// The number of consecutive snapshots a validator has to lose balance across before it's considered offline
+	offlineSnapshotCount int = 3
+)
+
+// Check the node for problems that need the operator's attention and send alerts for them
+type CheckAlertsTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+
+	// Validators that have been alerted as offline or slashed, so the alerts can be resolved when they recover
+	// and slashings are only alerted once
+	offline map[string]bool
+	slashed map[string]bool
+}
+
+// Create a new alert check task
+func NewCheckAlertsTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *CheckAlertsTask {
+	return &CheckAlertsTask{
+		ctx:     ctx,
+		logger:  logger,
+		sp:      sp,
+		offline: map[string]bool{},
+		slashed: map[string]bool{},
+	}
+}
+
+// Run the alert checks
+func (t *CheckAlertsTask) Run() error {
+	alertMgr := t.sp.GetAlertManager()
+	if !alertMgr.IsEnabled() {
+		return nil
+	}
+	t.logger.Debug("Checking for alerts...")
+
+	err := t.checkBalance(alertMgr)
+	if err != nil {
+		return err
+	}
+	t.checkValidators(alertMgr)
+
+	// The key pool check needs NodeSet
+	err = t.sp.RequireRegisteredWithNodeSet(t.ctx)
+	if err != nil {
+		t.logger.Debug("Node is not registered with NodeSet yet, skipping key pool check", log.Err(err))
+		return nil
+	}
+	return t.checkKeyPool(alertMgr)
+}
+
+// Alert if the node's wallet can't cover the cost of another validator deposit
+func (t *CheckAlertsTask) checkBalance(alertMgr *swcommon.AlertManager) error {
+	walletResponse, err := t.sp.GetHyperdriveClient().Wallet.Status()
+	if err != nil {
+		return fmt.Errorf("error getting wallet status: %w", err)
+	}
+	walletStatus := walletResponse.Data.WalletStatus
+	if !walletStatus.Address.HasAddress {
+		return nil
+	}
+	nodeAddress := walletStatus.Address.NodeAddress
+	balance, err := t.sp.GetEthClient().BalanceAt(t.ctx, nodeAddress, nil)
+	if err != nil {
+		return fmt.Errorf("error getting node balance: %w", err)
+	}
+
+	subject := nodeAddress.Hex()
+	if balance.Cmp(eth.EthToWei(swcommon.ValidatorDepositCost)) >= 0 {
+		alertMgr.Resolve(swcommon.AlertType_LowBalance, subject)
+		return nil
+	}
+	alertMgr.Send(t.logger.Logger, swcommon.Alert{
+		Type:     swcommon.AlertType_LowBalance,
+		Severity: swcommon.AlertSeverity_Warning,
+		Subject:  subject,
+		Title:    "Node wallet balance is too low for validator deposits",
+		Message:  fmt.Sprintf("The node wallet %s has %.6f ETH, but each validator deposit costs %.6f ETH.", subject, eth.WeiToEth(balance), swcommon.ValidatorDepositCost),
+	})
+	return nil
+}
+
+// Alert if any of the validators in the latest snapshots have been slashed or appear to be offline
+func (t *CheckAlertsTask) checkValidators(alertMgr *swcommon.AlertManager) {
+	snapshots := t.sp.GetValidatorSnapshotStore().GetLatestSnapshots(offlineSnapshotCount)
+	if len(snapshots) == 0 {
+		return
+	}
+	latest := snapshots[len(snapshots)-1]
+
+	// Slashings - a slashing is permanent, so only alert the first time it's seen instead of repeating it
+	slashed := map[string]bool{}
+	for _, entry := range latest.Validators {
+		if !entry.Slashed {
+			continue
+		}
+		pubkey := entry.Pubkey.HexWithPrefix()
+		slashed[pubkey] = true
+		if t.slashed[pubkey] {
+			continue
+		}
+		alertMgr.Send(t.logger.Logger, swcommon.Alert{
+			Type:     swcommon.AlertType_ValidatorSlashed,
+			Severity: swcommon.AlertSeverity_Critical,
+			Subject:  pubkey,
+			Title:    "Validator was slashed",
+			Message:  fmt.Sprintf("Validator %s (index %s) has been slashed as of epoch %d.", pubkey, entry.Index, latest.Epoch),
+		})
+	}
+	for pubkey := range t.slashed {
+		if !slashed[pubkey] {
+			alertMgr.Resolve(swcommon.AlertType_ValidatorSlashed, pubkey)
+		}
+	}
+	t.slashed = slashed
+
+	// Offline validators
+	offline := map[string]bool{}
+	if len(snapshots) == offlineSnapshotCount {
+		for _, validator := range swcommon.GetOfflineValidators(snapshots) {
+			pubkey := validator.HexWithPrefix()
+			offline[pubkey] = true
+			alertMgr.Send(t.logger.Logger, swcommon.Alert{
+				Type:     swcommon.AlertType_ValidatorOffline,
+				Severity: swcommon.AlertSeverity_Critical,
+				Subject:  pubkey,
+				Title:    "Validator appears to be offline",
+				Message:  fmt.Sprintf("Validator %s has lost balance in each of the last %d snapshots (epochs %d to %d), so it's probably missing attestations.", pubkey, offlineSnapshotCount-1, snapshots[0].Epoch, latest.Epoch),
+			})
+		}
+	}
+	for pubkey := range t.offline {
+		if !offline[pubkey] {
+			alertMgr.Resolve(swcommon.AlertType_ValidatorOffline, pubkey)
+		}
+	}
+	t.offline = offline
+}
+
+// Alert if there are no unused keys left while NodeSet still has room for more validators
+func (t *CheckAlertsTask) checkKeyPool(alertMgr *swcommon.AlertManager) error {
+	unusedCount := 0
+	for _, count := range t.sp.GetAvailableKeyManager().GetPoolCounts() {
+		unusedCount += count
+	}
+	if unusedCount > 0 {
+		alertMgr.Resolve(swcommon.AlertType_KeyPoolExhausted, "")
+		return nil
+	}
+
+	nodeSetLimit, err := getNodeSetAvailableValidators(t.sp)
+	if err != nil {
+		return err
+	}
+	if nodeSetLimit == 0 {
+		alertMgr.Resolve(swcommon.AlertType_KeyPoolExhausted, "")
+		return nil
+	}
+	alertMgr.Send(t.logger.Logger, swcommon.Alert{
+		Type:     swcommon.AlertType_KeyPoolExhausted,
+		Severity: swcommon.AlertSeverity_Warning,
+		Subject:  "",
+		Title:    "No validator keys are available",
+		Message:  fmt.Sprintf("The node has no unused validator keys, but NodeSet can still accept %d more validators. Generate more keys so the StakeWise Operator can keep registering validators.", nodeSetLimit),
+	})
+	return nil
+}
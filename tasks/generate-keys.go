@@ -0,0 +1,128 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Generate new validator keys when the number of available keys runs low
+type GenerateKeysTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new key generation task
+func NewGenerateKeysTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *GenerateKeysTask {
+	return &GenerateKeysTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Top up the available keys if the pool has dropped below the low-water mark
+func (t *GenerateKeysTask) Run() error {
+	cfg := t.sp.GetConfig()
+	if !cfg.AutoGenerateKeys.Value {
+		return nil
+	}
+	t.logger.Info("Checking for available keys...")
+
+	// Get the number of unused keys across all pools
+	keyMgr := t.sp.GetAvailableKeyManager()
+	unusedCount := 0
+	for _, count := range keyMgr.GetPoolCounts() {
+		unusedCount += count
+	}
+	lowWaterMark := int(cfg.KeyLowWaterMark.Value)
+	if unusedCount >= lowWaterMark {
+		t.logger.Debug("Enough keys are available, no need to generate more", "available", unusedCount, "lowWaterMark", lowWaterMark)
+		return nil
+	}
+
+	// Get the number of validators NodeSet will still let the node register
+	err := t.sp.RequireRegisteredWithNodeSet(t.ctx)
+	if err != nil {
+		t.logger.Info("Node is not registered with NodeSet yet, skipping key generation", log.Err(err))
+		return nil
+	}
+	nodeSetLimit, err := getNodeSetAvailableValidators(t.sp)
+	if err != nil {
+		return err
+	}
+
+	// Figure out how many keys to make
+	targetCount := int(cfg.KeyTargetCount.Value)
+	if targetCount > nodeSetLimit {
+		t.logger.Debug("Clamping key target count to NodeSet limit", "target", targetCount, "nodeSetLimit", nodeSetLimit)
+		targetCount = nodeSetLimit
+	}
+	newKeyCount := targetCount - unusedCount
+	if newKeyCount <= 0 {
+		t.logger.Info("NodeSet can't accept any more validators than the keys already available, skipping key generation", "available", unusedCount, "nodeSetLimit", nodeSetLimit)
+		return nil
+	}
+
+	// Generate the keys
+	t.logger.Info("Available keys are below the low-water mark, generating new keys", "available", unusedCount, "lowWaterMark", lowWaterMark, "count", newKeyCount)
+	wallet := t.sp.GetWallet()
+	for i := 0; i < newKeyCount; i++ {
+		key, err := wallet.GenerateNewValidatorKey(common.Address{})
+		if err != nil {
+			return fmt.Errorf("error generating validator key: %w", err)
+		}
+		pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+		t.logger.Info("Generated new validator key", "pubkey", pubkey.HexWithPrefix())
+	}
+
+	// Restart the VC so it picks up the new keys
+	if cfg.AutoGenerateKeysRestartVc.Value {
+		t.logger.Info("Restarting the Validator Client to load the new keys...")
+		_, err = t.sp.GetHyperdriveClient().Service.RestartContainer(string(swconfig.ContainerID_StakewiseValidator))
+		if err != nil {
+			return fmt.Errorf("error restarting the Validator Client: %w", err)
+		}
+	}
+	t.logger.Info("Key generation complete", "count", newKeyCount)
+	return nil
+}
+
+// Get the total number of validators NodeSet will let the node register across all of the deployment's vaults
+func getNodeSetAvailableValidators(sp swcommon.IStakeWiseServiceProvider) (int, error) {
+	hd := sp.GetHyperdriveClient()
+	res := sp.GetResources()
+
+	// Get the vaults
+	response, err := hd.NodeSet_StakeWise.GetVaults(res.DeploymentName)
+	if err != nil {
+		return 0, fmt.Errorf("error getting vaults from NodeSet: %w", err)
+	}
+	if response.Data.NotRegistered || response.Data.InvalidPermissions {
+		return 0, nil
+	}
+
+	// Add up the available validators for each one
+	total := 0
+	for _, vault := range response.Data.Vaults {
+		vaultCfg := res.GetVault(vault.Address)
+		if vaultCfg != nil && !vaultCfg.Enabled {
+			continue
+		}
+		infoResponse, err := hd.NodeSet_StakeWise.GetValidatorsInfo(res.DeploymentName, vault.Address)
+		if err != nil {
+			return 0, fmt.Errorf("error getting validators info for vault [%s] from NodeSet: %w", vault.Address.Hex(), err)
+		}
+		if infoResponse.Data.NotRegistered {
+			return 0, nil
+		}
+		total += infoResponse.Data.AvailableValidators
+	}
+	return total, nil
+}
@@ -0,0 +1,56 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+const (
+	// The number of blocks between vault exchange rate samples, about an hour on Mainnet
+	vaultRateSampleInterval uint64 = 300
+)
+
+// Record the exchange rate of each vault periodically, so vault APRs can be estimated without an archive node
+type RecordVaultRatesTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new vault rate task
+func NewRecordVaultRatesTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *RecordVaultRatesTask {
+	return &RecordVaultRatesTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Record a new sample if enough blocks have passed since the last one
+func (t *RecordVaultRatesTask) Run() error {
+	err := t.sp.RequireRegisteredWithNodeSet(t.ctx)
+	if err != nil {
+		t.logger.Debug("Node is not registered with NodeSet yet, skipping vault rate sample", log.Err(err))
+		return nil
+	}
+
+	// Check if a sample is due
+	currentBlock, err := t.sp.GetEthClient().BlockNumber(t.ctx)
+	if err != nil {
+		return fmt.Errorf("error getting current block number: %w", err)
+	}
+	latestBlock, hasSamples := t.sp.GetVaultRateStore().GetLatestBlock()
+	if hasSamples && currentBlock < latestBlock+vaultRateSampleInterval {
+		return nil
+	}
+	t.logger.Debug("Recording vault exchange rates...", "block", currentBlock)
+
+	err = swcommon.RecordVaultRates(t.ctx, t.sp)
+	if err != nil {
+		return fmt.Errorf("error recording vault exchange rates: %w", err)
+	}
+	return nil
+}
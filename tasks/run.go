@@ -2,6 +2,7 @@ package swtasks
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
@@ -19,7 +20,7 @@ const (
 	tasksInterval time.Duration = time.Minute * 5
 
 	// Time between individual tasks
-	//taskCooldown time.Duration = time.Second
+	taskCooldown time.Duration = time.Second
 
 	// Time to wait if the tasks loop isn't ready before checking again
 	notReadySleepTime time.Duration = time.Second * 15
@@ -46,10 +47,20 @@ type TaskLoop struct {
 	wg     *sync.WaitGroup
 
 	// Tasks
+	generateKeys        *GenerateKeysTask
+	scanDepositEvents   *ScanDepositEventsTask
+	updateKeyLifecycles *UpdateKeyLifecyclesTask
+	updateOracleConfig  *UpdateOracleConfigTask
+	snapshotValidators  *SnapshotValidatorsTask
+	checkAlerts         *CheckAlertsTask
+	updateVaultMetrics  *UpdateVaultMetricsTask
+	recordVaultRates    *RecordVaultRatesTask
 
 	// Internal
 	wasExecutionClientSynced bool
 	wasBeaconClientSynced    bool
+	executionUnsyncedSince   time.Time
+	beaconUnsyncedSince      time.Time
 }
 
 func NewTaskLoop(sp swcommon.IStakeWiseServiceProvider, wg *sync.WaitGroup) *TaskLoop {
@@ -61,6 +72,15 @@ func NewTaskLoop(sp swcommon.IStakeWiseServiceProvider, wg *sync.WaitGroup) *Tas
 		ctx:    ctx,
 		wg:     wg,
 
+		generateKeys:        NewGenerateKeysTask(ctx, sp, logger),
+		scanDepositEvents:   NewScanDepositEventsTask(ctx, sp, logger),
+		updateKeyLifecycles: NewUpdateKeyLifecyclesTask(ctx, sp, logger),
+		updateOracleConfig:  NewUpdateOracleConfigTask(ctx, sp, logger),
+		snapshotValidators:  NewSnapshotValidatorsTask(ctx, sp, logger),
+		checkAlerts:         NewCheckAlertsTask(ctx, sp, logger),
+		updateVaultMetrics:  NewUpdateVaultMetricsTask(ctx, sp, logger),
+		recordVaultRates:    NewRecordVaultRatesTask(ctx, sp, logger),
+
 		wasExecutionClientSynced: true,
 		wasBeaconClientSynced:    true,
 	}
@@ -94,16 +114,6 @@ func (t *TaskLoop) Run() error {
 		}
 	}()
 
-	/*
-		// Run metrics loop
-		go func() {
-			err := runMetricsServer(sp, log.NewColorLogger(MetricsColor), stateLocker)
-			if err != nil {
-				errorLog.Println(err)
-			}
-			wg.Done()
-		}()
-	*/
 	return nil
 }
 
@@ -167,12 +177,15 @@ func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
 		}
 		t.wasExecutionClientSynced = false
 		t.logger.Error("Execution Client not synced. Waiting for sync...", slog.String(log.ErrorKey, errMsg))
+		t.checkClientSyncAlert("execution", &t.executionUnsyncedSince, errMsg)
 		return t.sleepAndReturnReadyResult()
 	}
 
 	if !t.wasExecutionClientSynced {
 		t.logger.Info("Execution Client is now synced.")
 		t.wasExecutionClientSynced = true
+		t.executionUnsyncedSince = time.Time{}
+		t.sp.GetAlertManager().Resolve(swcommon.AlertType_ClientOutOfSync, "execution")
 	}
 
 	// Check the BC status
@@ -185,12 +198,15 @@ func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
 		// NOTE: if not synced, it returns an error - so there isn't necessarily an underlying issue
 		t.wasBeaconClientSynced = false
 		t.logger.Error("Beacon Node not synced. Waiting for sync...", slog.String(log.ErrorKey, errMsg))
+		t.checkClientSyncAlert("beacon", &t.beaconUnsyncedSince, errMsg)
 		return t.sleepAndReturnReadyResult()
 	}
 
 	if !t.wasBeaconClientSynced {
 		t.logger.Info("Beacon Node is now synced.")
 		t.wasBeaconClientSynced = true
+		t.beaconUnsyncedSince = time.Time{}
+		t.sp.GetAlertManager().Resolve(swcommon.AlertType_ClientOutOfSync, "beacon")
 	}
 
 	// Wait until the Stakewise wallet has been initialized
@@ -215,6 +231,26 @@ func (t *TaskLoop) waitUntilReady() waitUntilReadyResult {
 	return waitUntilReadySuccess
 }
 
+// Send an alert if a client has been out of sync for longer than the configured threshold
+func (t *TaskLoop) checkClientSyncAlert(client string, unsyncedSince *time.Time, errMsg string) {
+	if unsyncedSince.IsZero() {
+		*unsyncedSince = time.Now()
+		return
+	}
+	threshold := time.Duration(t.sp.GetConfig().AlertClientSyncThreshold.Value) * time.Minute
+	elapsed := time.Since(*unsyncedSince)
+	if elapsed < threshold {
+		return
+	}
+	t.sp.GetAlertManager().Send(t.logger.Logger, swcommon.Alert{
+		Type:     swcommon.AlertType_ClientOutOfSync,
+		Severity: swcommon.AlertSeverity_Critical,
+		Subject:  client,
+		Title:    fmt.Sprintf("The %s client is out of sync", client),
+		Message:  fmt.Sprintf("The %s client has been out of sync for %s, so the StakeWise module can't process validators: %s", client, elapsed.Round(time.Minute), errMsg),
+	})
+}
+
 // Sleep on the context for the not-ready sleep time, and return either exit or continue
 // based on whether the context was cancelled.
 func (t *TaskLoop) sleepAndReturnReadyResult() waitUntilReadyResult {
@@ -228,5 +264,69 @@ func (t *TaskLoop) sleepAndReturnReadyResult() waitUntilReadyResult {
 // Runs an iteration of the node tasks.
 // Returns true if the task loop should exit, false if it should continue.
 func (t *TaskLoop) runTasks() bool {
+	// Top up the available keys
+	if err := t.generateKeys.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Scan for deposit events, including lookback scans for any new keys
+	if err := t.scanDepositEvents.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Follow deposited keys through the Beacon chain
+	if err := t.updateKeyLifecycles.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Follow the StakeWise Keeper's oracle config
+	if err := t.updateOracleConfig.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Record the validator balances for performance tracking
+	if err := t.snapshotValidators.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Send alerts for any problems that need attention
+	if err := t.checkAlerts.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Update the vault metrics
+	if err := t.updateVaultMetrics.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
+	// Record the vault exchange rates for APR estimates
+	if err := t.recordVaultRates.Run(); err != nil {
+		t.logger.Error(err.Error())
+	}
+	if utils.SleepWithCancel(t.ctx, taskCooldown) {
+		return true
+	}
+
 	return utils.SleepWithCancel(t.ctx, tasksInterval)
 }
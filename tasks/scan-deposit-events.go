@@ -0,0 +1,88 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	batch "github.com/rocket-pool/batch-query"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Keep the available key manager's deposit event scan up to date so the relay doesn't have to do it
+type ScanDepositEventsTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new deposit event scan task
+func NewScanDepositEventsTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *ScanDepositEventsTask {
+	return &ScanDepositEventsTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Bring the deposit event index up to date, then scan the blocks since the last scan for deposit events, running a lookback scan if any keys need one
+func (t *ScanDepositEventsTask) Run() error {
+	// Get the current block number
+	currentBlock, err := t.sp.GetEthClient().BlockNumber(t.ctx)
+	if err != nil {
+		return fmt.Errorf("error getting current block number: %w", err)
+	}
+
+	// Update the index - this can take a while the first time it runs
+	index := t.sp.GetDepositEventIndex()
+	if index.GetNextBlock() <= currentBlock {
+		t.logger.Info("Updating deposit event index...", "from", index.GetNextBlock(), "to", currentBlock)
+		start := time.Now()
+		err = index.Sync(t.ctx, t.logger.Logger, currentBlock)
+		if err != nil {
+			return fmt.Errorf("error updating deposit event index: %w", err)
+		}
+		t.logger.Info("Deposit event index updated", "elapsed", time.Since(start))
+	}
+
+	keyMgr := t.sp.GetAvailableKeyManager()
+	if !keyMgr.HasKeyCandidates() {
+		return nil
+	}
+	t.logger.Info("Scanning for deposit events...")
+
+	// Get the current Beacon deposit root
+	var depositRoot common.Hash
+	err = t.sp.GetQueryManager().Query(func(mc *batch.MultiCaller) error {
+		t.sp.GetBeaconDepositContract().GetDepositRoot(mc, &depositRoot)
+		return nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("error getting latest Beacon deposit root: %w", err)
+	}
+
+	// Run the scan - the task loop already made sure the clients are synced
+	start := time.Now()
+	doLookback := keyMgr.RequiresLookbackScan(currentBlock)
+	if doLookback {
+		t.logger.Info("Lookback scan required for new keys or old data, starting scan")
+	}
+	eligibleKeys, ineligibleKeys, err := keyMgr.GetAvailableKeys(t.ctx, t.logger.Logger, depositRoot, currentBlock, swcommon.GetAvailableKeyOptions{
+		SkipSyncCheck:  true,
+		DoLookbackScan: doLookback,
+	})
+	if err != nil {
+		return fmt.Errorf("error scanning for deposit events: %w", err)
+	}
+	t.logger.Info(
+		"Deposit event scan complete",
+		"lookback", doLookback,
+		"block", currentBlock,
+		"available", len(eligibleKeys),
+		"ineligible", len(ineligibleKeys),
+		"elapsed", time.Since(start),
+	)
+	return nil
+}
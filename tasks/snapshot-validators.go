@@ -0,0 +1,92 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Take a snapshot of the balance and status of each NodeSet-registered validator for performance tracking
+type SnapshotValidatorsTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new validator snapshot task
+func NewSnapshotValidatorsTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *SnapshotValidatorsTask {
+	return &SnapshotValidatorsTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Take a new snapshot if the chain has moved to a new epoch since the last one
+func (t *SnapshotValidatorsTask) Run() error {
+	err := t.sp.RequireRegisteredWithNodeSet(t.ctx)
+	if err != nil {
+		t.logger.Debug("Node is not registered with NodeSet yet, skipping validator snapshot", log.Err(err))
+		return nil
+	}
+
+	// Check if a snapshot is due
+	bn := t.sp.GetBeaconClient()
+	head, err := bn.GetBeaconHead(t.ctx)
+	if err != nil {
+		return fmt.Errorf("error getting Beacon head: %w", err)
+	}
+	store := t.sp.GetValidatorSnapshotStore()
+	latestEpoch, hasSnapshots := store.GetLatestEpoch()
+	if hasSnapshots && head.Epoch <= latestEpoch {
+		return nil
+	}
+	t.logger.Debug("Taking validator snapshot...", "epoch", head.Epoch)
+
+	// Get the registered validators
+	pubkeys, err := swcommon.GetRegisteredPubkeys(t.sp)
+	if err != nil {
+		return err
+	}
+	if len(pubkeys) == 0 {
+		return nil
+	}
+
+	// Record their balances and statuses
+	statuses, err := bn.GetValidatorStatuses(t.ctx, pubkeys, nil)
+	if err != nil {
+		return fmt.Errorf("error getting validator statuses: %w", err)
+	}
+	snapshot := swcommon.ValidatorSnapshot{
+		Epoch:      head.Epoch,
+		Time:       time.Now().UTC(),
+		Validators: make([]swcommon.ValidatorSnapshotEntry, 0, len(pubkeys)),
+	}
+	for _, pubkey := range pubkeys {
+		status, exists := statuses[pubkey]
+		if !exists || !status.Exists {
+			continue
+		}
+		snapshot.Validators = append(snapshot.Validators, swcommon.ValidatorSnapshotEntry{
+			Pubkey:                pubkey,
+			Index:                 status.Index,
+			Status:                status.Status,
+			Balance:               status.Balance,
+			EffectiveBalance:      status.EffectiveBalance,
+			Slashed:               status.Slashed,
+			WithdrawalCredentials: status.WithdrawalCredentials,
+		})
+	}
+	if len(snapshot.Validators) == 0 {
+		return nil
+	}
+	err = store.AddSnapshot(snapshot)
+	if err != nil {
+		return fmt.Errorf("error saving validator snapshot: %w", err)
+	}
+	t.logger.Debug("Validator snapshot saved", "epoch", snapshot.Epoch, "validators", len(snapshot.Validators))
+	return nil
+}
@@ -0,0 +1,68 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Follow deposited keys through the Beacon chain so their lifecycles reflect when they become pending, active, and exited
+type UpdateKeyLifecyclesTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new key lifecycle task
+func NewUpdateKeyLifecyclesTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *UpdateKeyLifecyclesTask {
+	return &UpdateKeyLifecyclesTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Check the Beacon status of every key that has been deposited but hasn't exited yet, and record any state changes
+func (t *UpdateKeyLifecyclesTask) Run() error {
+	lifecycleMgr := t.sp.GetKeyLifecycleManager()
+	pubkeys := lifecycleMgr.GetPubkeysInStates(
+		swapi.KeyLifecycleState_Deposited,
+		swapi.KeyLifecycleState_Pending,
+		swapi.KeyLifecycleState_Active,
+	)
+	if len(pubkeys) == 0 {
+		return nil
+	}
+	t.logger.Debug("Updating key lifecycles...", "keys", len(pubkeys))
+
+	// Get the statuses from Beacon
+	statuses, err := t.sp.GetBeaconClient().GetValidatorStatuses(t.ctx, pubkeys, nil)
+	if err != nil {
+		return fmt.Errorf("error getting validator statuses for key lifecycles: %w", err)
+	}
+
+	// Record the new states - keys that aren't on Beacon yet stay where they are
+	transitions := make([]swcommon.KeyTransition, 0, len(statuses))
+	for _, pubkey := range pubkeys {
+		status, exists := statuses[pubkey]
+		if !exists || !status.Exists {
+			continue
+		}
+		transitions = append(transitions, swcommon.KeyTransition{
+			Pubkey: pubkey,
+			Event: swapi.KeyLifecycleEvent{
+				State:          swcommon.GetKeyLifecycleStateForStatus(status.Status),
+				ValidatorIndex: status.Index,
+			},
+		})
+	}
+	err = lifecycleMgr.RecordTransitions(transitions)
+	if err != nil {
+		return fmt.Errorf("error recording key lifecycles: %w", err)
+	}
+	t.logger.Debug("Key lifecycles updated", "keys", len(pubkeys), "onBeacon", len(transitions))
+	return nil
+}
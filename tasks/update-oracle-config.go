@@ -0,0 +1,106 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Follow the StakeWise Keeper's config updates and oracle set changes
+type UpdateOracleConfigTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new oracle config task
+func NewUpdateOracleConfigTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *UpdateOracleConfigTask {
+	return &UpdateOracleConfigTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Scan the Keeper for new config events and send alerts for any changes to the oracle set or thresholds
+func (t *UpdateOracleConfigTask) Run() error {
+	if t.sp.GetResources().Keeper == (common.Address{}) {
+		return nil
+	}
+
+	// Get the current block number
+	currentBlock, err := t.sp.GetEthClient().BlockNumber(t.ctx)
+	if err != nil {
+		return fmt.Errorf("error getting current block number: %w", err)
+	}
+
+	// Run the scan - this can take a while the first time it runs
+	oracleMgr := t.sp.GetOracleConfigManager()
+	firstScan := !oracleMgr.IsScanned()
+	if firstScan {
+		t.logger.Info("Scanning StakeWise Keeper for the oracle config...")
+	}
+	start := time.Now()
+	changes, err := oracleMgr.Sync(t.ctx, t.logger.Logger, currentBlock)
+	if err != nil {
+		return fmt.Errorf("error updating oracle config: %w", err)
+	}
+	if firstScan {
+		config, _ := oracleMgr.GetOracleConfig()
+		t.logger.Info("Oracle config loaded",
+			"oracles", len(config.Oracles),
+			"validatorsMinOracles", config.ValidatorsMinOracles,
+			"rewardsMinOracles", config.RewardsMinOracles,
+			"elapsed", time.Since(start),
+		)
+		return nil
+	}
+
+	// Report the changes since the last scan
+	for _, change := range changes {
+		switch change.Type {
+		case swapi.OracleConfigChangeType_ConfigUpdated:
+			t.logger.Warn("StakeWise Keeper config updated", "block", change.BlockNumber, "ipfsHash", change.ConfigIpfsHash)
+			t.sendAlert(change, swcommon.AlertSeverity_Warning, "StakeWise Keeper config updated",
+				fmt.Sprintf("The StakeWise Keeper published a new config in block %d (IPFS hash %s).", change.BlockNumber, change.ConfigIpfsHash),
+			)
+		case swapi.OracleConfigChangeType_OracleAdded:
+			t.logger.Warn("Oracle added to the StakeWise Keeper", "block", change.BlockNumber, "oracle", change.Oracle.Hex())
+			t.sendAlert(change, swcommon.AlertSeverity_Critical, "Oracle added to the StakeWise Keeper",
+				fmt.Sprintf("Oracle %s was added to the StakeWise Keeper in block %d.", change.Oracle.Hex(), change.BlockNumber),
+			)
+		case swapi.OracleConfigChangeType_OracleRemoved:
+			t.logger.Warn("Oracle removed from the StakeWise Keeper", "block", change.BlockNumber, "oracle", change.Oracle.Hex())
+			t.sendAlert(change, swcommon.AlertSeverity_Critical, "Oracle removed from the StakeWise Keeper",
+				fmt.Sprintf("Oracle %s was removed from the StakeWise Keeper in block %d.", change.Oracle.Hex(), change.BlockNumber),
+			)
+		case swapi.OracleConfigChangeType_ThresholdsUpdated:
+			t.logger.Warn("StakeWise Keeper oracle thresholds updated", "block", change.BlockNumber, "validatorsMinOracles", change.ValidatorsMinOracles, "rewardsMinOracles", change.RewardsMinOracles)
+			t.sendAlert(change, swcommon.AlertSeverity_Critical, "StakeWise Keeper oracle thresholds updated",
+				fmt.Sprintf("As of block %d, the StakeWise Keeper needs %d oracle signatures for validator approvals and %d for rewards updates.", change.BlockNumber, change.ValidatorsMinOracles, change.RewardsMinOracles),
+			)
+		}
+	}
+	return nil
+}
+
+// Send an alert about a change to the Keeper's config.
+// Each change gets its own subject so it's delivered once, even if another change of the same type happened recently.
+func (t *UpdateOracleConfigTask) sendAlert(change swapi.OracleConfigChange, severity swcommon.AlertSeverity, title string, message string) {
+	subject := fmt.Sprintf("%s/%d", change.Type, change.BlockNumber)
+	if change.Oracle != nil {
+		subject = fmt.Sprintf("%s/%s", subject, change.Oracle.Hex())
+	}
+	t.sp.GetAlertManager().Send(t.logger.Logger, swcommon.Alert{
+		Type:     swcommon.AlertType_OracleConfig,
+		Severity: severity,
+		Subject:  subject,
+		Title:    title,
+		Message:  message,
+	})
+}
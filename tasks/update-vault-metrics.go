@@ -0,0 +1,53 @@
+package swtasks
+
+import (
+	"context"
+	"fmt"
+
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	swapi "github.com/nodeset-org/hyperdrive-stakewise/shared/api"
+	"github.com/rocket-pool/node-manager-core/log"
+)
+
+// Refresh the per-vault validator counts and balances in the daemon's metrics
+type UpdateVaultMetricsTask struct {
+	ctx    context.Context
+	logger *log.Logger
+	sp     swcommon.IStakeWiseServiceProvider
+}
+
+// Create a new vault metrics task
+func NewUpdateVaultMetricsTask(ctx context.Context, sp swcommon.IStakeWiseServiceProvider, logger *log.Logger) *UpdateVaultMetricsTask {
+	return &UpdateVaultMetricsTask{
+		ctx:    ctx,
+		logger: logger,
+		sp:     sp,
+	}
+}
+
+// Get the latest vault status and record it in the metrics
+func (t *UpdateVaultMetricsTask) Run() error {
+	if !t.sp.GetConfig().EnableMetrics.Value {
+		return nil
+	}
+	t.logger.Debug("Updating vault metrics...")
+
+	err := t.sp.RequireRegisteredWithNodeSet(t.ctx)
+	if err != nil {
+		t.logger.Debug("Node is not registered with NodeSet yet, skipping vault metrics", log.Err(err))
+		return nil
+	}
+
+	var data swapi.NetworkStatusData
+	err = swcommon.GetNetworkStatus(t.sp, &data)
+	if err != nil {
+		return fmt.Errorf("error getting vault status for metrics: %w", err)
+	}
+
+	metrics := t.sp.GetMetricsManager()
+	for _, vault := range data.Vaults {
+		metrics.RecordVault(vault.Address, vault.Name, vault.RegisteredValidators, vault.MaxValidators, vault.Balance)
+	}
+	t.logger.Debug("Vault metrics updated", "vaults", len(data.Vaults))
+	return nil
+}
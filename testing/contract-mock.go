@@ -0,0 +1,190 @@
+package testing
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EVM opcodes used by the mock contract
+const (
+	opStop         byte = 0x00
+	opSub          byte = 0x03
+	opEq           byte = 0x14
+	opShr          byte = 0x1c
+	opCallDataLoad byte = 0x35
+	opCallDataSize byte = 0x36
+	opCallDataCopy byte = 0x37
+	opCodeCopy     byte = 0x39
+	opJumpI        byte = 0x57
+	opJumpDest     byte = 0x5b
+	opPush1        byte = 0x60
+	opPush2        byte = 0x61
+	opPush4        byte = 0x63
+	opDup1         byte = 0x80
+	opSwap1        byte = 0x90
+	opLog1         byte = 0xa1
+	opLog2         byte = 0xa2
+	opReturn       byte = 0xf3
+)
+
+// Functions every mock contract has for emitting events.
+// The calldata after the selector is the topics, 32 bytes each, followed by the raw event data.
+const (
+	mockLog1Signature string = "mockLog1()"
+	mockLog2Signature string = "mockLog2()"
+)
+
+// Handlers for the event functions
+var (
+	// Log the data after the first topic with LOG1
+	mockLog1Handler []byte = []byte{
+		opJumpDest,
+		opPush1, 0x04, opCallDataLoad, // topic 0
+		opCallDataSize, opPush1, 0x24, opSwap1, opSub, // data size
+		opDup1, opPush1, 0x24, opPush1, 0x00, opCallDataCopy, // copy the data to memory
+		opPush1, 0x00, opLog1,
+		opStop,
+	}
+
+	// Log the data after the first two topics with LOG2
+	mockLog2Handler []byte = []byte{
+		opJumpDest,
+		opPush1, 0x24, opCallDataLoad, // topic 1
+		opPush1, 0x04, opCallDataLoad, // topic 0
+		opCallDataSize, opPush1, 0x44, opSwap1, opSub, // data size
+		opDup1, opPush1, 0x44, opPush1, 0x00, opCallDataCopy, // copy the data to memory
+		opPush1, 0x00, opLog2,
+		opStop,
+	}
+)
+
+// Replace the code at the provided address with a mock contract that returns canned responses.
+// Responses are keyed by function signature, such as "balanceOf(address,uint256)", and hold the ABI-encoded return data.
+// Calls to any other function succeed without returning anything, so transactions that aren't mocked can still be simulated.
+// The contract can also emit events with EmitMockEvent.
+// The code is part of the chain state, so it's removed when the test manager reverts to an earlier snapshot.
+func (m *StakeWiseTestManager) SetMockContract(address common.Address, responses map[string][]byte) error {
+	code := buildMockContractCode(responses)
+	err := m.GetHardhatRpcClient().Call(nil, "hardhat_setCode", address, hexutil.Encode(code))
+	if err != nil {
+		return fmt.Errorf("error setting mock contract code at [%s]: %w", address.Hex(), err)
+	}
+	return nil
+}
+
+// Emit an event from a mock contract made with SetMockContract, then commit a block so it's included.
+// The topics include the event ID, and can have one or two entries. The data is logged as-is, so it should already be ABI-encoded.
+func (m *StakeWiseTestManager) EmitMockEvent(address common.Address, topics []common.Hash, data []byte) error {
+	var signature string
+	switch len(topics) {
+	case 1:
+		signature = mockLog1Signature
+	case 2:
+		signature = mockLog2Signature
+	default:
+		return fmt.Errorf("mock contracts can only emit events with 1 or 2 topics, but %d were provided", len(topics))
+	}
+	calldata := crypto.Keccak256([]byte(signature))[:4]
+	for _, topic := range topics {
+		calldata = append(calldata, topic[:]...)
+	}
+	calldata = append(calldata, data...)
+
+	// Send it from one of Hardhat's unlocked accounts
+	client := m.GetHardhatRpcClient()
+	var accounts []common.Address
+	err := client.Call(&accounts, "eth_accounts")
+	if err != nil {
+		return fmt.Errorf("error getting Hardhat accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return fmt.Errorf("hardhat doesn't have any unlocked accounts")
+	}
+	var txHash common.Hash
+	err = client.Call(&txHash, "eth_sendTransaction", map[string]any{
+		"from": accounts[0],
+		"to":   address,
+		"data": hexutil.Encode(calldata),
+		"gas":  hexutil.Uint64(100000),
+	})
+	if err != nil {
+		return fmt.Errorf("error emitting mock event from [%s]: %w", address.Hex(), err)
+	}
+	return m.CommitBlock()
+}
+
+// Assemble the runtime code for a mock contract.
+// The code dispatches on the function selector, copying the matching response out of the end of the code and returning it.
+func buildMockContractCode(responses map[string][]byte) []byte {
+	// Sort the signatures so the code is deterministic
+	signatures := make([]string, 0, len(responses))
+	for signature := range responses {
+		signatures = append(signatures, signature)
+	}
+	sort.Strings(signatures)
+
+	// Get the layout
+	const headerSize = 6    // PUSH1 0, CALLDATALOAD, PUSH1 0xe0, SHR
+	const dispatchSize = 11 // DUP1, PUSH4 selector, EQ, PUSH2 dest, JUMPI
+	const handlerSize = 16  // JUMPDEST, PUSH2 size, PUSH2 offset, PUSH1 0, CODECOPY, PUSH2 size, PUSH1 0, RETURN
+	logHandlers := map[string][]byte{
+		mockLog1Signature: mockLog1Handler,
+		mockLog2Signature: mockLog2Handler,
+	}
+	logSignatures := []string{mockLog1Signature, mockLog2Signature}
+	logHandlerStart := headerSize + dispatchSize*(len(signatures)+len(logSignatures)) + 1
+	handlerStart := logHandlerStart + len(mockLog1Handler) + len(mockLog2Handler)
+	dataStart := handlerStart + handlerSize*len(signatures)
+
+	// Load the selector
+	code := []byte{opPush1, 0x00, opCallDataLoad, opPush1, 0xe0, opShr}
+
+	// Jump to the handler for the matching selector, or stop if there isn't one
+	addDispatch := func(signature string, dest int) {
+		selector := crypto.Keccak256([]byte(signature))[:4]
+		code = append(code, opDup1, opPush4)
+		code = append(code, selector...)
+		code = append(code, opEq, opPush2)
+		code = binary.BigEndian.AppendUint16(code, uint16(dest))
+		code = append(code, opJumpI)
+	}
+	dest := logHandlerStart
+	for _, signature := range logSignatures {
+		addDispatch(signature, dest)
+		dest += len(logHandlers[signature])
+	}
+	for i, signature := range signatures {
+		addDispatch(signature, handlerStart+handlerSize*i)
+	}
+	code = append(code, opStop)
+
+	// Add the event handlers
+	for _, signature := range logSignatures {
+		code = append(code, logHandlers[signature]...)
+	}
+
+	// Return each response from the data section
+	offset := dataStart
+	for _, signature := range signatures {
+		size := uint16(len(responses[signature]))
+		code = append(code, opJumpDest, opPush2)
+		code = binary.BigEndian.AppendUint16(code, size)
+		code = append(code, opPush2)
+		code = binary.BigEndian.AppendUint16(code, uint16(offset))
+		code = append(code, opPush1, 0x00, opCodeCopy, opPush2)
+		code = binary.BigEndian.AppendUint16(code, size)
+		code = append(code, opPush1, 0x00, opReturn)
+		offset += int(size)
+	}
+
+	// Add the responses
+	for _, signature := range signatures {
+		code = append(code, responses[signature]...)
+	}
+	return code
+}
@@ -152,6 +152,7 @@ func (n *StakeWiseNode) CreateSubNode(hdNode *hdtesting.HyperdriveNode, address
 		return nil, fmt.Errorf("error creating Constellation config: %v", err)
 	}
 	csCfg.ApiPort.Value = port
+	applyTestConfig(csCfg)
 
 	// Make sure the module directory exists
 	moduleDir := filepath.Join(hdCfg.UserDataPath.Value, hdconfig.ModulesName, swconfig.ModuleName)
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/goccy/go-json"
 	"github.com/nodeset-org/hyperdrive-stakewise/relay"
 	swconfig "github.com/nodeset-org/hyperdrive-stakewise/shared/config"
@@ -17,7 +18,7 @@ const (
 )
 
 var (
-	// Code 503 - returned when the server is busy loading private keys or doing lookback scans
+	// Code 503 - returned when the server is busy loading private keys
 	ErrorUnavailable error = fmt.Errorf("server is currently unavailable to handle the request")
 
 	// Code 429 - returned when there's an active request being processed already, since only one can run at a time
@@ -28,6 +29,7 @@ var (
 type OperatorMock struct {
 	endpoint   string
 	res        *swconfig.MergedResources
+	vault      common.Address
 	startIndex int
 	total      int
 }
@@ -41,11 +43,17 @@ func NewOperatorMock(relayEndpoint string, res *swconfig.MergedResources, startI
 	return &OperatorMock{
 		endpoint:   endpoint,
 		res:        res,
+		vault:      res.Vault,
 		startIndex: startIndex,
 		total:      DefaultTotal,
 	}, nil
 }
 
+// Set the vault provided in requests
+func (m *OperatorMock) SetVault(vault common.Address) {
+	m.vault = vault
+}
+
 // Set the start index provided in requests
 func (m *OperatorMock) SetStartIndex(startIndex int) {
 	m.startIndex = startIndex
@@ -59,7 +67,7 @@ func (m *OperatorMock) SetTotal(total int) {
 // Simulate the relay request to create validators and return the response
 func (m *OperatorMock) SubmitValidatorsRequest() (*relay.ValidatorsResponse, error) {
 	request := relay.ValidatorsRequest{
-		Vault:                m.res.Vault,
+		Vault:                m.vault,
 		ValidatorsStartIndex: m.startIndex,
 		ValidatorsBatchSize:  BatchSize,
 		ValidatorsTotal:      m.total,
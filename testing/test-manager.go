@@ -51,6 +51,7 @@ func NewStakeWiseTestManager() (*StakeWiseTestManager, error) {
 		closeTestManager(tm)
 		return nil, fmt.Errorf("error creating StakeWise config: %v", err)
 	}
+	applyTestConfig(swCfg)
 
 	// Make the module directory
 	moduleDir := filepath.Join(hdCfg.UserDataPath.Value, hdconfig.ModulesName, swconfig.ModuleName)
@@ -170,6 +171,42 @@ func (m *StakeWiseTestManager) RevertModuleToSnapshot(moduleState any) error {
 	if err != nil {
 		return fmt.Errorf("error reloading available key manager: %v", err)
 	}
+
+	// Reload the deposit data cache
+	err = m.node.sp.GetDepositDataCache().Reload()
+	if err != nil {
+		return fmt.Errorf("error reloading deposit data cache: %v", err)
+	}
+
+	// Reload the deposit event index
+	err = m.node.sp.GetDepositEventIndex().Reload()
+	if err != nil {
+		return fmt.Errorf("error reloading deposit event index: %v", err)
+	}
+
+	// Reload the key lifecycle manager
+	err = m.node.sp.GetKeyLifecycleManager().Reload()
+	if err != nil {
+		return fmt.Errorf("error reloading key lifecycle manager: %v", err)
+	}
+
+	// Reload the oracle config manager
+	err = m.node.sp.GetOracleConfigManager().Reload()
+	if err != nil {
+		return fmt.Errorf("error reloading oracle config manager: %v", err)
+	}
+
+	// Reload the validator snapshot store
+	err = m.node.sp.GetValidatorSnapshotStore().Reload()
+	if err != nil {
+		return fmt.Errorf("error reloading validator snapshot store: %v", err)
+	}
+
+	// Reload the vault rate store
+	err = m.node.sp.GetVaultRateStore().Reload()
+	if err != nil {
+		return fmt.Errorf("error reloading vault rate store: %v", err)
+	}
 	return nil
 }
 
@@ -12,6 +12,13 @@ const (
 	StakeWiseVaultString string = "0x57ace215eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
 )
 
+// Override the config settings that don't suit the test environment
+func applyTestConfig(cfg *swconfig.StakeWiseConfig) {
+	cfg.VerifyDepositsRoot.Value = false // The test vault is a mock address without a contract; tests that check the root mock it
+	cfg.RelayPrepareKeys.Value = false   // Tests change the chain state between requests, so keys are checked fresh each time
+	cfg.EnableAlertFile.Value = true     // Tests check the alerts that were sent by reading the alerts file
+}
+
 // Returns a new StakewiseResources instance with test network values
 func getTestResources(hdResources *hdconfig.MergedResources, deploymentName string) *swconfig.MergedResources {
 	return &swconfig.MergedResources{
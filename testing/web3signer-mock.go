@@ -0,0 +1,192 @@
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	swcommon "github.com/nodeset-org/hyperdrive-stakewise/common"
+	"github.com/rocket-pool/node-manager-core/beacon"
+	eth2types "github.com/wealdtech/go-eth2-types/v2"
+	eth2ks "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+// Stands in for a Web3Signer remote signer, holding imported keys in memory and signing with them
+type Web3SignerMock struct {
+	server *httptest.Server
+	lock   *sync.Mutex
+	keys   map[beacon.ValidatorPubkey]*eth2types.BLSPrivateKey
+
+	// The sign requests the mock has received
+	signRequests []swcommon.Web3SignerSignRequest
+}
+
+// Creates a new Web3Signer mock and starts serving it on a local port
+func NewWeb3SignerMock() *Web3SignerMock {
+	m := &Web3SignerMock{
+		lock: &sync.Mutex{},
+		keys: map[beacon.ValidatorPubkey]*eth2types.BLSPrivateKey{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/eth2/publicKeys", m.handlePublicKeys)
+	mux.HandleFunc("/api/v1/eth2/sign/", m.handleSign)
+	mux.HandleFunc("/eth/v1/keystores", m.handleImport)
+	m.server = httptest.NewServer(mux)
+	return m
+}
+
+// The URL of the mock
+func (m *Web3SignerMock) GetUrl() string {
+	return m.server.URL
+}
+
+// Stop serving the mock
+func (m *Web3SignerMock) Close() {
+	m.server.Close()
+}
+
+// Check if the mock holds the key for the provided pubkey
+func (m *Web3SignerMock) HasKey(pubkey beacon.ValidatorPubkey) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, exists := m.keys[pubkey]
+	return exists
+}
+
+// Get the sign requests the mock has received
+func (m *Web3SignerMock) GetSignRequests() []swcommon.Web3SignerSignRequest {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	requests := make([]swcommon.Web3SignerSignRequest, len(m.signRequests))
+	copy(requests, m.signRequests)
+	return requests
+}
+
+// Return the pubkeys of every imported key
+func (m *Web3SignerMock) handlePublicKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	m.lock.Lock()
+	pubkeys := make([]string, 0, len(m.keys))
+	for pubkey := range m.keys {
+		pubkeys = append(pubkeys, pubkey.HexWithPrefix())
+	}
+	m.lock.Unlock()
+	writeMockResponse(w, http.StatusOK, pubkeys)
+}
+
+// Sign the request's signing root with the key in the path
+func (m *Web3SignerMock) handleSign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get the key
+	pubkey, err := beacon.HexToValidatorPubkey(strings.TrimPrefix(r.URL.Path, "/api/v1/eth2/sign/"))
+	if err != nil {
+		writeMockResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	m.lock.Lock()
+	key, exists := m.keys[pubkey]
+	m.lock.Unlock()
+	if !exists {
+		writeMockResponse(w, http.StatusNotFound, map[string]string{"error": "public key not found"})
+		return
+	}
+
+	// Sign the root
+	var request swcommon.Web3SignerSignRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeMockResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	m.lock.Lock()
+	m.signRequests = append(m.signRequests, request)
+	m.lock.Unlock()
+	signingRoot := common.HexToHash(request.SigningRoot)
+	signature := beacon.ValidatorSignature(key.Sign(signingRoot[:]).Marshal())
+	writeMockResponse(w, http.StatusOK, swcommon.Web3SignerSignResponse{
+		Signature: signature.HexWithPrefix(),
+	})
+}
+
+// Decrypt and store each keystore in the request
+func (m *Web3SignerMock) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request swcommon.Web3SignerImportRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || len(request.Keystores) != len(request.Passwords) {
+		writeMockResponse(w, http.StatusBadRequest, map[string]string{"error": "invalid import request"})
+		return
+	}
+
+	response := swcommon.Web3SignerImportResponse{
+		Data: make([]swcommon.Web3SignerImportStatus, len(request.Keystores)),
+	}
+	for i, keystoreString := range request.Keystores {
+		key, err := decryptMockKeystore(keystoreString, request.Passwords[i])
+		if err != nil {
+			response.Data[i] = swcommon.Web3SignerImportStatus{
+				Status:  "error",
+				Message: err.Error(),
+			}
+			continue
+		}
+
+		pubkey := beacon.ValidatorPubkey(key.PublicKey().Marshal())
+		m.lock.Lock()
+		_, exists := m.keys[pubkey]
+		m.keys[pubkey] = key
+		m.lock.Unlock()
+		if exists {
+			response.Data[i].Status = "duplicate"
+		} else {
+			response.Data[i].Status = "imported"
+		}
+	}
+	writeMockResponse(w, http.StatusOK, response)
+}
+
+// Decrypt a keystore provided in an import request
+func decryptMockKeystore(keystoreString string, password string) (*eth2types.BLSPrivateKey, error) {
+	var keystore beacon.ValidatorKeystore
+	err := json.Unmarshal([]byte(keystoreString), &keystore)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing keystore: %w", err)
+	}
+	keyBytes, err := eth2ks.New().Decrypt(keystore.Crypto, password)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting keystore: %w", err)
+	}
+	key, err := eth2types.BLSPrivateKeyFromBytes(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error recreating private key: %w", err)
+	}
+	return key, nil
+}
+
+// Serialize a response body and write it
+func writeMockResponse(w http.ResponseWriter, status int, body any) {
+	bytes, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(bytes)
+}